@@ -0,0 +1,87 @@
+package api
+
+import (
+	"crypto/subtle"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/birabittoh/go-lift/src/config"
+	"github.com/birabittoh/go-lift/src/database"
+	g "github.com/birabittoh/go-lift/src/globals"
+)
+
+// publicPath tells whether a path can be reached without signing in: the
+// health check, and calendar feeds, which calendar apps fetch with the secret
+// in their URL.
+func publicPath(path string) bool {
+	return path == "/api/ping" ||
+		strings.HasPrefix(path, "/calendar/") && strings.HasSuffix(path, "/feed.ics")
+}
+
+// metricsPath is scraped with the metrics token when one is set
+const metricsPath = "/metrics"
+
+// validToken tells whether a request carries the bearer token
+func validToken(r *http.Request, token string) bool {
+	got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(got), []byte(token)) == 1
+}
+
+// WithAuth protects a handler according to the auth mode. In proxy mode the
+// server must only be reachable through the proxy, which sets the header.
+// With a metrics token the metrics need it whatever the mode.
+func WithAuth(db *database.Database, next http.Handler) http.Handler {
+	cfg := db.Config
+	if cfg.AuthMode == config.AuthNone && cfg.MetricsToken == "" {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == metricsPath && cfg.MetricsToken != "" {
+			if !validToken(r, cfg.MetricsToken) {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="go-lift"`)
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if cfg.AuthMode == config.AuthNone || publicPath(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		var username string
+		switch cfg.AuthMode {
+		case config.AuthProxy:
+			username = r.Header.Get(cfg.AuthProxyHeader)
+			if username == "" {
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+
+		case config.AuthBasic:
+			var password string
+			var ok bool
+			username, password, ok = r.BasicAuth()
+			if ok {
+				user, err := db.AuthenticateUser(username, password)
+				if err != nil {
+					slog.Error("Authentication failed", "id", RequestID(r.Context()), "error", err)
+					http.Error(w, "Internal server error", http.StatusInternalServerError)
+					return
+				}
+				ok = user != nil
+			}
+			if !ok {
+				w.Header().Set("WWW-Authenticate", `Basic realm="go-lift", charset="UTF-8"`)
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+		}
+
+		next.ServeHTTP(w, g.WithUser(r, username))
+	})
+}
@@ -0,0 +1,51 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/birabittoh/go-lift/src/config"
+	"github.com/birabittoh/go-lift/src/database"
+)
+
+func TestWithAuthMetrics(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	tests := []struct {
+		name    string
+		mode    string
+		token   string
+		path    string
+		headers map[string]string
+		want    int
+	}{
+		{"no auth", config.AuthNone, "", "/metrics", nil, http.StatusOK},
+		{"no auth, missing token", config.AuthNone, "secret", "/metrics", nil, http.StatusUnauthorized},
+		{"no auth, wrong token", config.AuthNone, "secret", "/metrics", map[string]string{"Authorization": "Bearer other"}, http.StatusUnauthorized},
+		{"no auth, token", config.AuthNone, "secret", "/metrics", map[string]string{"Authorization": "Bearer secret"}, http.StatusOK},
+		{"no auth, other path", config.AuthNone, "secret", "/routines", nil, http.StatusOK},
+		{"proxy, signed out", config.AuthProxy, "", "/metrics", nil, http.StatusUnauthorized},
+		{"proxy, signed in", config.AuthProxy, "", "/metrics", map[string]string{"Remote-User": "alice"}, http.StatusOK},
+		{"proxy, token", config.AuthProxy, "secret", "/metrics", map[string]string{"Authorization": "Bearer secret"}, http.StatusOK},
+		{"proxy, signed in without token", config.AuthProxy, "secret", "/metrics", map[string]string{"Remote-User": "alice"}, http.StatusUnauthorized},
+		{"proxy, health check", config.AuthProxy, "secret", "/api/ping", nil, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.Default()
+			cfg.AuthMode, cfg.MetricsToken = tt.mode, tt.token
+			handler := WithAuth(&database.Database{Config: cfg}, ok)
+
+			r := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			for key, value := range tt.headers {
+				r.Header.Set(key, value)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/birabittoh/go-lift/src/database"
+	g "github.com/birabittoh/go-lift/src/globals"
+	"github.com/birabittoh/go-lift/src/ui"
+)
+
+func getBackupArchiveHandler(db *database.Database) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := ui.ServeArchive(w, db); err != nil {
+			jsonError(w, http.StatusInternalServerError, "Failed to export data")
+		}
+	}
+}
+
+func getBackupDatabaseHandler(db *database.Database) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := ui.ServeDatabase(w, r, db); err != nil {
+			jsonError(w, http.StatusInternalServerError, "Failed to back up database")
+		}
+	}
+}
+
+func restoreBackupHandler(db *database.Database) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		mode := r.URL.Query().Get("mode")
+		if mode == "" {
+			mode = database.RestoreMerge
+		}
+
+		if mode == database.RestoreReplace && !db.Config.IsAdmin(g.User(r)) {
+			jsonError(w, http.StatusForbidden, "Only admin users can replace all the data")
+			return
+		}
+
+		archive, err := database.ReadArchive(r.Body)
+		if err != nil {
+			jsonError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		if err := db.RestoreArchive(archive, mode); err != nil {
+			jsonError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		jsonResponse(w, http.StatusOK, map[string]string{"message": "Archive restored"})
+	}
+}
@@ -1,9 +1,14 @@
 package api
 
 import (
+	"bytes"
 	"encoding/json"
+	"fmt"
+	"io"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/birabittoh/go-lift/src/database"
 	g "github.com/birabittoh/go-lift/src/globals"
@@ -28,6 +33,7 @@ func getUserHandler(db *database.Database) http.HandlerFunc {
 			jsonError(w, http.StatusInternalServerError, "Database error")
 			return
 		}
+		user.Units().UserFromMetric(user)
 		jsonResponse(w, http.StatusOK, user)
 	}
 }
@@ -40,12 +46,39 @@ func updateUserHandler(db *database.Database) http.HandlerFunc {
 			return
 		}
 
-		var user database.User
-		if err := json.NewDecoder(r.Body).Decode(&user); err != nil {
+		stored, err := db.GetUserByID(id)
+		if err != nil {
+			if err == gorm.ErrRecordNotFound {
+				jsonError(w, http.StatusNotFound, "User not found")
+				return
+			}
+			jsonError(w, http.StatusInternalServerError, "Database error")
+			return
+		}
+
+		// Fields missing from the body keep their stored value
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			jsonError(w, http.StatusBadRequest, "Failed to read body")
+			return
+		}
+		var fields map[string]json.RawMessage
+		user := *stored
+		if json.Unmarshal(body, &fields) != nil || json.Unmarshal(body, &user) != nil {
 			jsonError(w, http.StatusBadRequest, "Invalid JSON")
 			return
 		}
-		user.ID = uint(id)
+		user.ID = stored.ID
+
+		// Height and weight are expressed in the units sent along with them,
+		// or else in the stored ones
+		units := user.Units()
+		if _, ok := fields["weight"]; ok && user.Weight != nil {
+			*user.Weight = units.WeightToKg(*user.Weight)
+		}
+		if _, ok := fields["height"]; ok && user.Height != nil {
+			*user.Height = units.LengthToCm(*user.Height)
+		}
 		if err := db.UpdateUser(&user); err != nil {
 			if err == gorm.ErrRecordNotFound {
 				jsonError(w, http.StatusNotFound, "User not found")
@@ -54,6 +87,7 @@ func updateUserHandler(db *database.Database) http.HandlerFunc {
 			jsonError(w, http.StatusInternalServerError, "Failed to update user")
 			return
 		}
+		units.UserFromMetric(&user)
 		jsonResponse(w, http.StatusOK, user)
 	}
 }
@@ -85,6 +119,59 @@ func getExerciseHandler(db *database.Database) http.HandlerFunc {
 	}
 }
 
+// getExerciseAlternativesHandler ranks the substitutes of an exercise. The
+// equipment parameter restricts them to a comma-separated list of equipment,
+// available=true to the equipment of the user.
+func getExerciseAlternativesHandler(db *database.Database) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		exercise, err := db.GetExerciseByID(r.PathValue("id"))
+		if err != nil {
+			if err == gorm.ErrRecordNotFound {
+				jsonError(w, http.StatusNotFound, "Exercise not found")
+				return
+			}
+			jsonError(w, http.StatusInternalServerError, "Database error")
+			return
+		}
+
+		query := r.URL.Query()
+		limit := database.MaxAlternatives
+		if value := query.Get("limit"); value != "" {
+			limit, err = strconv.Atoi(value)
+			if err != nil || limit < 1 || limit > 100 {
+				jsonError(w, http.StatusBadRequest, "Invalid limit")
+				return
+			}
+		}
+
+		var available []string
+		if value := query.Get("equipment"); value != "" {
+			for _, e := range strings.Split(value, ",") {
+				available = append(available, strings.ToLower(strings.TrimSpace(e)))
+			}
+		} else if query.Get("available") == "true" {
+			user, err := db.GetUserByID(1)
+			if err != nil {
+				jsonError(w, http.StatusInternalServerError, "Database error")
+				return
+			}
+			inventory, err := db.GetEquipmentInventory(user)
+			if err != nil {
+				jsonError(w, http.StatusInternalServerError, "Database error")
+				return
+			}
+			available = inventory.AvailableEquipment()
+		}
+
+		alternatives, err := db.GetExerciseAlternatives(exercise, available, limit)
+		if err != nil {
+			jsonError(w, http.StatusInternalServerError, "Database error")
+			return
+		}
+		jsonResponse(w, http.StatusOK, alternatives)
+	}
+}
+
 // Routine handlers
 func getRoutinesHandler(db *database.Database) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -114,6 +201,7 @@ func getRoutineHandler(db *database.Database) http.HandlerFunc {
 			jsonError(w, http.StatusInternalServerError, "Database error")
 			return
 		}
+		getUnits(db).RoutineFromMetric(routine)
 		jsonResponse(w, http.StatusOK, routine)
 	}
 }
@@ -126,6 +214,8 @@ func createRoutineHandler(db *database.Database) http.HandlerFunc {
 			return
 		}
 
+		units := getUnits(db)
+		units.RoutineToMetric(&routine)
 		err := db.NewRoutine(&routine)
 		if err != nil {
 			if err == gorm.ErrRecordNotFound {
@@ -135,6 +225,7 @@ func createRoutineHandler(db *database.Database) http.HandlerFunc {
 			jsonError(w, http.StatusInternalServerError, "Failed to create routine")
 			return
 		}
+		units.RoutineFromMetric(&routine)
 		jsonResponse(w, http.StatusCreated, routine)
 	}
 }
@@ -154,6 +245,8 @@ func updateRoutineHandler(db *database.Database) http.HandlerFunc {
 		}
 
 		routine.ID = uint(id)
+		units := getUnits(db)
+		units.RoutineToMetric(&routine)
 		if err := db.UpdateRoutine(&routine); err != nil {
 			if err == gorm.ErrRecordNotFound {
 				jsonError(w, http.StatusNotFound, "Routine not found")
@@ -162,6 +255,7 @@ func updateRoutineHandler(db *database.Database) http.HandlerFunc {
 			jsonError(w, http.StatusInternalServerError, "Failed to update routine")
 			return
 		}
+		units.RoutineFromMetric(&routine)
 		jsonResponse(w, http.StatusOK, routine)
 	}
 }
@@ -186,6 +280,200 @@ func deleteRoutineHandler(db *database.Database) http.HandlerFunc {
 	}
 }
 
+func cloneRoutineHandler(db *database.Database) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := g.GetIDFromPath(r)
+		if err != nil {
+			jsonError(w, http.StatusBadRequest, "Invalid routine ID")
+			return
+		}
+
+		var req struct {
+			Name       string `json:"name"`
+			IsTemplate bool   `json:"isTemplate"`
+		}
+		if r.ContentLength != 0 {
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				jsonError(w, http.StatusBadRequest, "Invalid JSON")
+				return
+			}
+		}
+
+		routine, err := db.GetRoutineByID(id)
+		if err != nil {
+			if err == gorm.ErrRecordNotFound {
+				jsonError(w, http.StatusNotFound, "Routine not found")
+				return
+			}
+			jsonError(w, http.StatusInternalServerError, "Database error")
+			return
+		}
+
+		if req.Name == "" {
+			req.Name = routine.Name
+		}
+
+		clone, err := db.CloneRoutine(routine, req.Name, req.IsTemplate)
+		if err != nil {
+			jsonError(w, http.StatusBadRequest, "Failed to clone routine:", err.Error())
+			return
+		}
+
+		clone, err = db.GetRoutineByID(clone.ID)
+		if err != nil {
+			jsonError(w, http.StatusInternalServerError, "Database error")
+			return
+		}
+		getUnits(db).RoutineFromMetric(clone)
+		jsonResponse(w, http.StatusCreated, clone)
+	}
+}
+
+func getRoutineVersionsHandler(db *database.Database) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := g.GetIDFromPath(r)
+		if err != nil {
+			jsonError(w, http.StatusBadRequest, "Invalid routine ID")
+			return
+		}
+
+		versions, err := db.GetRoutineVersions(id)
+		if err != nil {
+			jsonError(w, http.StatusInternalServerError, "Database error")
+			return
+		}
+
+		units := getUnits(db)
+		for i := range versions {
+			units.SnapshotFromMetric(&versions[i].Snapshot)
+		}
+		jsonResponse(w, http.StatusOK, versions)
+	}
+}
+
+func exportRoutineHandler(db *database.Database) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := g.GetIDFromPath(r)
+		if err != nil {
+			jsonError(w, http.StatusBadRequest, "Invalid routine ID")
+			return
+		}
+
+		format := r.URL.Query().Get("format")
+		if format == "" {
+			format = "json"
+		}
+		if format != "json" && format != "yaml" {
+			jsonError(w, http.StatusBadRequest, "Unsupported format: "+format)
+			return
+		}
+
+		routine, err := db.GetRoutineByID(id)
+		if err != nil {
+			if err == gorm.ErrRecordNotFound {
+				jsonError(w, http.StatusNotFound, "Routine not found")
+				return
+			}
+			jsonError(w, http.StatusInternalServerError, "Database error")
+			return
+		}
+
+		// Documents are always in kg, regardless of the user's units
+		var buf bytes.Buffer
+		if err := database.NewRoutineDocument(routine).Encode(&buf, format); err != nil {
+			jsonError(w, http.StatusInternalServerError, "Failed to export routine")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/"+format)
+		buf.WriteTo(w)
+	}
+}
+
+func importRoutineHandler(db *database.Database) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			jsonError(w, http.StatusBadRequest, "Failed to read request body")
+			return
+		}
+
+		doc, err := database.ParseRoutineDocument(data)
+		if err != nil {
+			jsonError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		result, err := db.ImportRoutine(doc)
+		if err != nil {
+			jsonError(w, http.StatusBadRequest, "Failed to import routine:", err.Error())
+			return
+		}
+
+		result.Routine, err = db.GetRoutineByID(result.Routine.ID)
+		if err != nil {
+			jsonError(w, http.StatusInternalServerError, "Database error")
+			return
+		}
+		getUnits(db).RoutineFromMetric(result.Routine)
+		jsonResponse(w, http.StatusCreated, result)
+	}
+}
+
+func getRoutineEstimateHandler(db *database.Database) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := g.GetIDFromPath(r)
+		if err != nil {
+			jsonError(w, http.StatusBadRequest, "Invalid routine ID")
+			return
+		}
+
+		routine, err := db.GetRoutineByID(id)
+		if err != nil {
+			jsonError(w, http.StatusNotFound, "Routine not found")
+			return
+		}
+
+		estimate, err := db.GetRoutineEstimate(routine)
+		if err != nil {
+			jsonError(w, http.StatusInternalServerError, "Failed to estimate routine:", err.Error())
+			return
+		}
+
+		jsonResponse(w, http.StatusOK, estimate)
+	}
+}
+
+func generateRoutinesHandler(db *database.Database) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var opts database.GeneratorOptions
+		if err := json.NewDecoder(r.Body).Decode(&opts); err != nil {
+			jsonError(w, http.StatusBadRequest, "Invalid JSON")
+			return
+		}
+
+		generated, err := db.GenerateRoutines(opts)
+		if err != nil {
+			jsonError(w, http.StatusBadRequest, "Failed to generate routines:", err.Error())
+			return
+		}
+
+		units := getUnits(db)
+		routines := make([]*database.Routine, 0, len(generated))
+		for _, routine := range generated {
+			loaded, err := db.GetRoutineByID(routine.ID)
+			if err != nil {
+				jsonError(w, http.StatusInternalServerError, "Database error")
+				return
+			}
+			units.RoutineFromMetric(loaded)
+			routines = append(routines, loaded)
+		}
+
+		jsonResponse(w, http.StatusCreated, routines)
+	}
+}
+
 // Record routine handlers (workout sessions)
 func getRecordRoutinesHandler(db *database.Database) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -204,6 +492,11 @@ func getRecordRoutinesHandler(db *database.Database) http.HandlerFunc {
 			return
 		}
 
+		units := getUnits(db)
+		for i := range records {
+			units.RecordRoutineFromMetric(&records[i])
+		}
+
 		jsonResponse(w, http.StatusOK, records)
 	}
 }
@@ -216,13 +509,8 @@ func getRecordRoutineHandler(db *database.Database) http.HandlerFunc {
 			return
 		}
 
-		var record database.RecordRoutine
-		if err := db.Preload("Routine").
-			Preload("RecordItems.RoutineItem").
-			Preload("RecordItems.RecordExerciseItems.ExerciseItem.Exercise").
-			Preload("RecordItems.RecordExerciseItems.RecordSets.Set").
-			Order("RecordItems.order_index, RecordItems.RecordExerciseItems.order_index, RecordItems.RecordExerciseItems.RecordSets.order_index").
-			First(&record, id).Error; err != nil {
+		record, err := db.GetRecordRoutineByID(uint(id))
+		if err != nil {
 			if err == gorm.ErrRecordNotFound {
 				jsonError(w, http.StatusNotFound, "Record not found")
 				return
@@ -231,6 +519,7 @@ func getRecordRoutineHandler(db *database.Database) http.HandlerFunc {
 			return
 		}
 
+		getUnits(db).RecordRoutineFromMetric(record)
 		jsonResponse(w, http.StatusOK, record)
 	}
 }
@@ -243,11 +532,14 @@ func createRecordRoutineHandler(db *database.Database) http.HandlerFunc {
 			return
 		}
 
+		units := getUnits(db)
+		units.RecordRoutineToMetric(&record)
 		if err := db.Create(&record).Error; err != nil {
 			jsonError(w, http.StatusInternalServerError, "Failed to create record")
 			return
 		}
 
+		units.RecordRoutineFromMetric(&record)
 		jsonResponse(w, http.StatusCreated, record)
 	}
 }
@@ -267,11 +559,14 @@ func updateRecordRoutineHandler(db *database.Database) http.HandlerFunc {
 		}
 
 		record.ID = uint(id)
+		units := getUnits(db)
+		units.RecordRoutineToMetric(&record)
 		if err := db.Save(&record).Error; err != nil {
 			jsonError(w, http.StatusInternalServerError, "Failed to update record")
 			return
 		}
 
+		units.RecordRoutineFromMetric(&record)
 		jsonResponse(w, http.StatusOK, record)
 	}
 }
@@ -293,6 +588,239 @@ func deleteRecordRoutineHandler(db *database.Database) http.HandlerFunc {
 	}
 }
 
+type FreestyleRecordRequest struct {
+	Name string `json:"name"`
+}
+
+// createFreestyleRecordHandler starts a workout without a routine
+func createFreestyleRecordHandler(db *database.Database) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req FreestyleRecordRequest
+		if r.ContentLength != 0 {
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				jsonError(w, http.StatusBadRequest, "Invalid JSON")
+				return
+			}
+		}
+
+		record, err := db.NewFreestyleRecordRoutine(req.Name)
+		if err != nil {
+			jsonError(w, http.StatusConflict, err.Error())
+			return
+		}
+
+		jsonResponse(w, http.StatusCreated, record)
+	}
+}
+
+// RecordSessionRequest describes a change to a workout in progress
+type RecordSessionRequest struct {
+	ExerciseID           string `json:"exerciseId"`
+	RecordExerciseItemID uint   `json:"recordExerciseItemId"`
+	RecordRoutineItemID  uint   `json:"recordRoutineItemId"`
+	Skip                 bool   `json:"skip"`
+	Up                   bool   `json:"up"`
+}
+
+// recordSessionHandler applies a change to a workout in progress and returns
+// the updated workout
+func recordSessionHandler(db *database.Database, action func(record *database.RecordRoutine, req RecordSessionRequest) error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := g.GetIDFromPath(r)
+		if err != nil {
+			jsonError(w, http.StatusBadRequest, "Invalid record ID")
+			return
+		}
+
+		var req RecordSessionRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			jsonError(w, http.StatusBadRequest, "Invalid JSON")
+			return
+		}
+
+		record, err := db.GetRecordRoutineByID(id)
+		if err != nil {
+			jsonError(w, http.StatusNotFound, "Record not found")
+			return
+		}
+
+		if err := action(record, req); err != nil {
+			jsonError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		record, err = db.GetRecordRoutineByID(id)
+		if err != nil {
+			jsonError(w, http.StatusInternalServerError, "Database error")
+			return
+		}
+
+		getUnits(db).RecordRoutineFromMetric(record)
+		jsonResponse(w, http.StatusOK, record)
+	}
+}
+
+func addRecordExerciseHandler(db *database.Database) http.HandlerFunc {
+	return recordSessionHandler(db, func(record *database.RecordRoutine, req RecordSessionRequest) error {
+		_, err := db.AddRecordExercise(record, req.ExerciseID)
+		return err
+	})
+}
+
+func addRecordSetHandler(db *database.Database) http.HandlerFunc {
+	return recordSessionHandler(db, func(record *database.RecordRoutine, req RecordSessionRequest) error {
+		_, err := db.AddRecordSet(record, req.RecordExerciseItemID)
+		return err
+	})
+}
+
+func swapRecordExerciseHandler(db *database.Database) http.HandlerFunc {
+	return recordSessionHandler(db, func(record *database.RecordRoutine, req RecordSessionRequest) error {
+		return db.SwapRecordExercise(record, req.RecordExerciseItemID, req.ExerciseID)
+	})
+}
+
+func skipRecordExerciseHandler(db *database.Database) http.HandlerFunc {
+	return recordSessionHandler(db, func(record *database.RecordRoutine, req RecordSessionRequest) error {
+		return db.SkipRecordExercise(record, req.RecordExerciseItemID, req.Skip)
+	})
+}
+
+func moveRecordItemHandler(db *database.Database) http.HandlerFunc {
+	return recordSessionHandler(db, func(record *database.RecordRoutine, req RecordSessionRequest) error {
+		return db.MoveRecordRoutineItem(record, req.RecordRoutineItemID, req.Up)
+	})
+}
+
+// saveRecordAsRoutineHandler creates a routine from a workout
+func saveRecordAsRoutineHandler(db *database.Database) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := g.GetIDFromPath(r)
+		if err != nil {
+			jsonError(w, http.StatusBadRequest, "Invalid record ID")
+			return
+		}
+
+		var req FreestyleRecordRequest
+		if r.ContentLength != 0 {
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				jsonError(w, http.StatusBadRequest, "Invalid JSON")
+				return
+			}
+		}
+
+		record, err := db.GetRecordRoutineByID(id)
+		if err != nil {
+			jsonError(w, http.StatusNotFound, "Record not found")
+			return
+		}
+
+		routine, err := db.SaveRecordAsRoutine(record, req.Name)
+		if err != nil {
+			jsonError(w, http.StatusBadRequest, "Failed to save routine:", err.Error())
+			return
+		}
+
+		routine, err = db.GetRoutineByID(routine.ID)
+		if err != nil {
+			jsonError(w, http.StatusInternalServerError, "Database error")
+			return
+		}
+
+		getUnits(db).RoutineFromMetric(routine)
+		jsonResponse(w, http.StatusCreated, routine)
+	}
+}
+
+// exportRecordsHandler returns the sets of the finished workouts within an
+// optional date range, as CSV in the layout of Strong (default) or Hevy.
+func exportRecordsHandler(db *database.Database) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		from, err := parseDateParam(r, "from", time.Time{})
+		if err != nil {
+			jsonError(w, http.StatusBadRequest, "Invalid from date")
+			return
+		}
+
+		to, err := parseDateParam(r, "to", time.Now())
+		if err != nil {
+			jsonError(w, http.StatusBadRequest, "Invalid to date")
+			return
+		}
+
+		format := r.URL.Query().Get("format")
+		if format == "" {
+			format = database.CSVFormatStrong
+		}
+		if format != database.CSVFormatStrong && format != database.CSVFormatHevy {
+			jsonError(w, http.StatusBadRequest, "Unsupported format: "+format)
+			return
+		}
+
+		records, err := db.GetWorkoutLog(from, to.AddDate(0, 0, 1))
+		if err != nil {
+			jsonError(w, http.StatusInternalServerError, "Database error")
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", "go-lift-workouts-"+format+".csv"))
+		database.WriteWorkoutsCSV(w, records, format, getUnits(db))
+	}
+}
+
+// previewRecordsImportHandler parses a Strong, Hevy or FitNotes CSV export and
+// suggests a catalog exercise for each exercise name, without importing.
+func previewRecordsImportHandler(db *database.Database) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			jsonError(w, http.StatusBadRequest, "Failed to read request body")
+			return
+		}
+
+		imp, err := db.PrepareWorkoutImport(data, r.URL.Query().Get("weightUnit"))
+		if err != nil {
+			jsonError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		jsonResponse(w, http.StatusOK, imp)
+	}
+}
+
+type RecordsImportRequest struct {
+	Data       string            `json:"data"`       // CSV export
+	WeightUnit string            `json:"weightUnit"` // Used when the file does not state it
+	Mapping    map[string]string `json:"mapping"`    // Exercise name to exercise ID, empty to skip
+}
+
+// importRecordsHandler imports the workouts of a CSV export as finished
+// records. Exercise names missing from the mapping use the suggested match.
+func importRecordsHandler(db *database.Database) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req RecordsImportRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			jsonError(w, http.StatusBadRequest, "Invalid JSON")
+			return
+		}
+
+		imp, err := db.PrepareWorkoutImport([]byte(req.Data), req.WeightUnit)
+		if err != nil {
+			jsonError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		result, err := db.ImportWorkouts(imp, req.Mapping)
+		if err != nil {
+			jsonError(w, http.StatusBadRequest, "Failed to import workouts:", err.Error())
+			return
+		}
+
+		jsonResponse(w, http.StatusCreated, result)
+	}
+}
+
 // Stats handler
 func getStatsHandler(db *database.Database) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -302,7 +830,7 @@ func getStatsHandler(db *database.Database) http.HandlerFunc {
 		db.Model(&database.RecordRoutine{}).Count(&stats.TotalWorkouts)
 
 		// Total minutes (sum of all workout durations)
-		var totalSeconds uint
+		var totalSeconds int64
 		db.Model(&database.RecordRoutine{}).Select("COALESCE(SUM(duration), 0)").Scan(&totalSeconds)
 		stats.TotalMinutes = int(totalSeconds / 60)
 
@@ -314,15 +842,12 @@ func getStatsHandler(db *database.Database) http.HandlerFunc {
 			ExerciseName string `json:"exercise_name"`
 			Count        int    `json:"count"`
 		}
-		db.Raw(`
-			SELECT e.name as exercise_name, COUNT(*) as count
-			FROM record_exercise_items rei
-			JOIN exercise_items ei ON rei.exercise_item_id = ei.id
-			JOIN exercises e ON ei.exercise_id = e.id
-			GROUP BY e.id, e.name
-			ORDER BY count DESC
-			LIMIT 1
-		`).Scan(&exerciseStats)
+		db.Model(&database.RecordExerciseItem{}).
+			Select("exercise_name, COUNT(*) AS count").
+			Group("exercise_id, exercise_name").
+			Order("count DESC").
+			Limit(1).
+			Scan(&exerciseStats)
 
 		if exerciseStats.Count > 0 {
 			stats.MostFrequentExercise = &struct {
@@ -339,14 +864,12 @@ func getStatsHandler(db *database.Database) http.HandlerFunc {
 			RoutineName string `json:"routine_name"`
 			Count       int    `json:"count"`
 		}
-		db.Raw(`
-			SELECT r.name as routine_name, COUNT(*) as count
-			FROM record_routines rr
-			JOIN routines r ON rr.routine_id = r.id
-			GROUP BY r.id, r.name
-			ORDER BY count DESC
-			LIMIT 1
-		`).Scan(&routineStats)
+		db.Model(&database.RecordRoutine{}).
+			Select("routine_name, COUNT(*) AS count").
+			Group("routine_id, routine_name").
+			Order("count DESC").
+			Limit(1).
+			Scan(&routineStats)
 
 		if routineStats.Count > 0 {
 			stats.MostFrequentRoutine = &struct {
@@ -364,6 +887,25 @@ func getStatsHandler(db *database.Database) http.HandlerFunc {
 			Limit(5).
 			Find(&stats.RecentWorkouts)
 
+		units := getUnits(db)
+		for i := range stats.RecentWorkouts {
+			units.RecordRoutineFromMetric(&stats.RecentWorkouts[i])
+		}
+
+		// Volume and records include body weight for bodyweight exercises
+		training, err := db.GetTrainingStats()
+		if err != nil {
+			jsonError(w, http.StatusInternalServerError, "Failed to compute training stats")
+			return
+		}
+		stats.TotalVolume = units.WeightFromKg(training.TotalVolume)
+		stats.PersonalRecords = training.PersonalRecords
+		for i := range stats.PersonalRecords {
+			pr := &stats.PersonalRecords[i]
+			pr.HeaviestLoad = units.LoadFromKg(pr.HeaviestLoad)
+			pr.EstimatedOneRepMax = units.WeightFromKg(pr.EstimatedOneRepMax)
+		}
+
 		jsonResponse(w, http.StatusOK, stats)
 	}
 }
@@ -0,0 +1,62 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/birabittoh/go-lift/src/database"
+)
+
+func TestUpdateUserPartially(t *testing.T) {
+	db := newTestDB(t)
+	mux := http.NewServeMux()
+	mux.HandleFunc("PUT /api/users/{id}", updateUserHandler(db))
+
+	put := func(body string) database.User {
+		t.Helper()
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/api/users/1", strings.NewReader(body)))
+		if w.Code != http.StatusOK {
+			t.Fatalf("PUT %s: status %d: %s", body, w.Code, w.Body)
+		}
+		var user database.User
+		if err := json.Unmarshal(w.Body.Bytes(), &user); err != nil {
+			t.Fatal(err)
+		}
+		return user
+	}
+
+	put(`{"name": "Alice", "weight": 176.4, "height": 70, "weightUnit": "lb", "lengthUnit": "in"}`)
+
+	// The name alone keeps the stored units and measurements
+	user := put(`{"name": "Alicia"}`)
+	if user.Name != "Alicia" || user.WeightUnit != "lb" || user.LengthUnit != "in" {
+		t.Errorf("user = %s, %s, %s, want Alicia in lb and in", user.Name, user.WeightUnit, user.LengthUnit)
+	}
+	if user.Weight == nil || *user.Weight != 176.4 || user.Height == nil || *user.Height != 70 {
+		t.Errorf("weight and height = %v, %v, want 176.4 and 70", user.Weight, user.Height)
+	}
+
+	// A weight without units is in the stored ones
+	user = put(`{"weight": 180}`)
+	stored, err := db.GetUserByID(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Name != "Alicia" || stored.Weight == nil || *stored.Weight < 81.6 || *stored.Weight > 81.7 {
+		t.Errorf("stored user = %s, %v kg, want Alicia and 81.6 kg", stored.Name, stored.Weight)
+	}
+	if user.Weight == nil || *user.Weight != 180 {
+		t.Errorf("weight = %v, want 180", user.Weight)
+	}
+
+	// Switching units converts the values sent with them
+	put(`{"weight": 80, "weightUnit": "kg"}`)
+	stored, _ = db.GetUserByID(1)
+	if stored.WeightUnit != "kg" || *stored.Weight != 80 || stored.LengthUnit != "in" {
+		t.Errorf("stored user = %v %s, %s, want 80 kg and in", *stored.Weight, stored.WeightUnit, stored.LengthUnit)
+	}
+}
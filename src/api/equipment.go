@@ -0,0 +1,92 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/birabittoh/go-lift/src/database"
+	g "github.com/birabittoh/go-lift/src/globals"
+	"gorm.io/gorm"
+)
+
+// Equipment handlers
+func getEquipmentHandler(db *database.Database) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := g.GetIDFromPath(r)
+		if err != nil {
+			jsonError(w, http.StatusBadRequest, "Invalid user ID")
+			return
+		}
+
+		user, err := db.GetUserByID(id)
+		if err != nil {
+			if err == gorm.ErrRecordNotFound {
+				jsonError(w, http.StatusNotFound, "User not found")
+				return
+			}
+			jsonError(w, http.StatusInternalServerError, "Database error")
+			return
+		}
+
+		inventory, err := db.GetEquipmentInventory(user)
+		if err != nil {
+			jsonError(w, http.StatusInternalServerError, "Database error")
+			return
+		}
+
+		if err := user.Units().InventoryFromMetric(inventory); err != nil {
+			jsonError(w, http.StatusInternalServerError, "Invalid equipment:", err.Error())
+			return
+		}
+		jsonResponse(w, http.StatusOK, inventory)
+	}
+}
+
+func updateEquipmentHandler(db *database.Database) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := g.GetIDFromPath(r)
+		if err != nil {
+			jsonError(w, http.StatusBadRequest, "Invalid user ID")
+			return
+		}
+
+		user, err := db.GetUserByID(id)
+		if err != nil {
+			if err == gorm.ErrRecordNotFound {
+				jsonError(w, http.StatusNotFound, "User not found")
+				return
+			}
+			jsonError(w, http.StatusInternalServerError, "Database error")
+			return
+		}
+
+		inventory, err := db.GetEquipmentInventory(user)
+		if err != nil {
+			jsonError(w, http.StatusInternalServerError, "Database error")
+			return
+		}
+
+		var update database.EquipmentInventory
+		if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+			jsonError(w, http.StatusBadRequest, "Invalid JSON")
+			return
+		}
+		update.ID = inventory.ID
+		update.UserID = user.ID
+		update.CreatedAt = inventory.CreatedAt
+
+		units := user.Units()
+		if err := units.InventoryToMetric(&update); err != nil {
+			jsonError(w, http.StatusBadRequest, "Invalid equipment:", err.Error())
+			return
+		}
+
+		if err := db.UpdateEquipmentInventory(&update); err != nil {
+			jsonError(w, http.StatusBadRequest, "Failed to update equipment:", err.Error())
+			return
+		}
+
+		units.InventoryFromMetric(&update)
+		jsonResponse(w, http.StatusOK, update)
+	}
+}
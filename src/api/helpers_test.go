@@ -0,0 +1,38 @@
+package api
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/birabittoh/go-lift/src/config"
+	"github.com/birabittoh/go-lift/src/database"
+)
+
+// newTestDB returns a migrated SQLite database in a temporary directory, with
+// a single exercise instead of the catalog
+func newTestDB(t *testing.T) *database.Database {
+	t.Helper()
+
+	cfg := config.Default()
+	cfg.DBPath = filepath.Join(t.TempDir(), "fitness.sqlite")
+	cfg.DBLogLevel = config.LogSilent
+
+	db, err := database.OpenDB(cfg)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	if err := db.MigrateUp(0); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	squat := database.Exercise{ID: "Barbell_Squat", Name: "Barbell Squat", Level: "beginner", Category: "strength"}
+	if err := db.Create(&squat).Error; err != nil {
+		t.Fatalf("failed to add exercise: %v", err)
+	}
+	if err := db.CheckInitialData(); err != nil {
+		t.Fatalf("failed to add initial data: %v", err)
+	}
+
+	return db
+}
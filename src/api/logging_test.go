@@ -0,0 +1,90 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// captureLogs sends the default logger to a buffer for the rest of the test
+func captureLogs(t *testing.T, level slog.Level) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: level})))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+	return &buf
+}
+
+func TestWithRequestLog(t *testing.T) {
+	var seen string
+	handler := WithRequestLog(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = RequestID(r.Context())
+		if r.URL.Path == "/fail" {
+			http.Error(w, "Database error", http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+
+	tests := []struct {
+		name, path, header string
+		keep               bool
+		level              string
+		status             int
+	}{
+		{"proxy ID", "/api/routines", "abc-123.x_y", true, "INFO", http.StatusOK},
+		{"no ID", "/api/routines", "", false, "INFO", http.StatusOK},
+		{"invalid ID", "/api/routines", "bad id\n", false, "INFO", http.StatusOK},
+		{"server error", "/fail", "", false, "ERROR", http.StatusInternalServerError},
+		{"health check", "/api/ping", "", false, "DEBUG", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs := captureLogs(t, slog.LevelDebug)
+
+			r := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.header != "" {
+				r.Header.Set(requestIDHeader, tt.header)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			id := w.Header().Get(requestIDHeader)
+			if !validRequestID.MatchString(id) || (id == tt.header) != tt.keep {
+				t.Errorf("request ID = %q, want the header %q kept: %v", id, tt.header, tt.keep)
+			}
+			if seen != id {
+				t.Errorf("request ID in the context = %q, want %q", seen, id)
+			}
+
+			var entry struct {
+				Level  string
+				Msg    string
+				ID     string
+				Path   string
+				Status int
+				Bytes  int
+			}
+			if err := json.Unmarshal(logs.Bytes(), &entry); err != nil {
+				t.Fatalf("log = %q: %v", logs, err)
+			}
+			if entry.Level != tt.level || entry.ID != id || entry.Path != tt.path || entry.Status != tt.status || entry.Bytes != w.Body.Len() {
+				t.Errorf("log = %+v, want level %s, ID %s, path %s, status %d and %d bytes", entry, tt.level, id, tt.path, tt.status, w.Body.Len())
+			}
+		})
+	}
+}
+
+func TestWithRequestLogSkipsHealthChecks(t *testing.T) {
+	logs := captureLogs(t, slog.LevelInfo)
+	handler := WithRequestLog(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/ping", nil))
+	if logs.Len() != 0 {
+		t.Errorf("health check logged at the info level: %s", logs)
+	}
+}
@@ -0,0 +1,141 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/birabittoh/go-lift/src/database"
+	g "github.com/birabittoh/go-lift/src/globals"
+	"gorm.io/gorm"
+)
+
+type bodyMeasurementRequest struct {
+	Value float64   `json:"value"` // In the metric unit, like the values returned
+	Date  time.Time `json:"date"`
+	Notes string    `json:"notes"`
+}
+
+// Measurement handlers
+func getBodyMetricsHandler(db *database.Database) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		metrics, err := db.GetBodyMetrics()
+		if err != nil {
+			jsonError(w, http.StatusInternalServerError, "Database error")
+			return
+		}
+		for i := range metrics {
+			database.BodyMetricFromCanonical(&metrics[i])
+		}
+		jsonResponse(w, http.StatusOK, metrics)
+	}
+}
+
+func getBodyMetricHandler(db *database.Database) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := g.GetIDFromPath(r)
+		if err != nil {
+			jsonError(w, http.StatusBadRequest, "Invalid metric ID")
+			return
+		}
+
+		metric, err := db.GetBodyMetricByID(id)
+		if err != nil {
+			if err == gorm.ErrRecordNotFound {
+				jsonError(w, http.StatusNotFound, "Metric not found")
+				return
+			}
+			jsonError(w, http.StatusInternalServerError, "Database error")
+			return
+		}
+		database.BodyMetricFromCanonical(metric)
+		jsonResponse(w, http.StatusOK, metric)
+	}
+}
+
+func createBodyMetricHandler(db *database.Database) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var metric database.BodyMetric
+		if err := json.NewDecoder(r.Body).Decode(&metric); err != nil {
+			jsonError(w, http.StatusBadRequest, "Invalid JSON")
+			return
+		}
+
+		if err := db.NewBodyMetric(&metric); err != nil {
+			jsonError(w, http.StatusBadRequest, "Failed to create metric:", err.Error())
+			return
+		}
+		jsonResponse(w, http.StatusCreated, metric)
+	}
+}
+
+func createBodyMeasurementHandler(db *database.Database) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := g.GetIDFromPath(r)
+		if err != nil {
+			jsonError(w, http.StatusBadRequest, "Invalid metric ID")
+			return
+		}
+
+		metric, err := db.GetBodyMetricByID(id)
+		if err != nil {
+			if err == gorm.ErrRecordNotFound {
+				jsonError(w, http.StatusNotFound, "Metric not found")
+				return
+			}
+			jsonError(w, http.StatusInternalServerError, "Database error")
+			return
+		}
+
+		var req bodyMeasurementRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			jsonError(w, http.StatusBadRequest, "Invalid JSON")
+			return
+		}
+
+		measurement, err := db.NewBodyMeasurement(metric, req.Value, req.Date, req.Notes)
+		if err != nil {
+			jsonError(w, http.StatusBadRequest, "Failed to save measurement:", err.Error())
+			return
+		}
+		measurement.Value = database.FromCanonical(measurement.Value, metric.Unit)
+		jsonResponse(w, http.StatusCreated, measurement)
+	}
+}
+
+func deleteBodyMeasurementHandler(db *database.Database) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := g.GetIDFromPath(r)
+		if err != nil {
+			jsonError(w, http.StatusBadRequest, "Invalid measurement ID")
+			return
+		}
+
+		measurement, err := db.GetBodyMeasurementByID(id)
+		if err != nil {
+			if err == gorm.ErrRecordNotFound {
+				jsonError(w, http.StatusNotFound, "Measurement not found")
+				return
+			}
+			jsonError(w, http.StatusInternalServerError, "Database error")
+			return
+		}
+
+		if _, err := db.DeleteBodyMeasurement(measurement); err != nil {
+			jsonError(w, http.StatusInternalServerError, "Failed to delete measurement")
+			return
+		}
+		jsonResponse(w, http.StatusOK, map[string]string{"message": "Measurement deleted"})
+	}
+}
+
+func importBodyMeasurementsHandler(db *database.Database) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		result, err := db.ImportBodyMeasurementsCSV(r.Body)
+		if err != nil {
+			jsonError(w, http.StatusBadRequest, "Failed to import measurements:", err.Error())
+			return
+		}
+		jsonResponse(w, http.StatusOK, result)
+	}
+}
@@ -0,0 +1,41 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/birabittoh/go-lift/src/metrics"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestWithMetrics(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /routines/{id}", func(w http.ResponseWriter, r *http.Request) {
+		if r.PathValue("id") == "0" {
+			http.NotFound(w, r)
+		}
+	})
+	handler := WithMetrics(mux, mux)
+
+	count := func(method, route, status string) float64 {
+		return testutil.ToFloat64(metrics.HTTPRequests.WithLabelValues(method, route, status))
+	}
+	ok := count("GET", "GET /routines/{id}", "200")
+	notFound := count("GET", "GET /routines/{id}", "404")
+	unmatched := count("GET", "unmatched", "404")
+
+	for _, path := range []string{"/routines/1", "/routines/2", "/routines/0", "/nothing/1", "/nothing/2"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	if got := count("GET", "GET /routines/{id}", "200") - ok; got != 2 {
+		t.Errorf("served routines = %v, want 2 counted under the route pattern", got)
+	}
+	if got := count("GET", "GET /routines/{id}", "404") - notFound; got != 1 {
+		t.Errorf("missing routines = %v, want 1", got)
+	}
+	if got := count("GET", "unmatched", "404") - unmatched; got != 2 {
+		t.Errorf("unmatched requests = %v, want 2 counted together", got)
+	}
+}
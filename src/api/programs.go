@@ -0,0 +1,197 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/birabittoh/go-lift/src/database"
+	g "github.com/birabittoh/go-lift/src/globals"
+	"gorm.io/gorm"
+)
+
+// programRequest is the body of program create and update requests
+type programRequest struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	Weeks       []database.ProgramWeek `json:"weeks"`
+	RoutineIDs  []uint                 `json:"routineIds"` // Routines performed every week, in order
+}
+
+// Program handlers
+func getProgramsHandler(db *database.Database) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		programs, err := db.GetPrograms()
+		if err != nil {
+			jsonError(w, http.StatusInternalServerError, "Database error")
+			return
+		}
+		jsonResponse(w, http.StatusOK, programs)
+	}
+}
+
+func getProgramHandler(db *database.Database) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := g.GetIDFromPath(r)
+		if err != nil {
+			jsonError(w, http.StatusBadRequest, "Invalid program ID")
+			return
+		}
+
+		program, err := db.GetProgramByID(id)
+		if err != nil {
+			if err == gorm.ErrRecordNotFound {
+				jsonError(w, http.StatusNotFound, "Program not found")
+				return
+			}
+			jsonError(w, http.StatusInternalServerError, "Database error")
+			return
+		}
+		jsonResponse(w, http.StatusOK, program)
+	}
+}
+
+func createProgramHandler(db *database.Database) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req programRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			jsonError(w, http.StatusBadRequest, "Invalid JSON")
+			return
+		}
+
+		program := &database.Program{
+			Name:        req.Name,
+			Description: req.Description,
+			Weeks:       req.Weeks,
+		}
+		for _, id := range req.RoutineIDs {
+			program.Routines = append(program.Routines, database.ProgramRoutine{RoutineID: id})
+		}
+
+		if err := db.NewProgram(program); err != nil {
+			jsonError(w, http.StatusBadRequest, "Failed to create program:", err.Error())
+			return
+		}
+
+		program, err := db.GetProgramByID(program.ID)
+		if err != nil {
+			jsonError(w, http.StatusInternalServerError, "Database error")
+			return
+		}
+		jsonResponse(w, http.StatusCreated, program)
+	}
+}
+
+func updateProgramHandler(db *database.Database) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := g.GetIDFromPath(r)
+		if err != nil {
+			jsonError(w, http.StatusBadRequest, "Invalid program ID")
+			return
+		}
+
+		var req programRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			jsonError(w, http.StatusBadRequest, "Invalid JSON")
+			return
+		}
+
+		program, err := db.GetProgramByID(id)
+		if err != nil {
+			if err == gorm.ErrRecordNotFound {
+				jsonError(w, http.StatusNotFound, "Program not found")
+				return
+			}
+			jsonError(w, http.StatusInternalServerError, "Database error")
+			return
+		}
+
+		program.Name = req.Name
+		program.Description = req.Description
+		if err := db.UpdateProgram(program); err != nil {
+			jsonError(w, http.StatusBadRequest, "Failed to update program:", err.Error())
+			return
+		}
+
+		if req.Weeks != nil {
+			if err := db.UpdateProgramWeeks(program, req.Weeks); err != nil {
+				jsonError(w, http.StatusBadRequest, "Failed to update program weeks:", err.Error())
+				return
+			}
+		}
+
+		if req.RoutineIDs != nil {
+			if err := db.UpdateProgramRoutines(program, req.RoutineIDs); err != nil {
+				jsonError(w, http.StatusBadRequest, "Failed to update program routines:", err.Error())
+				return
+			}
+		}
+
+		program, err = db.GetProgramByID(id)
+		if err != nil {
+			jsonError(w, http.StatusInternalServerError, "Database error")
+			return
+		}
+		jsonResponse(w, http.StatusOK, program)
+	}
+}
+
+func deleteProgramHandler(db *database.Database) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := g.GetIDFromPath(r)
+		if err != nil {
+			jsonError(w, http.StatusBadRequest, "Invalid program ID")
+			return
+		}
+
+		if err := db.DeleteProgram(id); err != nil {
+			jsonError(w, http.StatusInternalServerError, "Failed to delete program")
+			return
+		}
+		jsonResponse(w, http.StatusOK, map[string]string{"message": "Program deleted"})
+	}
+}
+
+func startProgramHandler(db *database.Database) http.HandlerFunc {
+	return setProgramActive(db, true)
+}
+
+func stopProgramHandler(db *database.Database) http.HandlerFunc {
+	return setProgramActive(db, false)
+}
+
+func setProgramActive(db *database.Database, active bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := g.GetIDFromPath(r)
+		if err != nil {
+			jsonError(w, http.StatusBadRequest, "Invalid program ID")
+			return
+		}
+
+		program, err := db.GetProgramByID(id)
+		if err != nil {
+			if err == gorm.ErrRecordNotFound {
+				jsonError(w, http.StatusNotFound, "Program not found")
+				return
+			}
+			jsonError(w, http.StatusInternalServerError, "Database error")
+			return
+		}
+
+		if active {
+			err = db.StartProgram(program)
+		} else {
+			err = db.StopProgram(program)
+		}
+		if err != nil {
+			jsonError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		program, err = db.GetProgramByID(id)
+		if err != nil {
+			jsonError(w, http.StatusInternalServerError, "Database error")
+			return
+		}
+		jsonResponse(w, http.StatusOK, program)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	"github.com/birabittoh/go-lift/src/database"
+	"github.com/birabittoh/go-lift/src/metrics"
 	"github.com/birabittoh/go-lift/src/ui"
 )
 
@@ -12,30 +13,76 @@ func GetServeMux(db *database.Database) *http.ServeMux {
 
 	mux.HandleFunc("GET /authelia/api/user/info", mockAutheliaHandler)
 
+	mux.Handle("GET /metrics", metrics.Handler())
 	mux.HandleFunc("GET /api/ping", pingHandler)
 	mux.HandleFunc("GET /api/connection", connectionHandler(db))
 
 	// Profile routes
 	mux.HandleFunc("GET /api/users/{id}", getUserHandler(db))
 	mux.HandleFunc("PUT /api/users/{id}", updateUserHandler(db))
+	mux.HandleFunc("GET /api/users/{id}/equipment", getEquipmentHandler(db))
+	mux.HandleFunc("PUT /api/users/{id}/equipment", updateEquipmentHandler(db))
+
+	// Measurements routes
+	mux.HandleFunc("GET /api/measurements", getBodyMetricsHandler(db))
+	mux.HandleFunc("GET /api/measurements/{id}", getBodyMetricHandler(db))
+	mux.HandleFunc("POST /api/measurements", createBodyMetricHandler(db))
+	mux.HandleFunc("POST /api/measurements/import", importBodyMeasurementsHandler(db))
+	mux.HandleFunc("POST /api/measurements/{id}/entries", createBodyMeasurementHandler(db))
+	mux.HandleFunc("DELETE /api/measurements/entries/{id}", deleteBodyMeasurementHandler(db))
 
 	// Exercises routes (read-only)
 	mux.HandleFunc("GET /api/exercises", getExercisesHandler(db))
 	mux.HandleFunc("GET /api/exercises/{id}", getExerciseHandler(db))
+	mux.HandleFunc("GET /api/exercises/{id}/alternatives", getExerciseAlternativesHandler(db))
 
 	// Routines routes
 	mux.HandleFunc("GET /api/routines", getRoutinesHandler(db))
 	mux.HandleFunc("GET /api/routines/{id}", getRoutineHandler(db))
 	mux.HandleFunc("POST /api/routines", createRoutineHandler(db))
+	mux.HandleFunc("POST /api/routines/import", importRoutineHandler(db))
+	mux.HandleFunc("POST /api/routines/generate", generateRoutinesHandler(db))
 	mux.HandleFunc("PUT /api/routines/{id}", updateRoutineHandler(db))
 	mux.HandleFunc("DELETE /api/routines/{id}", deleteRoutineHandler(db))
+	mux.HandleFunc("POST /api/routines/{id}/clone", cloneRoutineHandler(db))
+	mux.HandleFunc("GET /api/routines/{id}/versions", getRoutineVersionsHandler(db))
+	mux.HandleFunc("GET /api/routines/{id}/export", exportRoutineHandler(db))
+	mux.HandleFunc("GET /api/routines/{id}/estimate", getRoutineEstimateHandler(db))
+
+	// Programs routes
+	mux.HandleFunc("GET /api/programs", getProgramsHandler(db))
+	mux.HandleFunc("GET /api/programs/{id}", getProgramHandler(db))
+	mux.HandleFunc("POST /api/programs", createProgramHandler(db))
+	mux.HandleFunc("PUT /api/programs/{id}", updateProgramHandler(db))
+	mux.HandleFunc("DELETE /api/programs/{id}", deleteProgramHandler(db))
+	mux.HandleFunc("POST /api/programs/{id}/start", startProgramHandler(db))
+	mux.HandleFunc("POST /api/programs/{id}/stop", stopProgramHandler(db))
 
 	// Record routines routes (workout sessions)
 	mux.HandleFunc("GET /api/records", getRecordRoutinesHandler(db))
+	mux.HandleFunc("GET /api/records/export", exportRecordsHandler(db))
+	mux.HandleFunc("POST /api/records/import/preview", previewRecordsImportHandler(db))
+	mux.HandleFunc("POST /api/records/import", importRecordsHandler(db))
 	mux.HandleFunc("GET /api/records/{id}", getRecordRoutineHandler(db))
 	mux.HandleFunc("POST /api/records", createRecordRoutineHandler(db))
 	mux.HandleFunc("PUT /api/records/{id}", updateRecordRoutineHandler(db))
 	mux.HandleFunc("DELETE /api/records/{id}", deleteRecordRoutineHandler(db))
+	mux.HandleFunc("POST /api/records/freestyle", createFreestyleRecordHandler(db))
+	mux.HandleFunc("POST /api/records/{id}/exercises", addRecordExerciseHandler(db))
+	mux.HandleFunc("POST /api/records/{id}/sets", addRecordSetHandler(db))
+	mux.HandleFunc("POST /api/records/{id}/swap", swapRecordExerciseHandler(db))
+	mux.HandleFunc("POST /api/records/{id}/skip", skipRecordExerciseHandler(db))
+	mux.HandleFunc("POST /api/records/{id}/move", moveRecordItemHandler(db))
+	mux.HandleFunc("POST /api/records/{id}/routine", saveRecordAsRoutineHandler(db))
+
+	// Schedule routes
+	mux.HandleFunc("GET /api/schedule", getScheduleHandler(db))
+	mux.HandleFunc("GET /api/schedule/today", getTodaysWorkoutHandler(db))
+
+	// Backup routes
+	mux.HandleFunc("GET /api/backup", getBackupArchiveHandler(db))
+	mux.HandleFunc("GET /api/backup/database", getBackupDatabaseHandler(db))
+	mux.HandleFunc("POST /api/backup/restore", restoreBackupHandler(db))
 
 	// Stats routes
 	mux.HandleFunc("GET /api/stats", getStatsHandler(db))
@@ -57,5 +104,7 @@ type WorkoutStats struct {
 		Name  string `json:"name"`
 		Count int    `json:"count"`
 	} `json:"mostFrequentRoutine,omitempty"`
-	RecentWorkouts []database.RecordRoutine `json:"recentWorkouts"`
+	RecentWorkouts  []database.RecordRoutine  `json:"recentWorkouts"`
+	TotalVolume     float64                   `json:"totalVolume"`
+	PersonalRecords []database.PersonalRecord `json:"personalRecords"`
 }
@@ -0,0 +1,70 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/birabittoh/go-lift/src/database"
+)
+
+const dateLayout = "2006-01-02"
+
+// parseDateParam reads an optional YYYY-MM-DD query parameter
+func parseDateParam(r *http.Request, name string, fallback time.Time) (time.Time, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return fallback, nil
+	}
+	return time.ParseInLocation(dateLayout, value, time.Local)
+}
+
+// Schedule handlers
+func getScheduleHandler(db *database.Database) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Default to the current week, Monday to Sunday
+		now := time.Now()
+		monday := now.AddDate(0, 0, -((int(now.Weekday()) + 6) % 7))
+
+		from, err := parseDateParam(r, "from", monday)
+		if err != nil {
+			jsonError(w, http.StatusBadRequest, "Invalid from date")
+			return
+		}
+
+		to, err := parseDateParam(r, "to", from.AddDate(0, 0, 6))
+		if err != nil {
+			jsonError(w, http.StatusBadRequest, "Invalid to date")
+			return
+		}
+
+		if err := database.CheckScheduleRange(from, to); err != nil {
+			jsonError(w, http.StatusBadRequest, "Invalid date range:", err.Error())
+			return
+		}
+
+		schedule, err := db.GetSchedule(from, to)
+		if err != nil {
+			jsonError(w, http.StatusInternalServerError, "Database error")
+			return
+		}
+
+		jsonResponse(w, http.StatusOK, schedule)
+	}
+}
+
+func getTodaysWorkoutHandler(db *database.Database) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		workout, err := db.GetTodaysWorkout()
+		if err != nil {
+			jsonError(w, http.StatusInternalServerError, "Database error")
+			return
+		}
+
+		if workout == nil {
+			jsonError(w, http.StatusNotFound, "No workout scheduled")
+			return
+		}
+
+		jsonResponse(w, http.StatusOK, workout)
+	}
+}
@@ -0,0 +1,43 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGetScheduleStatus(t *testing.T) {
+	db := newTestDB(t)
+	handler := getScheduleHandler(db)
+
+	get := func(query string) int {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/schedule?"+query, nil))
+		return w.Code
+	}
+
+	tests := []struct {
+		name  string
+		query string
+		want  int
+	}{
+		{"current week", "", http.StatusOK},
+		{"range", "from=2024-03-04&to=2024-03-10", http.StatusOK},
+		{"bad date", "from=04/03/2024", http.StatusBadRequest},
+		{"reversed", "from=2024-03-10&to=2024-03-04", http.StatusBadRequest},
+		{"too long", "from=2024-01-01&to=2026-01-01", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := get(tt.query); got != tt.want {
+				t.Errorf("status = %d, want %d", got, tt.want)
+			}
+		})
+	}
+
+	// Failures of the database are not the client's fault
+	db.Close()
+	if got := get("from=2024-03-04&to=2024-03-10"); got != http.StatusInternalServerError {
+		t.Errorf("status with the database closed = %d, want %d", got, http.StatusInternalServerError)
+	}
+}
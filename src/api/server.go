@@ -0,0 +1,81 @@
+package api
+
+import (
+	"fmt"
+	"log/slog"
+	"mime"
+	"net/http"
+	"time"
+
+	"github.com/birabittoh/go-lift/src/database"
+	"github.com/birabittoh/go-lift/src/metrics"
+)
+
+// Timeouts of the HTTP server. Writes are allowed longer than reads, as
+// database backups are streamed to the client.
+const (
+	readHeaderTimeout = 10 * time.Second
+	readTimeout       = time.Minute
+	writeTimeout      = 2 * time.Minute
+	idleTimeout       = 2 * time.Minute
+)
+
+// Request body limits, by content type
+const (
+	maxFormSize   = 1 << 20  // URL-encoded forms
+	maxJSONSize   = 8 << 20  // JSON, which can embed a CSV export to import
+	maxUploadSize = 32 << 20 // Uploaded files, as multipart forms or raw bodies
+)
+
+// bodyLimit is the largest body accepted with a content type
+func bodyLimit(contentType string) int64 {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch mediaType {
+	case "application/x-www-form-urlencoded":
+		return maxFormSize
+	case "application/json":
+		return maxJSONSize
+	default:
+		return maxUploadSize
+	}
+}
+
+// WithBodyLimit rejects request bodies larger than allowed for their content
+// type. Bodies without a length are cut off when they reach the limit, which
+// makes reading them fail.
+func WithBodyLimit(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Body == nil || r.Body == http.NoBody {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		limit := bodyLimit(r.Header.Get("Content-Type"))
+		if r.ContentLength > limit {
+			http.Error(w, fmt.Sprintf("Request body too large, the limit is %d MB", limit>>20), http.StatusRequestEntityTooLarge)
+			return
+		}
+
+		r.Body = http.MaxBytesReader(w, r.Body, limit)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// NewServer returns the HTTP server of the app, with its routes behind
+// metrics, request logging, authentication and body limits
+func NewServer(db *database.Database) *http.Server {
+	if err := metrics.Register(db.MetricsCollector()); err != nil {
+		slog.Warn("Failed to register database metrics", "error", err)
+	}
+
+	mux := GetServeMux(db)
+	return &http.Server{
+		Addr:              db.Config.ListenAddress,
+		Handler:           WithMetrics(mux, WithRequestLog(WithAuth(db, WithBodyLimit(mux)))),
+		ReadHeaderTimeout: readHeaderTimeout,
+		ReadTimeout:       readTimeout,
+		WriteTimeout:      writeTimeout,
+		IdleTimeout:       idleTimeout,
+		ErrorLog:          slog.NewLogLogger(slog.Default().Handler(), slog.LevelWarn),
+	}
+}
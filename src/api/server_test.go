@@ -0,0 +1,76 @@
+package api
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/birabittoh/go-lift/src/database"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestNewServerTwice(t *testing.T) {
+	t.Chdir("../..") // The UI templates are read from the repository root
+	NewServer(newTestDB(t))
+
+	db := newTestDB(t)
+	if err := db.Create(&database.RecordRoutine{RoutineName: "Legs"}).Error; err != nil {
+		t.Fatal(err)
+	}
+	NewServer(db)
+
+	families, err := prometheus.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, family := range families {
+		if family.GetName() == "golift_active_workouts" {
+			if got := family.GetMetric()[0].GetGauge().GetValue(); got != 1 {
+				t.Errorf("active workouts = %v, want 1 from the last server's database", got)
+			}
+			return
+		}
+	}
+	t.Error("no active workouts metric")
+}
+
+func TestWithBodyLimit(t *testing.T) {
+	handler := WithBodyLimit(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := io.ReadAll(r.Body); err != nil {
+			http.Error(w, "Failed to read body", http.StatusBadRequest)
+		}
+	}))
+
+	tests := []struct {
+		name        string
+		contentType string
+		size        int64
+		chunked     bool
+		want        int
+	}{
+		{"form", "application/x-www-form-urlencoded", maxFormSize, false, http.StatusOK},
+		{"large form", "application/x-www-form-urlencoded", maxFormSize + 1, false, http.StatusRequestEntityTooLarge},
+		{"json with charset", "application/json; charset=utf-8", maxFormSize + 1, false, http.StatusOK},
+		{"large json", "application/json", maxJSONSize + 1, false, http.StatusRequestEntityTooLarge},
+		{"upload", "multipart/form-data; boundary=x", maxJSONSize + 1, false, http.StatusOK},
+		{"large upload", "text/csv", maxUploadSize + 1, false, http.StatusRequestEntityTooLarge},
+		{"chunked form", "application/x-www-form-urlencoded", maxFormSize, true, http.StatusOK},
+		{"large chunked form", "application/x-www-form-urlencoded", maxFormSize + 1, true, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/api/import", bytes.NewReader(make([]byte, tt.size)))
+			r.Header.Set("Content-Type", tt.contentType)
+			if tt.chunked {
+				r.ContentLength = -1
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
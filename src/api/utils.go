@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"net/http"
 	"strings"
+
+	"github.com/birabittoh/go-lift/src/database"
 )
 
 func jsonResponse(w http.ResponseWriter, status int, data interface{}) {
@@ -15,3 +17,12 @@ func jsonResponse(w http.ResponseWriter, status int, data interface{}) {
 func jsonError(w http.ResponseWriter, status int, messages ...string) {
 	jsonResponse(w, status, map[string]string{"error": strings.Join(messages, " ")})
 }
+
+// getUnits returns the unit preferences that JSON values are expressed in
+func getUnits(db *database.Database) database.Units {
+	user, err := db.GetUserByID(1)
+	if err != nil {
+		return database.MetricUnits
+	}
+	return user.Units()
+}
@@ -0,0 +1,85 @@
+package src
+
+import (
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/birabittoh/go-lift/src/config"
+	"github.com/birabittoh/go-lift/src/database"
+)
+
+// runBackup writes a data archive, or a copy of the database with -database
+func runBackup(cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("backup", flag.ExitOnError)
+	output := fs.String("o", "", "output file (default: dated file in the current directory)")
+	sqlite := fs.Bool("database", false, "copy the SQLite database instead of writing an archive")
+	fs.Parse(args)
+
+	db, err := database.InitializeDB(cfg)
+	if err != nil {
+		return err
+	}
+
+	if *sqlite {
+		path := *output
+		if path == "" {
+			path = database.BackupFilename(".sqlite")
+		}
+		if err := db.BackupDatabase(path); err != nil {
+			return err
+		}
+		slog.Info("Database copied", "path", path)
+		return nil
+	}
+
+	path := *output
+	if path == "" {
+		path = database.BackupFilename(".json.gz")
+	}
+	if err := db.WriteArchiveFile(path); err != nil {
+		return err
+	}
+
+	slog.Info("Archive written", "path", path)
+	return nil
+}
+
+// runRestore restores a data archive
+func runRestore(cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("restore", flag.ExitOnError)
+	mode := fs.String("mode", database.RestoreMerge, "merge with the existing data or replace it")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: go-lift restore [-mode merge|replace] <archive>")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	f, err := os.Open(fs.Arg(0))
+	if err != nil {
+		return fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer f.Close()
+
+	archive, err := database.ReadArchive(f)
+	if err != nil {
+		return err
+	}
+
+	db, err := database.InitializeDB(cfg)
+	if err != nil {
+		return err
+	}
+
+	if err := db.RestoreArchive(archive, *mode); err != nil {
+		return err
+	}
+
+	slog.Info("Archive restored", "mode", *mode)
+	return nil
+}
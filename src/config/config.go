@@ -0,0 +1,287 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+	_ "time/tzdata" // The release image has no time zone database
+
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+)
+
+// Database drivers
+const (
+	DriverSQLite   = "sqlite"
+	DriverPostgres = "postgres"
+)
+
+// Authentication modes
+const (
+	AuthNone  = "none"  // Anyone who can reach the server can use it
+	AuthProxy = "proxy" // A reverse proxy authenticates users and sets a header
+	AuthBasic = "basic" // HTTP basic authentication with the users' passwords
+)
+
+// Log levels
+const (
+	LogDebug  = "debug"
+	LogInfo   = "info"
+	LogWarn   = "warn"
+	LogError  = "error"
+	LogSilent = "silent" // Only for the database log
+)
+
+// Log formats
+const (
+	LogFormatText = "text"
+	LogFormatJSON = "json"
+)
+
+const (
+	DefaultCatalogURL = "https://raw.githubusercontent.com/yuhonas/free-exercise-db/main/"
+	defaultFile       = "config.yaml"
+)
+
+// Config is the configuration of the server. Values are read from the
+// defaults, then the config file, then the environment, which includes .env.
+type Config struct {
+	ListenAddress string `yaml:"listenAddress" env:"APP_LISTEN_ADDRESS"`
+	BaseURL       string `yaml:"baseUrl" env:"APP_BASE_URL"`  // Public address, used in links shared outside the app
+	Timezone      string `yaml:"timezone" env:"APP_TIMEZONE"` // IANA name, empty for the system one
+	LogLevel      string `yaml:"logLevel" env:"APP_LOG_LEVEL"`
+	LogFormat     string `yaml:"logFormat" env:"APP_LOG_FORMAT"`
+
+	DBDriver   string `yaml:"dbDriver" env:"APP_DB_DRIVER"`
+	DBPath     string `yaml:"dbPath" env:"APP_DB_PATH"`          // SQLite database file
+	DBDSN      string `yaml:"dbDsn" env:"APP_DB_DSN"`            // Postgres data source
+	DBLogLevel string `yaml:"dbLogLevel" env:"APP_DB_LOG_LEVEL"` // Statements are logged at debug, slow ones at warn
+
+	CatalogURL string `yaml:"catalogUrl" env:"APP_CATALOG_URL"` // Base URL of the exercise catalog
+
+	AuthMode        string `yaml:"authMode" env:"APP_AUTH_MODE"`
+	AuthProxyHeader string `yaml:"authProxyHeader" env:"APP_AUTH_PROXY_HEADER"` // Header holding the user in proxy mode
+	AdminUsers      string `yaml:"adminUsers" env:"APP_ADMIN_USERS"`            // Comma-separated users allowed to replace all the data
+
+	MetricsToken string `yaml:"metricsToken" env:"APP_METRICS_TOKEN"` // Bearer token of /metrics scrapers, instead of signing in
+
+	Location *time.Location `yaml:"-"`
+}
+
+// Default returns the configuration used when nothing is set
+func Default() *Config {
+	return &Config{
+		ListenAddress:   ":3000",
+		LogLevel:        LogInfo,
+		LogFormat:       LogFormatText,
+		DBLogLevel:      LogWarn,
+		DBDriver:        DriverSQLite,
+		DBPath:          "data/fitness.sqlite",
+		CatalogURL:      DefaultCatalogURL,
+		AuthMode:        AuthNone,
+		AuthProxyHeader: "Remote-User",
+		Location:        time.Local,
+	}
+}
+
+// Load reads the configuration from a file, if any, and the environment, and
+// validates it. Without a path, $APP_CONFIG or config.yaml are read if they
+// exist.
+func Load(path string) (*Config, error) {
+	godotenv.Load()
+
+	cfg := Default()
+
+	required := path != ""
+	if path == "" {
+		path = os.Getenv("APP_CONFIG")
+		required = path != ""
+	}
+	if path == "" {
+		path = defaultFile
+	}
+	if err := cfg.readFile(path, required); err != nil {
+		return nil, err
+	}
+
+	cfg.readEnv()
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+func (c *Config) readFile(path string, required bool) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) && !required {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(c); err != nil && err != io.EOF {
+		return fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// readEnv overrides the fields whose variable is set
+func (c *Config) readEnv() {
+	v := reflect.ValueOf(c).Elem()
+	t := v.Type()
+	for i := range t.NumField() {
+		key := t.Field(i).Tag.Get("env")
+		if key == "" {
+			continue
+		}
+		if value, ok := os.LookupEnv(key); ok {
+			v.Field(i).SetString(strings.TrimSpace(value))
+		}
+	}
+}
+
+// fieldError names a setting by its variable, which is how most users set it
+func fieldError(name, format string, args ...any) error {
+	field, _ := reflect.TypeOf(Config{}).FieldByName(name)
+	return fmt.Errorf("%s: %s", field.Tag.Get("env"), fmt.Sprintf(format, args...))
+}
+
+func oneOf(name, value string, allowed ...string) error {
+	if slices.Contains(allowed, value) {
+		return nil
+	}
+	return fieldError(name, "%q is not one of %s", value, strings.Join(allowed, ", "))
+}
+
+// Validate checks every setting and reports all the invalid ones
+func (c *Config) Validate() error {
+	var errs []error
+
+	if c.ListenAddress == "" {
+		errs = append(errs, fieldError("ListenAddress", "is required"))
+	}
+
+	if c.BaseURL != "" {
+		u, err := url.Parse(c.BaseURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs = append(errs, fieldError("BaseURL", "%q is not an http or https URL", c.BaseURL))
+		}
+		c.BaseURL = strings.TrimRight(c.BaseURL, "/")
+	}
+
+	c.Location = time.Local
+	if c.Timezone != "" {
+		loc, err := time.LoadLocation(c.Timezone)
+		if err != nil {
+			errs = append(errs, fieldError("Timezone", "unknown time zone %q", c.Timezone))
+		} else {
+			c.Location = loc
+		}
+	}
+
+	c.LogLevel = strings.ToLower(c.LogLevel)
+	if err := oneOf("LogLevel", c.LogLevel, LogDebug, LogInfo, LogWarn, LogError); err != nil {
+		errs = append(errs, err)
+	}
+	c.LogFormat = strings.ToLower(c.LogFormat)
+	if err := oneOf("LogFormat", c.LogFormat, LogFormatText, LogFormatJSON); err != nil {
+		errs = append(errs, err)
+	}
+	c.DBLogLevel = strings.ToLower(c.DBLogLevel)
+	if err := oneOf("DBLogLevel", c.DBLogLevel, LogDebug, LogInfo, LogWarn, LogError, LogSilent); err != nil {
+		errs = append(errs, err)
+	}
+
+	switch c.DBDriver {
+	case DriverSQLite:
+		if c.DBPath == "" {
+			errs = append(errs, fieldError("DBPath", "is required by the %s driver", c.DBDriver))
+		}
+	case DriverPostgres:
+		if c.DBDSN == "" {
+			errs = append(errs, fieldError("DBDSN", "is required by the %s driver", c.DBDriver))
+		}
+	default:
+		errs = append(errs, oneOf("DBDriver", c.DBDriver, DriverSQLite, DriverPostgres))
+	}
+
+	u, err := url.Parse(c.CatalogURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		errs = append(errs, fieldError("CatalogURL", "%q is not an http or https URL", c.CatalogURL))
+	} else if !strings.HasSuffix(c.CatalogURL, "/") {
+		c.CatalogURL += "/"
+	}
+
+	if err := oneOf("AuthMode", c.AuthMode, AuthNone, AuthProxy, AuthBasic); err != nil {
+		errs = append(errs, err)
+	}
+	if c.AuthMode == AuthProxy && c.AuthProxyHeader == "" {
+		errs = append(errs, fieldError("AuthProxyHeader", "is required by the %s auth mode", c.AuthMode))
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid configuration:\n%w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// IsAdmin tells whether a signed-in user may run the operations that affect
+// all the data. Without authentication anyone who can reach the server can.
+func (c *Config) IsAdmin(username string) bool {
+	if c.AuthMode == AuthNone {
+		return true
+	}
+	if username == "" {
+		return false
+	}
+
+	for _, admin := range strings.Split(c.AdminUsers, ",") {
+		if strings.TrimSpace(admin) == username {
+			return true
+		}
+	}
+	return false
+}
+
+var dsnPassword = regexp.MustCompile(`(password=)('[^']*'|\S+)`)
+
+// redactDSN hides the password of a data source, in URL or key=value form
+func redactDSN(dsn string) string {
+	if u, err := url.Parse(dsn); err == nil && u.User != nil {
+		if _, ok := u.User.Password(); ok {
+			u.User = url.UserPassword(u.User.Username(), "xxxxx")
+			return u.String()
+		}
+	}
+	return dsnPassword.ReplaceAllString(dsn, "${1}xxxxx")
+}
+
+// Print writes the configuration in the config file format, without secrets
+func (c *Config) Print(w io.Writer) error {
+	redacted := *c
+	redacted.DBDSN = redactDSN(c.DBDSN)
+	if redacted.MetricsToken != "" {
+		redacted.MetricsToken = "xxxxx"
+	}
+
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&redacted); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
@@ -0,0 +1,166 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*Config)
+		errors []string // Variables reported as invalid
+	}{
+		{"default", func(c *Config) {}, nil},
+		{"postgres", func(c *Config) { c.DBDriver, c.DBDSN = DriverPostgres, "host=db" }, nil},
+		{"postgres without dsn", func(c *Config) { c.DBDriver = DriverPostgres }, []string{"APP_DB_DSN"}},
+		{"sqlite without path", func(c *Config) { c.DBPath = "" }, []string{"APP_DB_PATH"}},
+		{"unknown driver", func(c *Config) { c.DBDriver = "mysql" }, []string{"APP_DB_DRIVER"}},
+		{"no listen address", func(c *Config) { c.ListenAddress = "" }, []string{"APP_LISTEN_ADDRESS"}},
+		{"base url", func(c *Config) { c.BaseURL = "https://lift.example.com/" }, nil},
+		{"relative base url", func(c *Config) { c.BaseURL = "lift.example.com" }, []string{"APP_BASE_URL"}},
+		{"time zone", func(c *Config) { c.Timezone = "Europe/Rome" }, nil},
+		{"unknown time zone", func(c *Config) { c.Timezone = "Mars/Olympus" }, []string{"APP_TIMEZONE"}},
+		{"upper case levels", func(c *Config) { c.LogLevel, c.DBLogLevel = "DEBUG", "Silent" }, nil},
+		{"silent app log", func(c *Config) { c.LogLevel = LogSilent }, []string{"APP_LOG_LEVEL"}},
+		{"unknown log format", func(c *Config) { c.LogFormat = "xml" }, []string{"APP_LOG_FORMAT"}},
+		{"catalog without scheme", func(c *Config) { c.CatalogURL = "example.com/catalog" }, []string{"APP_CATALOG_URL"}},
+		{"proxy auth", func(c *Config) { c.AuthMode = AuthProxy }, nil},
+		{"proxy auth without header", func(c *Config) { c.AuthMode, c.AuthProxyHeader = AuthProxy, "" }, []string{"APP_AUTH_PROXY_HEADER"}},
+		{"unknown auth mode", func(c *Config) { c.AuthMode = "oauth" }, []string{"APP_AUTH_MODE"}},
+		{
+			"every error is reported",
+			func(c *Config) { c.ListenAddress, c.LogFormat, c.AuthMode = "", "xml", "oauth" },
+			[]string{"APP_LISTEN_ADDRESS", "APP_LOG_FORMAT", "APP_AUTH_MODE"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Default()
+			tt.modify(cfg)
+			err := cfg.Validate()
+			if len(tt.errors) == 0 {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("no error, want one about %v", tt.errors)
+			}
+			for _, key := range tt.errors {
+				if !strings.Contains(err.Error(), key+":") {
+					t.Errorf("error does not mention %s:\n%v", key, err)
+				}
+			}
+			if lines := strings.Count(err.Error(), "\n"); lines != len(tt.errors) {
+				t.Errorf("got %d errors, want %d:\n%v", lines, len(tt.errors), err)
+			}
+		})
+	}
+}
+
+func TestValidateNormalizes(t *testing.T) {
+	cfg := Default()
+	cfg.BaseURL = "https://lift.example.com/"
+	cfg.CatalogURL = "https://example.com/catalog"
+	cfg.LogLevel = "WARN"
+	cfg.Timezone = "Europe/Rome"
+	if err := cfg.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.BaseURL != "https://lift.example.com" {
+		t.Errorf("base URL = %s, want it without the trailing slash", cfg.BaseURL)
+	}
+	if cfg.CatalogURL != "https://example.com/catalog/" {
+		t.Errorf("catalog URL = %s, want it with a trailing slash", cfg.CatalogURL)
+	}
+	if cfg.LogLevel != LogWarn {
+		t.Errorf("log level = %s, want %s", cfg.LogLevel, LogWarn)
+	}
+	if cfg.Location.String() != "Europe/Rome" {
+		t.Errorf("location = %s, want Europe/Rome", cfg.Location)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	data := "listenAddress: \":4000\"\nlogLevel: debug\ndbPath: /tmp/lift.sqlite\n"
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"APP_CONFIG", "APP_LISTEN_ADDRESS", "APP_DB_DRIVER", "APP_DB_PATH", "APP_AUTH_MODE"} {
+		t.Setenv(key, "") // Restores the variable after the test
+		os.Unsetenv(key)
+	}
+	t.Setenv("APP_LOG_LEVEL", " error ")
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.ListenAddress != ":4000" || cfg.DBPath != "/tmp/lift.sqlite" {
+		t.Errorf("config = %+v, want the values of the file", cfg)
+	}
+	if cfg.LogLevel != LogError {
+		t.Errorf("log level = %s, want the one of the environment", cfg.LogLevel)
+	}
+	if cfg.AuthMode != AuthNone {
+		t.Errorf("auth mode = %s, want the default", cfg.AuthMode)
+	}
+
+	if err := os.WriteFile(path, []byte("listenAdress: \":4000\"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err == nil {
+		t.Error("loaded a config file with an unknown setting")
+	}
+	if _, err := Load(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Error("loaded a config file that does not exist")
+	}
+}
+
+func TestRedactDSN(t *testing.T) {
+	tests := []struct {
+		dsn, want string
+	}{
+		{"host=db user=lift password=secret dbname=lift", "host=db user=lift password=xxxxx dbname=lift"},
+		{"host=db password='a secret' dbname=lift", "host=db password=xxxxx dbname=lift"},
+		{"postgres://lift:secret@db:5432/lift", "postgres://lift:xxxxx@db:5432/lift"},
+		{"postgres://lift@db/lift", "postgres://lift@db/lift"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := redactDSN(tt.dsn); got != tt.want {
+			t.Errorf("redactDSN(%q) = %q, want %q", tt.dsn, got, tt.want)
+		}
+	}
+}
+
+func TestIsAdmin(t *testing.T) {
+	tests := []struct {
+		name     string
+		mode     string
+		admins   string
+		username string
+		want     bool
+	}{
+		{"no auth", AuthNone, "", "", true},
+		{"admin", AuthBasic, "alice, bob", "bob", true},
+		{"other user", AuthBasic, "alice, bob", "carol", false},
+		{"no admins", AuthProxy, "", "alice", false},
+		{"no user", AuthProxy, "alice", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Default()
+			cfg.AuthMode, cfg.AdminUsers = tt.mode, tt.admins
+			if got := cfg.IsAdmin(tt.username); got != tt.want {
+				t.Errorf("IsAdmin(%q) = %v, want %v", tt.username, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,718 @@
+package database
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const ArchiveFormat = "go-lift/backup"
+
+// maxArchiveSize is the largest archive read, once decompressed
+var maxArchiveSize int64 = 256 << 20
+
+const (
+	RestoreMerge   = "merge"   // Add the archive content to the existing data
+	RestoreReplace = "replace" // Delete the existing data first
+)
+
+// routineDay is a row of the join table between routines and days
+type routineDay struct {
+	RoutineID uint `json:"routineId"`
+	DayID     uint `json:"dayId"`
+}
+
+func (routineDay) TableName() string {
+	return "routine_days"
+}
+
+// archiveExercise keeps the fields an exercise does not expose through the API
+type archiveExercise struct {
+	Exercise
+	InstructionsString *string `json:"instructionsString"`
+}
+
+// archiveUser keeps the secrets of a user, which are never exposed through
+// the API
+type archiveUser struct {
+	User
+	FeedToken    *string `json:"feedToken"`
+	PasswordHash string  `json:"passwordHash"`
+}
+
+// Archive is a complete copy of the data, stored as flat tables. All values
+// are in canonical units.
+type Archive struct {
+	Format        string    `json:"format"`
+	SchemaVersion int       `json:"schemaVersion"`
+	CreatedAt     time.Time `json:"createdAt"`
+
+	Users                []archiveUser        `json:"users"`
+	HeightMeasurements   []HeightMeasurement  `json:"heightMeasurements"`
+	WeightMeasurements   []WeightMeasurement  `json:"weightMeasurements"`
+	BodyMetrics          []BodyMetric         `json:"bodyMetrics"`
+	BodyMeasurements     []BodyMeasurement    `json:"bodyMeasurements"`
+	EquipmentInventories []EquipmentInventory `json:"equipmentInventories"`
+	Exercises            []archiveExercise    `json:"exercises"`
+	Routines             []Routine            `json:"routines"`
+	RoutineDays          []routineDay         `json:"routineDays"`
+	RoutineVersions      []RoutineVersion     `json:"routineVersions"`
+	RoutineItems         []RoutineItem        `json:"routineItems"`
+	ExerciseItems        []ExerciseItem       `json:"exerciseItems"`
+	Sets                 []Set                `json:"sets"`
+	Programs             []Program            `json:"programs"`
+	ProgramWeeks         []ProgramWeek        `json:"programWeeks"`
+	ProgramRoutines      []ProgramRoutine     `json:"programRoutines"`
+	RecordRoutines       []RecordRoutine      `json:"recordRoutines"`
+	RecordRoutineItems   []RecordRoutineItem  `json:"recordRoutineItems"`
+	RecordExerciseItems  []RecordExerciseItem `json:"recordExerciseItems"`
+	RecordSets           []RecordSet          `json:"recordSets"`
+}
+
+// ExportArchive reads every table within a single transaction, so that the
+// archive is consistent even while the server is in use.
+func (db *Database) ExportArchive() (*Archive, error) {
+	a := &Archive{
+		Format:        ArchiveFormat,
+		SchemaVersion: SchemaVersion,
+		CreatedAt:     time.Now(),
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var users []User
+		var exercises []Exercise
+		tables := []any{
+			&a.HeightMeasurements,
+			&a.WeightMeasurements,
+			&a.BodyMetrics,
+			&a.BodyMeasurements,
+			&a.EquipmentInventories,
+			&exercises,
+			&a.Routines,
+			&a.RoutineVersions,
+			&a.RoutineItems,
+			&a.ExerciseItems,
+			&a.Sets,
+			&a.Programs,
+			&a.ProgramWeeks,
+			&a.ProgramRoutines,
+			&a.RecordRoutines,
+			&a.RecordRoutineItems,
+			&a.RecordExerciseItems,
+			&a.RecordSets,
+		}
+		for _, table := range tables {
+			if err := tx.Order("id").Find(table).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Unscoped().Order("id").Find(&users).Error; err != nil {
+			return err
+		}
+		if err := tx.Order("routine_id, day_id").Find(&a.RoutineDays).Error; err != nil {
+			return err
+		}
+
+		for _, user := range users {
+			a.Users = append(a.Users, archiveUser{User: user, FeedToken: user.FeedToken, PasswordHash: user.PasswordHash})
+		}
+		for _, exercise := range exercises {
+			a.Exercises = append(a.Exercises, archiveExercise{Exercise: exercise, InstructionsString: exercise.InstructionsString})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read data: %w", err)
+	}
+
+	return a, nil
+}
+
+// Write stores the archive as gzip-compressed JSON
+func (a *Archive) Write(w io.Writer) error {
+	gz := gzip.NewWriter(w)
+	if err := json.NewEncoder(gz).Encode(a); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// WriteArchiveFile exports the data to a new archive file
+func (db *Database) WriteArchiveFile(path string) error {
+	archive, err := db.ExportArchive()
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create archive: %w", err)
+	}
+	defer f.Close()
+
+	if err := archive.Write(f); err != nil {
+		return fmt.Errorf("failed to write archive: %w", err)
+	}
+
+	return f.Close()
+}
+
+// ReadArchive reads an archive, compressed or not, and checks it can be restored
+func ReadArchive(r io.Reader) (*Archive, error) {
+	br := bufio.NewReader(r)
+	var reader io.Reader = br
+	if magic, _ := br.Peek(2); len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress archive: %w", err)
+		}
+		defer gz.Close()
+		reader = gz
+	}
+
+	// Compressed archives can expand to much more than their upload size
+	limited := &io.LimitedReader{R: reader, N: maxArchiveSize + 1}
+
+	var a Archive
+	if err := json.NewDecoder(limited).Decode(&a); err != nil {
+		if limited.N <= 0 {
+			return nil, fmt.Errorf("archive is larger than %d MiB", maxArchiveSize>>20)
+		}
+		return nil, fmt.Errorf("failed to parse archive: %w", err)
+	}
+
+	if a.Format != ArchiveFormat {
+		return nil, fmt.Errorf("not a backup archive: format is %q", a.Format)
+	}
+	if a.SchemaVersion > SchemaVersion {
+		return nil, fmt.Errorf("archive schema version %d is newer than the supported version %d", a.SchemaVersion, SchemaVersion)
+	}
+	if a.SchemaVersion < 1 {
+		return nil, fmt.Errorf("unsupported archive schema version: %d", a.SchemaVersion)
+	}
+
+	for i := range a.Users {
+		a.Users[i].User.FeedToken = a.Users[i].FeedToken
+		a.Users[i].User.PasswordHash = a.Users[i].PasswordHash
+	}
+	for i := range a.Exercises {
+		a.Exercises[i].Exercise.InstructionsString = a.Exercises[i].InstructionsString
+	}
+
+	return &a, nil
+}
+
+// RestoreArchive restores an archive in a single transaction: either every
+// row is restored or nothing changes.
+func (db *Database) RestoreArchive(a *Archive, mode string) error {
+	var restore func(tx *gorm.DB, a *Archive) error
+	switch mode {
+	case RestoreMerge:
+		restore = mergeArchive
+	case RestoreReplace:
+		restore = replaceArchive
+
+		// Replacing deletes all the data, keep it in case the archive was
+		// the wrong one
+		if err := db.backupToDataDir("-restore"); err != nil {
+			return fmt.Errorf("failed to back up data before restoring: %w", err)
+		}
+	default:
+		return fmt.Errorf("invalid restore mode: %s", mode)
+	}
+
+	if err := db.Transaction(func(tx *gorm.DB) error { return restore(tx, a) }); err != nil {
+		return fmt.Errorf("failed to restore archive: %w", err)
+	}
+
+	// The archive may not contain a user, the app needs one
+	return db.ensureUserData()
+}
+
+func insertAll[T any](tx *gorm.DB, rows []T) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	return tx.Omit(clause.Associations).CreateInBatches(&rows, 100).Error
+}
+
+// replaceArchive deletes all data, except week days, and inserts the archive
+// keeping its IDs.
+func replaceArchive(tx *gorm.DB, a *Archive) error {
+	if err := tx.Where("1 = 1").Delete(&routineDay{}).Error; err != nil {
+		return fmt.Errorf("failed to delete data: %w", err)
+	}
+	for i := len(models) - 1; i >= 0; i-- {
+		if _, ok := models[i].(*Day); ok {
+			continue
+		}
+		if err := tx.Unscoped().Where("1 = 1").Delete(models[i]).Error; err != nil {
+			return fmt.Errorf("failed to delete data: %w", err)
+		}
+	}
+
+	users := make([]User, len(a.Users))
+	for i := range a.Users {
+		users[i] = a.Users[i].User
+	}
+	exercises := make([]Exercise, len(a.Exercises))
+	for i := range a.Exercises {
+		exercises[i] = a.Exercises[i].Exercise
+	}
+
+	inserts := []func() error{
+		func() error { return insertAll(tx, users) },
+		func() error { return insertAll(tx, a.HeightMeasurements) },
+		func() error { return insertAll(tx, a.WeightMeasurements) },
+		func() error { return insertAll(tx, a.BodyMetrics) },
+		func() error { return insertAll(tx, a.BodyMeasurements) },
+		func() error { return insertAll(tx, a.EquipmentInventories) },
+		func() error { return insertAll(tx, exercises) },
+		func() error { return insertAll(tx, a.Routines) },
+		func() error { return ensureDays(tx) },
+		func() error { return insertAll(tx, a.RoutineDays) },
+		func() error { return insertAll(tx, a.RoutineVersions) },
+		func() error { return insertAll(tx, a.RoutineItems) },
+		func() error { return insertAll(tx, a.ExerciseItems) },
+		func() error { return insertAll(tx, a.Sets) },
+		func() error { return insertAll(tx, a.Programs) },
+		func() error { return insertAll(tx, a.ProgramWeeks) },
+		func() error { return insertAll(tx, a.ProgramRoutines) },
+		func() error { return insertAll(tx, a.RecordRoutines) },
+		func() error { return insertAll(tx, a.RecordRoutineItems) },
+		func() error { return insertAll(tx, a.RecordExerciseItems) },
+		func() error { return insertAll(tx, a.RecordSets) },
+	}
+	for _, insert := range inserts {
+		if err := insert(); err != nil {
+			return err
+		}
+	}
+
+	return resetSequences(tx)
+}
+
+// ensureDays creates the week days routines can be scheduled on
+func ensureDays(tx *gorm.DB) error {
+	days := (&Database{DB: tx}).GetDays()
+	return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&days).Error
+}
+
+// idMap translates the IDs of an archive to the IDs of the merged rows
+type idMap map[uint]uint
+
+func (m idMap) optional(id *uint) *uint {
+	if id == nil {
+		return nil
+	}
+	if newID, ok := m[*id]; ok {
+		return &newID
+	}
+	return nil
+}
+
+// mergeArchive adds the archive to the existing data. The profile and
+// equipment of the existing user are kept, measurements taken at the same
+// time are skipped and everything else is added with new IDs, so merging the
+// same archive twice duplicates routines, programs and workouts.
+func mergeArchive(tx *gorm.DB, a *Archive) error {
+	var user User
+	if err := tx.Order("id").First(&user).Error; err != nil {
+		return fmt.Errorf("failed to retrieve user: %w", err)
+	}
+
+	if err := mergeMeasurements(tx, a); err != nil {
+		return err
+	}
+
+	var inventories int64
+	if err := tx.Model(&EquipmentInventory{}).Where("user_id = ?", user.ID).Count(&inventories).Error; err != nil {
+		return err
+	}
+	if inventories == 0 && len(a.EquipmentInventories) > 0 {
+		inventory := a.EquipmentInventories[0]
+		inventory.ID = 0
+		inventory.UserID = user.ID
+		if err := tx.Omit(clause.Associations).Create(&inventory).Error; err != nil {
+			return fmt.Errorf("failed to merge equipment: %w", err)
+		}
+	}
+
+	// Catalog exercises are left untouched
+	for _, exercise := range a.Exercises {
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&exercise.Exercise).Error; err != nil {
+			return fmt.Errorf("failed to merge exercises: %w", err)
+		}
+	}
+
+	routines, err := mergeRoutines(tx, a)
+	if err != nil {
+		return fmt.Errorf("failed to merge routines: %w", err)
+	}
+
+	programs, err := mergePrograms(tx, a, routines)
+	if err != nil {
+		return fmt.Errorf("failed to merge programs: %w", err)
+	}
+
+	if err := mergeRecords(tx, a, routines, programs); err != nil {
+		return fmt.Errorf("failed to merge workouts: %w", err)
+	}
+
+	return nil
+}
+
+func mergeMeasurements(tx *gorm.DB, a *Archive) error {
+	for _, m := range a.HeightMeasurements {
+		var count int64
+		tx.Model(&HeightMeasurement{}).Where("created_at = ?", m.CreatedAt).Count(&count)
+		if count == 0 {
+			m.ID = 0
+			if err := tx.Create(&m).Error; err != nil {
+				return fmt.Errorf("failed to merge height measurements: %w", err)
+			}
+		}
+	}
+
+	for _, m := range a.WeightMeasurements {
+		var count int64
+		tx.Model(&WeightMeasurement{}).Where("created_at = ?", m.CreatedAt).Count(&count)
+		if count == 0 {
+			m.ID = 0
+			if err := tx.Create(&m).Error; err != nil {
+				return fmt.Errorf("failed to merge weight measurements: %w", err)
+			}
+		}
+	}
+
+	// Metrics are matched by name
+	metrics := idMap{}
+	for _, metric := range a.BodyMetrics {
+		var existing BodyMetric
+		err := tx.Where("name = ?", metric.Name).Limit(1).Find(&existing).Error
+		if err != nil {
+			return err
+		}
+		if existing.ID == 0 {
+			existing = metric
+			existing.ID = 0
+			if err := tx.Omit(clause.Associations).Create(&existing).Error; err != nil {
+				return fmt.Errorf("failed to merge body metrics: %w", err)
+			}
+		}
+		metrics[metric.ID] = existing.ID
+	}
+
+	for _, m := range a.BodyMeasurements {
+		metricID, ok := metrics[m.BodyMetricID]
+		if !ok {
+			continue
+		}
+
+		var count int64
+		tx.Model(&BodyMeasurement{}).Where("body_metric_id = ? AND date = ?", metricID, m.Date).Count(&count)
+		if count == 0 {
+			m.ID = 0
+			m.BodyMetricID = metricID
+			if err := tx.Omit(clause.Associations).Create(&m).Error; err != nil {
+				return fmt.Errorf("failed to merge body measurements: %w", err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// routineIDs maps the IDs of every level of the routines
+type routineIDs struct {
+	routines, versions, items, exerciseItems, sets idMap
+}
+
+func mergeRoutines(tx *gorm.DB, a *Archive) (*routineIDs, error) {
+	ids := &routineIDs{idMap{}, idMap{}, idMap{}, idMap{}, idMap{}}
+
+	for _, r := range a.Routines {
+		oldID := r.ID
+		r.ID = 0
+		if err := tx.Omit(clause.Associations).Create(&r).Error; err != nil {
+			return nil, err
+		}
+		ids.routines[oldID] = r.ID
+	}
+
+	if err := ensureDays(tx); err != nil {
+		return nil, err
+	}
+	for _, rd := range a.RoutineDays {
+		if routineID, ok := ids.routines[rd.RoutineID]; ok {
+			rd.RoutineID = routineID
+			if err := tx.Create(&rd).Error; err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// Versions of deleted routines are kept for their workouts
+	for _, v := range a.RoutineVersions {
+		oldID := v.ID
+		v.ID = 0
+		v.RoutineID = ids.routines.optional(v.RoutineID)
+		if err := tx.Create(&v).Error; err != nil {
+			return nil, err
+		}
+		ids.versions[oldID] = v.ID
+	}
+
+	for _, ri := range a.RoutineItems {
+		routineID, ok := ids.routines[ri.RoutineID]
+		if !ok {
+			continue
+		}
+		oldID := ri.ID
+		ri.ID = 0
+		ri.RoutineID = routineID
+		if err := tx.Omit(clause.Associations).Create(&ri).Error; err != nil {
+			return nil, err
+		}
+		ids.items[oldID] = ri.ID
+	}
+
+	for _, ei := range a.ExerciseItems {
+		itemID, ok := ids.items[ei.RoutineItemID]
+		if !ok {
+			continue
+		}
+		oldID := ei.ID
+		ei.ID = 0
+		ei.RoutineItemID = itemID
+		if err := tx.Omit(clause.Associations).Create(&ei).Error; err != nil {
+			return nil, err
+		}
+		ids.exerciseItems[oldID] = ei.ID
+	}
+
+	for _, set := range a.Sets {
+		exerciseItemID, ok := ids.exerciseItems[set.ExerciseItemID]
+		if !ok {
+			continue
+		}
+		oldID := set.ID
+		set.ID = 0
+		set.ExerciseItemID = exerciseItemID
+		if err := tx.Omit(clause.Associations).Create(&set).Error; err != nil {
+			return nil, err
+		}
+		ids.sets[oldID] = set.ID
+	}
+
+	return ids, nil
+}
+
+func mergePrograms(tx *gorm.DB, a *Archive, routines *routineIDs) (idMap, error) {
+	programs := idMap{}
+
+	// Only one program can be followed at a time
+	var active int64
+	if err := tx.Model(&Program{}).Where("active = ?", true).Count(&active).Error; err != nil {
+		return nil, err
+	}
+
+	for _, p := range a.Programs {
+		oldID := p.ID
+		p.ID = 0
+		if active > 0 {
+			p.Active = false
+		}
+		if err := tx.Omit(clause.Associations).Create(&p).Error; err != nil {
+			return nil, err
+		}
+		programs[oldID] = p.ID
+	}
+
+	for _, week := range a.ProgramWeeks {
+		programID, ok := programs[week.ProgramID]
+		if !ok {
+			continue
+		}
+		week.ID = 0
+		week.ProgramID = programID
+		if err := tx.Create(&week).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	for _, pr := range a.ProgramRoutines {
+		programID, ok := programs[pr.ProgramID]
+		routineID, found := routines.routines[pr.RoutineID]
+		if !ok || !found {
+			continue
+		}
+		pr.ID = 0
+		pr.ProgramID = programID
+		pr.RoutineID = routineID
+		if err := tx.Omit(clause.Associations).Create(&pr).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	return programs, nil
+}
+
+func mergeRecords(tx *gorm.DB, a *Archive, routines *routineIDs, programs idMap) error {
+	records, items, exerciseItems := idMap{}, idMap{}, idMap{}
+
+	for _, r := range a.RecordRoutines {
+		oldID := r.ID
+		r.ID = 0
+		r.RoutineID = routines.routines.optional(r.RoutineID)
+		r.VersionID = routines.versions.optional(r.VersionID)
+		r.ProgramID = programs.optional(r.ProgramID)
+		if r.ProgramID == nil {
+			r.ProgramWeek = nil
+		}
+		if err := tx.Omit(clause.Associations).Create(&r).Error; err != nil {
+			return err
+		}
+		records[oldID] = r.ID
+	}
+
+	for _, rri := range a.RecordRoutineItems {
+		recordID, ok := records[rri.RecordRoutineID]
+		if !ok {
+			continue
+		}
+		oldID := rri.ID
+		rri.ID = 0
+		rri.RecordRoutineID = recordID
+		rri.RoutineItemID = routines.items.optional(rri.RoutineItemID)
+		if err := tx.Omit(clause.Associations).Create(&rri).Error; err != nil {
+			return err
+		}
+		items[oldID] = rri.ID
+	}
+
+	for _, rei := range a.RecordExerciseItems {
+		itemID, ok := items[rei.RecordRoutineItemID]
+		if !ok {
+			continue
+		}
+		oldID := rei.ID
+		rei.ID = 0
+		rei.RecordRoutineItemID = itemID
+		rei.ExerciseItemID = routines.exerciseItems.optional(rei.ExerciseItemID)
+		if err := tx.Omit(clause.Associations).Create(&rei).Error; err != nil {
+			return err
+		}
+		exerciseItems[oldID] = rei.ID
+	}
+
+	for _, set := range a.RecordSets {
+		exerciseItemID, ok := exerciseItems[set.RecordExerciseItemID]
+		if !ok {
+			continue
+		}
+		set.ID = 0
+		set.RecordExerciseItemID = exerciseItemID
+		set.SetID = routines.sets.optional(set.SetID)
+		if err := tx.Omit(clause.Associations).Create(&set).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// BackupDatabase writes a consistent copy of the live database to a new file,
+// without blocking the server. VACUUM INTO is used because the SQLite driver
+// doesn't provide the online backup API: it copies in a single read
+// transaction and compacts the copy. Postgres databases can't be copied to a
+// file, use a data archive or pg_dump instead.
+func (db *Database) BackupDatabase(path string) error {
+	if !db.IsSQLite() {
+		return fmt.Errorf("database copies need SQLite, use a data archive or pg_dump instead")
+	}
+
+	// VACUUM INTO only refuses to overwrite files that are not empty
+	if _, err := os.Lstat(path); err == nil {
+		return fmt.Errorf("backup file already exists: %s", path)
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to check backup file: %w", err)
+	}
+
+	if err := db.Exec("VACUUM INTO ?", path).Error; err != nil {
+		return fmt.Errorf("failed to back up database: %w", err)
+	}
+	return nil
+}
+
+// TempDatabaseBackup writes a consistent copy of the live database to a
+// temporary file, which is deleted by the returned cleanup function.
+func (db *Database) TempDatabaseBackup() (path string, cleanup func(), err error) {
+	dir, err := os.MkdirTemp("", "go-lift-backup-")
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	cleanup = func() { os.RemoveAll(dir) }
+
+	path = filepath.Join(dir, filepath.Base(db.Config.DBPath))
+	if err := db.BackupDatabase(path); err != nil {
+		cleanup()
+		return "", nil, err
+	}
+
+	return path, cleanup, nil
+}
+
+// backupToDataDir saves the data in the data directory before it is changed,
+// unless there is none yet. SQLite databases are copied, others are written to
+// an archive. The suffix tells what the backup was taken for.
+func (db *Database) backupToDataDir(suffix string) error {
+	if !db.Migrator().HasTable(&User{}) {
+		return nil
+	}
+
+	ext := ".json.gz"
+	if db.IsSQLite() {
+		ext = ".sqlite"
+	}
+
+	if err := os.MkdirAll(db.dataDir(), 0755); err != nil {
+		return fmt.Errorf("failed to create data directory: %w", err)
+	}
+
+	path := filepath.Join(db.dataDir(), BackupFilename(suffix+ext))
+	for i := 2; ; i++ {
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			break
+		}
+		path = filepath.Join(db.dataDir(), BackupFilename(fmt.Sprintf("%s-%d%s", suffix, i, ext)))
+	}
+
+	if db.IsSQLite() {
+		if err := db.BackupDatabase(path); err != nil {
+			return err
+		}
+	} else if err := db.WriteArchiveFile(path); err != nil {
+		return err
+	}
+
+	slog.Info("Database backed up", "path", path)
+	return nil
+}
+
+// BackupFilename returns a dated file name for a backup
+func BackupFilename(ext string) string {
+	return "go-lift-" + time.Now().Format("20060102-150405") + ext
+}
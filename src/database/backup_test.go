@@ -0,0 +1,224 @@
+package database
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// archiveJSON encodes the tables of an archive, to compare them
+func archiveJSON(t *testing.T, a *Archive) string {
+	t.Helper()
+	copy := *a
+	copy.CreatedAt = time.Time{}
+	b, err := json.Marshal(copy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestArchiveRoundTrip(t *testing.T) {
+	forEachMigratedDB(t, func(t *testing.T, db *Database) {
+		var user User
+		if err := db.First(&user).Error; err != nil {
+			t.Fatal(err)
+		}
+		token, err := db.GetFeedToken(&user)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := db.SetUserCredentials(&user, "alice", "correct horse"); err != nil {
+			t.Fatal(err)
+		}
+
+		var metric BodyMetric
+		if err := db.First(&metric).Error; err != nil {
+			t.Fatal(err)
+		}
+		mustCreate(t, db.DB, &BodyMeasurement{BodyMetricID: metric.ID, Value: 18.5, Date: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)})
+		mustCreate(t, db.DB, &WeightMeasurement{Weight: 80})
+		addWorkout(t, db, "Legs")
+
+		exported, err := db.ExportArchive()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var buf bytes.Buffer
+		if err := exported.Write(&buf); err != nil {
+			t.Fatal(err)
+		}
+		archive, err := ReadArchive(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Replacing the data with its own archive changes nothing
+		if err := db.RestoreArchive(archive, RestoreReplace); err != nil {
+			t.Fatal(err)
+		}
+		restored, err := db.ExportArchive()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := archiveJSON(t, restored), archiveJSON(t, exported); got != want {
+			t.Errorf("data after restoring its archive:\n%s\nwant:\n%s", got, want)
+		}
+
+		if u, err := db.GetUserByFeedToken(token); err != nil || u.ID != user.ID {
+			t.Errorf("GetUserByFeedToken() = %v, %v, want the user back", u, err)
+		}
+		if u, err := db.AuthenticateUser("alice", "correct horse"); err != nil || u == nil {
+			t.Errorf("AuthenticateUser() = %v, %v, want the user back", u, err)
+		}
+
+		// Merging adds the routines and workouts again, but not the measurements
+		if err := db.RestoreArchive(archive, RestoreMerge); err != nil {
+			t.Fatal(err)
+		}
+		counts := []struct {
+			model any
+			want  int64
+		}{
+			{&User{}, 1},
+			{&Routine{}, 2},
+			{&Set{}, 4},
+			{&RecordRoutine{}, 2},
+			{&RecordSet{}, 4},
+			{&BodyMeasurement{}, 1},
+			{&WeightMeasurement{}, 1},
+		}
+		for _, c := range counts {
+			var got int64
+			db.Model(c.model).Count(&got)
+			if got != c.want {
+				t.Errorf("%T rows after merging = %d, want %d", c.model, got, c.want)
+			}
+		}
+	})
+}
+
+func TestReadArchiveChecksVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		archive string
+		wantErr bool
+	}{
+		{"current", `{"format":"go-lift/backup","schemaVersion":1}`, false},
+		{"other format", `{"format":"other","schemaVersion":1}`, true},
+		{"newer schema", `{"format":"go-lift/backup","schemaVersion":1000}`, true},
+		{"no schema", `{"format":"go-lift/backup"}`, true},
+		{"not json", `format: go-lift/backup`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ReadArchive(bytes.NewBufferString(tt.archive))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ReadArchive() error = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRestoreReplaceKeepsIDs(t *testing.T) {
+	forEachMigratedDB(t, func(t *testing.T, db *Database) {
+		mustCreate(t, db.DB, &Routine{Name: "Legs", RoutineItems: []RoutineItem{{
+			ExerciseItems: []ExerciseItem{{ExerciseID: "Barbell_Squat", Sets: []Set{{Reps: ptr[uint](5)}}}},
+		}}})
+		mustCreate(t, db.DB, &Routine{Name: "Push"})
+
+		archive, err := db.ExportArchive()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := db.RestoreArchive(archive, RestoreReplace); err != nil {
+			t.Fatal(err)
+		}
+
+		var routines []Routine
+		db.Order("id").Find(&routines)
+		if len(routines) != 2 || routines[0].ID != archive.Routines[0].ID || routines[1].ID != archive.Routines[1].ID {
+			t.Fatalf("routines after restore = %+v, want the IDs of %+v", routines, archive.Routines)
+		}
+
+		// New rows must not collide with the restored IDs
+		routine := Routine{Name: "Pull"}
+		mustCreate(t, db.DB, &routine)
+		if routine.ID <= routines[1].ID {
+			t.Errorf("new routine ID = %d, want more than %d", routine.ID, routines[1].ID)
+		}
+	})
+}
+
+func TestBackupDatabaseKeepsExistingFiles(t *testing.T) {
+	forEachMigratedDB(t, func(t *testing.T, db *Database) {
+		if !db.IsSQLite() {
+			if err := db.BackupDatabase(filepath.Join(t.TempDir(), "copy.sqlite")); err == nil {
+				t.Error("copied a Postgres database to a file")
+			}
+			return
+		}
+
+		path := filepath.Join(t.TempDir(), "copy.sqlite")
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := db.BackupDatabase(path); err == nil {
+			t.Error("overwrote an existing file")
+		}
+
+		os.Remove(path)
+		if err := db.BackupDatabase(path); err != nil {
+			t.Fatal(err)
+		}
+		if info, err := os.Stat(path); err != nil || info.Size() == 0 {
+			t.Errorf("backup file = %v, %v, want a copy of the database", info, err)
+		}
+	})
+}
+
+func TestReadArchiveLimitsSize(t *testing.T) {
+	defer func(size int64) { maxArchiveSize = size }(maxArchiveSize)
+	maxArchiveSize = 1 << 20
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	gz.Write([]byte(`{"format":"go-lift/backup","schemaVersion":1,"padding":"`))
+	gz.Write(bytes.Repeat([]byte{'a'}, 2<<20))
+	gz.Write([]byte(`"}`))
+	gz.Close()
+
+	_, err := ReadArchive(&buf)
+	if err == nil || !strings.Contains(err.Error(), "larger than") {
+		t.Errorf("ReadArchive() error = %v, want the archive to be too large", err)
+	}
+}
+
+func TestRestoreReplaceBacksUpData(t *testing.T) {
+	forEachMigratedDB(t, func(t *testing.T, db *Database) {
+		addWorkout(t, db, "Legs")
+
+		archive, err := db.ExportArchive()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := db.RestoreArchive(archive, RestoreMerge); err != nil {
+			t.Fatal(err)
+		}
+		if backups, _ := filepath.Glob(filepath.Join(db.dataDir(), "*-restore*")); len(backups) != 0 {
+			t.Errorf("backups after merging = %v, want none", backups)
+		}
+
+		if err := db.RestoreArchive(archive, RestoreReplace); err != nil {
+			t.Fatal(err)
+		}
+		if backups, _ := filepath.Glob(filepath.Join(db.dataDir(), "*-restore*")); len(backups) != 1 {
+			t.Errorf("backups after replacing = %v, want one", backups)
+		}
+	})
+}
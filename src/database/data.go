@@ -1,12 +1,23 @@
 package database
 
 import (
-	"log"
+	"log/slog"
 )
 
 var (
-	defaultUserList = []User{{Name: "User"}}
-	weekDays        = []string{"monday", "tuesday", "wednesday", "thursday", "friday", "saturday", "sunday"}
+	defaultUserList       = []User{{Name: "User"}}
+	defaultBodyMetricList = []BodyMetric{
+		{Name: "Body fat", Unit: "%"},
+		{Name: "Neck", Unit: "cm"},
+		{Name: "Chest", Unit: "cm"},
+		{Name: "Waist", Unit: "cm"},
+		{Name: "Hips", Unit: "cm"},
+		{Name: "Arm", Unit: "cm"},
+		{Name: "Forearm", Unit: "cm"},
+		{Name: "Thigh", Unit: "cm"},
+		{Name: "Calf", Unit: "cm"},
+	}
+	weekDays = []string{"monday", "tuesday", "wednesday", "thursday", "friday", "saturday", "sunday"}
 )
 
 // CheckInitialData ensures that all necessary initial data is in the database
@@ -21,7 +32,12 @@ func (db *Database) CheckInitialData() (err error) {
 		return
 	}
 
-	log.Println("Initial data verification complete")
+	err = db.ensureBodyMetricData()
+	if err != nil {
+		return
+	}
+
+	slog.Debug("Initial data verification complete")
 	return
 }
 
@@ -35,8 +51,8 @@ func (db *Database) ensureExerciseData() error {
 
 	// If no exercise data, insert the initial data
 	if count == 0 {
-		log.Println("Adding initial exercise data")
-		db.UpdateExercises()
+		slog.Info("Adding initial exercise data")
+		return db.UpdateExercises()
 	}
 
 	return nil
@@ -52,7 +68,7 @@ func (db *Database) ensureUserData() error {
 
 	// If no user data, insert the initial data
 	if count == 0 {
-		log.Println("Adding initial user data")
+		slog.Info("Adding initial user data")
 		for _, user := range defaultUserList {
 			if err := db.Create(&user).Error; err != nil {
 				return err
@@ -62,3 +78,23 @@ func (db *Database) ensureUserData() error {
 
 	return nil
 }
+
+// ensureBodyMetricData checks if body metrics exist and adds the defaults if not
+func (db *Database) ensureBodyMetricData() error {
+	var count int64
+	if err := db.Model(&BodyMetric{}).Count(&count).Error; err != nil {
+		return err
+	}
+
+	if count == 0 {
+		slog.Info("Adding initial body metric data")
+		for i, metric := range defaultBodyMetricList {
+			metric.OrderIndex = i
+			if err := db.Create(&metric).Error; err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,72 @@
+package database
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/birabittoh/go-lift/src/config"
+	"github.com/glebarez/sqlite"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// openDialector selects the configured database driver. SQLite creates the
+// directory of the database file if needed.
+func openDialector(cfg *config.Config) (gorm.Dialector, error) {
+	switch cfg.DBDriver {
+	case config.DriverSQLite:
+		if err := os.MkdirAll(filepath.Dir(cfg.DBPath), 0755); err != nil {
+			return nil, err
+		}
+		return sqlite.Open(cfg.DBPath + "?_pragma=foreign_keys(1)"), nil
+	case config.DriverPostgres:
+		return postgres.Open(cfg.DBDSN), nil
+	default:
+		return nil, fmt.Errorf("unknown database driver: %s", cfg.DBDriver)
+	}
+}
+
+// IsSQLite tells whether the database is SQLite, some maintenance features
+// like file copies only work there
+func (db *Database) IsSQLite() bool {
+	return db.Dialector.Name() == config.DriverSQLite
+}
+
+// dataDir is where backups are written, next to the SQLite database
+func (db *Database) dataDir() string {
+	if db.Config.DBPath == "" {
+		return "."
+	}
+	return filepath.Dir(db.Config.DBPath)
+}
+
+// resetSequences moves the Postgres ID sequences past rows inserted with their
+// own IDs, SQLite doesn't need it
+func resetSequences(tx *gorm.DB) error {
+	if tx.Dialector.Name() != config.DriverPostgres {
+		return nil
+	}
+
+	for _, model := range models {
+		stmt := &gorm.Statement{DB: tx}
+		if err := stmt.Parse(model); err != nil {
+			return err
+		}
+
+		field := stmt.Schema.PrioritizedPrimaryField
+		if field == nil || !field.AutoIncrement {
+			continue
+		}
+
+		err := tx.Exec(fmt.Sprintf(
+			"SELECT setval(pg_get_serial_sequence('%[1]s', '%[2]s'), COALESCE(MAX(%[2]s), 0) + 1, false) FROM %[1]s",
+			stmt.Schema.Table, field.DBName,
+		)).Error
+		if err != nil {
+			return fmt.Errorf("failed to reset the sequence of %s: %w", stmt.Schema.Table, err)
+		}
+	}
+
+	return nil
+}
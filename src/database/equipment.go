@@ -0,0 +1,451 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+const (
+	equipmentBarbell    = "barbell"
+	equipmentEZBar      = "e-z curl bar"
+	equipmentDumbbell   = "dumbbell"
+	equipmentKettlebell = "kettlebells"
+	equipmentMachine    = "machine"
+	equipmentCable      = "cable"
+	equipmentBodyOnly   = "body only"
+)
+
+// PlateCount is an available plate and how many of them there are (0 means unlimited)
+type PlateCount struct {
+	Weight float64 `json:"weight"`
+	Count  int     `json:"count"`
+}
+
+// PlateLoad describes how to load a bar for a given weight
+type PlateLoad struct {
+	Bar     float64   `json:"bar"`
+	PerSide []float64 `json:"perSide"`
+	Total   float64   `json:"total"`
+	Exact   bool      `json:"exact"`
+}
+
+var defaultInventories = map[string]EquipmentInventory{
+	"kg": {
+		BarWeights:        "20, 10",
+		Plates:            "25x2, 20x6, 15x2, 10x4, 5x4, 2.5x4, 1.25x4",
+		DumbbellIncrement: 2,
+		DumbbellMax:       50,
+		MachineIncrement:  5,
+		MachineMax:        150,
+	},
+	"lb": {
+		BarWeights:        "45, 25",
+		Plates:            "45x8, 35x2, 25x4, 10x4, 5x4, 2.5x4",
+		DumbbellIncrement: 5,
+		DumbbellMax:       120,
+		MachineIncrement:  10,
+		MachineMax:        300,
+	},
+}
+
+func toGrams(kg float64) int {
+	return int(math.Round(kg * 1000))
+}
+
+func fromGrams(g int) float64 {
+	return float64(g) / 1000
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+func parseWeightList(s string) ([]float64, error) {
+	var weights []float64
+	for _, field := range strings.Split(s, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		w, err := strconv.ParseFloat(field, 64)
+		if err != nil || w <= 0 {
+			return nil, fmt.Errorf("invalid weight: %s", field)
+		}
+		weights = append(weights, w)
+	}
+	return weights, nil
+}
+
+// parsePlates reads a list of "weight" or "weightxcount" entries
+func parsePlates(s string) ([]PlateCount, error) {
+	var plates []PlateCount
+	for _, field := range strings.Split(s, ",") {
+		field = strings.ToLower(strings.TrimSpace(field))
+		if field == "" {
+			continue
+		}
+
+		plate := PlateCount{}
+		weight, count, hasCount := strings.Cut(field, "x")
+		w, err := strconv.ParseFloat(strings.TrimSpace(weight), 64)
+		if err != nil || w <= 0 {
+			return nil, fmt.Errorf("invalid plate: %s", field)
+		}
+		plate.Weight = w
+
+		if hasCount {
+			c, err := strconv.Atoi(strings.TrimSpace(count))
+			if err != nil || c <= 0 {
+				return nil, fmt.Errorf("invalid plate count: %s", field)
+			}
+			plate.Count = c
+		}
+
+		plates = append(plates, plate)
+	}
+
+	sort.Slice(plates, func(i, j int) bool { return plates[i].Weight > plates[j].Weight })
+	return plates, nil
+}
+
+func formatWeightList(weights []float64) string {
+	fields := make([]string, 0, len(weights))
+	for _, w := range weights {
+		fields = append(fields, strconv.FormatFloat(w, 'f', -1, 64))
+	}
+	return strings.Join(fields, ", ")
+}
+
+func formatPlates(plates []PlateCount) string {
+	fields := make([]string, 0, len(plates))
+	for _, p := range plates {
+		field := strconv.FormatFloat(p.Weight, 'f', -1, 64)
+		if p.Count > 0 {
+			field += "x" + strconv.Itoa(p.Count)
+		}
+		fields = append(fields, field)
+	}
+	return strings.Join(fields, ", ")
+}
+
+func roundHundredth(value float64) float64 {
+	return math.Round(value*100) / 100
+}
+
+// convertWeightList converts every weight of a comma-separated list
+func convertWeightList(s string, f func(float64) float64) (string, error) {
+	weights, err := parseWeightList(s)
+	if err != nil {
+		return "", err
+	}
+	for i := range weights {
+		weights[i] = f(weights[i])
+	}
+	return formatWeightList(weights), nil
+}
+
+// convertPlates converts every plate weight of a plate list
+func convertPlates(s string, f func(float64) float64) (string, error) {
+	plates, err := parsePlates(s)
+	if err != nil {
+		return "", err
+	}
+	for i := range plates {
+		plates[i].Weight = f(plates[i].Weight)
+	}
+	return formatPlates(plates), nil
+}
+
+func convertInventory(inv *EquipmentInventory, f func(float64) float64) (err error) {
+	inv.BarWeights, err = convertWeightList(inv.BarWeights, f)
+	if err != nil {
+		return
+	}
+
+	inv.Plates, err = convertPlates(inv.Plates, f)
+	if err != nil {
+		return
+	}
+
+	inv.DumbbellIncrement = f(inv.DumbbellIncrement)
+	inv.DumbbellMax = f(inv.DumbbellMax)
+	inv.MachineIncrement = f(inv.MachineIncrement)
+	inv.MachineMax = f(inv.MachineMax)
+	return
+}
+
+// InventoryFromMetric converts the inventory weights to the preferred unit in place
+func (p Units) InventoryFromMetric(inv *EquipmentInventory) error {
+	return convertInventory(inv, func(kg float64) float64 {
+		return roundHundredth(FromCanonical(kg, p.Weight))
+	})
+}
+
+// InventoryToMetric converts the inventory weights from the preferred unit in place
+func (p Units) InventoryToMetric(inv *EquipmentInventory) error {
+	return convertInventory(inv, func(v float64) float64 {
+		return ToCanonical(v, p.Weight)
+	})
+}
+
+// GetEquipmentInventory returns the user's inventory, or an unsaved default
+// one in the user's preferred unit if they never saved theirs.
+func (db *Database) GetEquipmentInventory(user *User) (*EquipmentInventory, error) {
+	var inv EquipmentInventory
+	err := db.Where("user_id = ?", user.ID).First(&inv).Error
+	if err == nil {
+		return &inv, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	units := user.Units()
+	inv = defaultInventories[units.Weight]
+	inv.UserID = user.ID
+	if err := units.InventoryToMetric(&inv); err != nil {
+		return nil, err
+	}
+
+	return &inv, nil
+}
+
+// UpdateEquipmentInventory saves an inventory, creating it if it has no ID yet
+func (db *Database) UpdateEquipmentInventory(inv *EquipmentInventory) error {
+	if inv.UserID == 0 {
+		return fmt.Errorf("equipment inventory user ID is required for update")
+	}
+
+	if _, err := parseWeightList(inv.BarWeights); err != nil {
+		return err
+	}
+
+	if _, err := parsePlates(inv.Plates); err != nil {
+		return err
+	}
+
+	if inv.DumbbellIncrement < 0 || inv.DumbbellMax < 0 || inv.MachineIncrement < 0 || inv.MachineMax < 0 {
+		return fmt.Errorf("invalid increments")
+	}
+
+	inv.Available = strings.Join(inv.AvailableEquipment(), ", ")
+
+	if err := db.Save(inv).Error; err != nil {
+		return fmt.Errorf("failed to update equipment inventory: %w", err)
+	}
+
+	return nil
+}
+
+// barFor picks the bar used for an equipment type: the heaviest bar for
+// barbell exercises and the lightest one for e-z bar exercises.
+func (inv *EquipmentInventory) barFor(equipment string) (float64, bool) {
+	bars, err := parseWeightList(inv.BarWeights)
+	if err != nil || len(bars) == 0 {
+		return 0, false
+	}
+
+	sort.Float64s(bars)
+	switch equipment {
+	case equipmentBarbell:
+		return bars[len(bars)-1], true
+	case equipmentEZBar:
+		return bars[0], true
+	}
+	return 0, false
+}
+
+// loadPerSide greedily picks plates for one side, without exceeding the target
+func loadPerSide(plates []PlateCount, target int) (picked []int, loaded int) {
+	for _, p := range plates {
+		g := toGrams(p.Weight)
+		available := math.MaxInt
+		if p.Count > 0 {
+			available = p.Count / 2
+		}
+		for available > 0 && loaded+g <= target {
+			picked = append(picked, g)
+			loaded += g
+			available--
+		}
+	}
+	return
+}
+
+// LoadBreakdown returns the plates needed on each side of the bar to lift kg,
+// or nil when the equipment is not loaded with plates or the weight is lighter
+// than the bar.
+func (inv *EquipmentInventory) LoadBreakdown(kg float64, equipment *string) *PlateLoad {
+	if inv == nil || equipment == nil {
+		return nil
+	}
+
+	bar, ok := inv.barFor(*equipment)
+	if !ok {
+		return nil
+	}
+
+	plates, err := parsePlates(inv.Plates)
+	if err != nil {
+		return nil
+	}
+
+	target := toGrams(kg) - toGrams(bar)
+	if target < 0 {
+		return nil
+	}
+
+	load := &PlateLoad{Bar: bar}
+
+	picked, loaded := loadPerSide(plates, target/2)
+	for _, g := range picked {
+		load.PerSide = append(load.PerSide, fromGrams(g))
+	}
+	load.Total = fromGrams(toGrams(bar) + 2*loaded)
+	load.Exact = abs(target-2*loaded) <= 2 // Tolerate rounding of converted plates
+
+	return load
+}
+
+func snapToIncrement(kg, increment, maximum float64) float64 {
+	if increment <= 0 {
+		return kg
+	}
+	snapped := math.Round(kg/increment) * increment
+	if snapped < increment {
+		snapped = increment
+	}
+	if maximum > 0 && snapped > maximum {
+		snapped = maximum
+	}
+	return snapped
+}
+
+// SnapLoad rounds a planned weight to the nearest value that can actually be
+// loaded with the available equipment. Unknown equipment is left untouched.
+func (inv *EquipmentInventory) SnapLoad(kg float64, equipment *string) float64 {
+	if inv == nil || equipment == nil || kg <= 0 {
+		return kg
+	}
+
+	switch *equipment {
+	case equipmentDumbbell, equipmentKettlebell:
+		return snapToIncrement(kg, inv.DumbbellIncrement, inv.DumbbellMax)
+	case equipmentMachine, equipmentCable:
+		return snapToIncrement(kg, inv.MachineIncrement, inv.MachineMax)
+	case equipmentBarbell, equipmentEZBar:
+		bar, ok := inv.barFor(*equipment)
+		if !ok {
+			return kg
+		}
+		plates, err := parsePlates(inv.Plates)
+		if err != nil || len(plates) == 0 {
+			return bar
+		}
+
+		// Try the closest loadable weight below and above the target
+		smallest := toGrams(plates[len(plates)-1].Weight)
+		perSide := (toGrams(kg) - toGrams(bar)) / 2
+		if perSide <= 0 {
+			return bar
+		}
+		_, below := loadPerSide(plates, perSide)
+		_, above := loadPerSide(plates, below+smallest)
+
+		best := below
+		if above > below && above-perSide < perSide-below {
+			best = above
+		}
+		return fromGrams(toGrams(bar) + 2*best)
+	}
+
+	return kg
+}
+
+// snapRoutineLoads rounds the planned weights of a routine to loads the
+// equipment of the user can make
+func (db *Database) snapRoutineLoads(routine *Routine) error {
+	var ids []string
+	for _, item := range routine.RoutineItems {
+		for _, exerciseItem := range item.ExerciseItems {
+			ids = append(ids, exerciseItem.ExerciseID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	var exercises []Exercise
+	if err := db.Where("id IN ?", ids).Find(&exercises).Error; err != nil {
+		return fmt.Errorf("failed to retrieve exercises: %w", err)
+	}
+	equipment := make(map[string]*string, len(exercises))
+	for _, e := range exercises {
+		equipment[e.ID] = e.Equipment
+	}
+
+	inventory, err := db.userInventory()
+	if err != nil {
+		return err
+	}
+
+	for i := range routine.RoutineItems {
+		for j := range routine.RoutineItems[i].ExerciseItems {
+			item := &routine.RoutineItems[i].ExerciseItems[j]
+			for k := range item.Sets {
+				if weight := item.Sets[k].Weight; weight != nil {
+					*weight = inventory.SnapLoad(*weight, equipment[item.ExerciseID])
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// userInventory returns the equipment inventory of the app user
+func (db *Database) userInventory() (*EquipmentInventory, error) {
+	user, err := db.GetUserByID(1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve user: %w", err)
+	}
+
+	inventory, err := db.GetEquipmentInventory(user)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve equipment: %w", err)
+	}
+	return inventory, nil
+}
+
+// FormatPlateLoad describes a plate load in the given unit, e.g. "20 + 2×(20, 2.5)"
+func FormatPlateLoad(load *PlateLoad, u string) string {
+	if load == nil {
+		return ""
+	}
+
+	convert := func(kg float64) string {
+		return strconv.FormatFloat(roundHundredth(FromCanonical(kg, u)), 'f', -1, 64)
+	}
+
+	s := convert(load.Bar)
+	if len(load.PerSide) > 0 {
+		side := make([]string, 0, len(load.PerSide))
+		for _, p := range load.PerSide {
+			side = append(side, convert(p))
+		}
+		s += " + 2×(" + strings.Join(side, ", ") + ")"
+	}
+	if !load.Exact {
+		s += " ≈ " + convert(load.Total)
+	}
+	return s
+}
@@ -0,0 +1,136 @@
+package database
+
+import "testing"
+
+func TestSnapLoad(t *testing.T) {
+	kg := defaultInventories["kg"]
+	limited := &EquipmentInventory{BarWeights: "20", Plates: "20x2, 5x2"}
+
+	tests := []struct {
+		name      string
+		inv       *EquipmentInventory
+		kg        float64
+		equipment *string
+		want      float64
+	}{
+		{"exact barbell load", &kg, 100, ptr(equipmentBarbell), 100},
+		{"barbell rounded down", &kg, 101, ptr(equipmentBarbell), 100},
+		{"barbell rounded up", &kg, 102, ptr(equipmentBarbell), 102.5},
+		{"lighter than the bar", &kg, 15, ptr(equipmentBarbell), 20},
+		{"e-z bar uses the lightest bar", &kg, 30, ptr(equipmentEZBar), 30},
+		{"plates run out", limited, 80, ptr(equipmentBarbell), 70},
+		{"dumbbell increment", &kg, 23, ptr(equipmentDumbbell), 24},
+		{"dumbbell maximum", &kg, 51, ptr(equipmentDumbbell), 50},
+		{"dumbbell minimum", &kg, 0.5, ptr(equipmentKettlebell), 2},
+		{"machine increment", &kg, 62, ptr(equipmentCable), 60},
+		{"unknown equipment", &kg, 33.3, ptr(equipmentBodyOnly), 33.3},
+		{"no equipment", &kg, 33.3, nil, 33.3},
+		{"no inventory", nil, 33.3, ptr(equipmentBarbell), 33.3},
+	}
+	for _, tt := range tests {
+		if got := tt.inv.SnapLoad(tt.kg, tt.equipment); got != tt.want {
+			t.Errorf("%s: SnapLoad(%g) = %g, want %g", tt.name, tt.kg, got, tt.want)
+		}
+	}
+}
+
+func TestLoadBreakdown(t *testing.T) {
+	kg := defaultInventories["kg"]
+	limited := &EquipmentInventory{BarWeights: "20", Plates: "20x2, 5x2"}
+
+	tests := []struct {
+		name      string
+		inv       *EquipmentInventory
+		kg        float64
+		equipment *string
+		want      string
+	}{
+		{"plates", &kg, 102.5, ptr(equipmentBarbell), "20 + 2×(25, 15, 1.25)"},
+		{"bar only", &kg, 20, ptr(equipmentBarbell), "20"},
+		{"lighter than the bar", &kg, 15, ptr(equipmentBarbell), ""},
+		{"plates run out", limited, 80, ptr(equipmentBarbell), "20 + 2×(20, 5) ≈ 70"},
+		{"not loaded with plates", &kg, 20, ptr(equipmentDumbbell), ""},
+	}
+	for _, tt := range tests {
+		if got := FormatPlateLoad(tt.inv.LoadBreakdown(tt.kg, tt.equipment), "kg"); got != tt.want {
+			t.Errorf("%s: LoadBreakdown(%g) = %q, want %q", tt.name, tt.kg, got, tt.want)
+		}
+	}
+}
+
+func TestGetEquipmentInventoryDefault(t *testing.T) {
+	forEachMigratedDB(t, func(t *testing.T, db *Database) {
+		user, err := db.GetUserByID(1)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		inv, err := db.GetEquipmentInventory(user)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if inv.ID != 0 || inv.BarWeights != defaultInventories["kg"].BarWeights {
+			t.Errorf("inventory = %+v, want the unsaved default", inv)
+		}
+		var count int64
+		if err := db.Model(&EquipmentInventory{}).Count(&count).Error; err != nil {
+			t.Fatal(err)
+		}
+		if count != 0 {
+			t.Errorf("reading the inventory saved %d rows", count)
+		}
+
+		// Saving the default creates it
+		inv.DumbbellMax = 40
+		if err := db.UpdateEquipmentInventory(inv); err != nil {
+			t.Fatal(err)
+		}
+		inv, err = db.GetEquipmentInventory(user)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if inv.ID == 0 || inv.DumbbellMax != 40 {
+			t.Errorf("inventory = %+v, want the saved one", inv)
+		}
+	})
+}
+
+func TestRoutineLoadsAreSnapped(t *testing.T) {
+	forEachMigratedDB(t, func(t *testing.T, db *Database) {
+		routine := &Routine{Name: "Legs", RoutineItems: []RoutineItem{{
+			ExerciseItems: []ExerciseItem{
+				{ExerciseID: "Barbell_Squat", Sets: []Set{{Reps: ptr[uint](5), Weight: ptr(101.0)}}},
+				{ExerciseID: "Pullups", Sets: []Set{{Reps: ptr[uint](5), Weight: ptr(3.3)}}},
+			},
+		}}}
+		if err := db.NewRoutine(routine); err != nil {
+			t.Fatal(err)
+		}
+
+		squat := routine.RoutineItems[0].ExerciseItems[0].Sets[0]
+		if *squat.Weight != 100 {
+			t.Errorf("created squat load = %g, want 100", *squat.Weight)
+		}
+		if pullup := routine.RoutineItems[0].ExerciseItems[1].Sets[0]; *pullup.Weight != 3.3 {
+			t.Errorf("created pull-up load = %g, want 3.3 as bodyweight exercises are not snapped", *pullup.Weight)
+		}
+
+		*routine.RoutineItems[0].ExerciseItems[0].Sets[0].Weight = 102
+		if err := db.UpdateRoutine(routine); err != nil {
+			t.Fatal(err)
+		}
+		if got := *routine.RoutineItems[0].ExerciseItems[0].Sets[0].Weight; got != 102.5 {
+			t.Errorf("updated squat load = %g, want 102.5", got)
+		}
+
+		squat.Weight = ptr(61.0)
+		if err := db.UpdateSet(&squat); err != nil {
+			t.Fatal(err)
+		}
+		var stored Set
+		db.First(&stored, squat.ID)
+		if *stored.Weight != 60 {
+			t.Errorf("stored set load = %g, want 60", *stored.Weight)
+		}
+	})
+}
@@ -0,0 +1,197 @@
+package database
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Time estimates used until there are enough finished workouts to learn from
+const (
+	defaultSecondsPerRep = 3
+	setupTime            = 60 // In seconds, getting ready for an exercise
+
+	minSecondsPerRep = 1
+	maxSecondsPerRep = 15
+	estimateWorkouts = 20 // Recent workouts the time per rep is learned from
+)
+
+// MuscleVolume is the number of planned sets training a muscle
+type MuscleVolume struct {
+	Muscle        string `json:"muscle"`
+	Sets          int    `json:"sets"`          // Sets where the muscle is a primary one
+	SecondarySets int    `json:"secondarySets"` // Sets where the muscle is a secondary one
+}
+
+// RoutineEstimate summarizes how long a routine takes and what it trains
+type RoutineEstimate struct {
+	Duration      uint           `json:"duration"` // In seconds
+	Sets          int            `json:"sets"`
+	SecondsPerRep float64        `json:"secondsPerRep"`
+	Muscles       []MuscleVolume `json:"muscles"`
+}
+
+// recordTiming splits a finished workout into the time spent on repetitions
+// and the number of repetitions done. Time not spent resting, setting up or on
+// timed sets is attributed to repetitions.
+func recordTiming(record *RecordRoutine) (seconds float64, reps uint) {
+	if record.Duration == nil {
+		return 0, 0
+	}
+
+	other := 0.0
+	for _, rri := range record.RecordRoutineItems {
+		for _, rei := range rri.RecordExerciseItems {
+			done := 0
+			for _, set := range rei.RecordSets {
+				if set.CompletedAt == nil {
+					continue
+				}
+				done++
+				if set.Reps != nil && *set.Reps > 0 {
+					reps += *set.Reps
+				} else if set.Duration != nil {
+					other += float64(*set.Duration)
+				}
+			}
+			if done > 0 {
+				other += setupTime + float64(done)*float64(rei.RestTime)
+			}
+		}
+	}
+
+	return float64(*record.Duration) - other, reps
+}
+
+// secondsPerRep estimates the time a repetition takes from the given workouts,
+// falling back to a default when they don't tell enough
+func secondsPerRep(records []RecordRoutine) float64 {
+	var seconds float64
+	var reps uint
+	for i := range records {
+		s, r := recordTiming(&records[i])
+		if s <= 0 || r == 0 {
+			continue
+		}
+		seconds += s
+		reps += r
+	}
+
+	if reps == 0 {
+		return defaultSecondsPerRep
+	}
+	return min(max(seconds/float64(reps), minSecondsPerRep), maxSecondsPerRep)
+}
+
+// GetSecondsPerRep learns the time per repetition from the recent finished workouts
+func (db *Database) GetSecondsPerRep() (float64, error) {
+	var records []RecordRoutine
+	err := db.
+		Preload("RecordRoutineItems").
+		Preload("RecordRoutineItems.RecordExerciseItems").
+		Preload("RecordRoutineItems.RecordExerciseItems.RecordSets").
+		Where("duration IS NOT NULL").
+		Order("created_at DESC").
+		Limit(estimateWorkouts).
+		Find(&records).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to retrieve workouts: %w", err)
+	}
+
+	return secondsPerRep(records), nil
+}
+
+// EstimateRoutine adds up the duration of the sets, the rest after each of
+// them and the setup of each exercise. The routine must be loaded with its
+// exercises and sets.
+func EstimateRoutine(routine *Routine, perRep float64) *RoutineEstimate {
+	estimate := &RoutineEstimate{SecondsPerRep: perRep, Muscles: []MuscleVolume{}}
+	muscles := map[string]*MuscleVolume{}
+	volume := func(muscle string) *MuscleVolume {
+		if muscles[muscle] == nil {
+			muscles[muscle] = &MuscleVolume{Muscle: muscle}
+		}
+		return muscles[muscle]
+	}
+
+	seconds := 0.0
+	for _, ri := range routine.RoutineItems {
+		for _, ei := range ri.ExerciseItems {
+			if len(ei.Sets) == 0 {
+				continue
+			}
+
+			seconds += setupTime
+			for _, set := range ei.Sets {
+				switch {
+				case set.Duration != nil && *set.Duration > 0:
+					seconds += float64(*set.Duration)
+				case set.Reps != nil:
+					seconds += float64(*set.Reps) * perRep
+				}
+				seconds += float64(ei.RestTime)
+			}
+
+			n := len(ei.Sets)
+			estimate.Sets += n
+			for _, muscle := range splitList(ei.Exercise.PrimaryMuscles) {
+				volume(muscle).Sets += n
+			}
+			for _, muscle := range splitList(ei.Exercise.SecondaryMuscles) {
+				volume(muscle).SecondarySets += n
+			}
+		}
+	}
+	estimate.Duration = uint(seconds)
+
+	for _, m := range muscles {
+		estimate.Muscles = append(estimate.Muscles, *m)
+	}
+	sort.Slice(estimate.Muscles, func(i, j int) bool {
+		a, b := estimate.Muscles[i], estimate.Muscles[j]
+		if a.Sets != b.Sets {
+			return a.Sets > b.Sets
+		}
+		if a.SecondarySets != b.SecondarySets {
+			return a.SecondarySets > b.SecondarySets
+		}
+		return a.Muscle < b.Muscle
+	})
+
+	return estimate
+}
+
+// GetRoutineEstimate estimates a routine loaded with its exercises and sets
+func (db *Database) GetRoutineEstimate(routine *Routine) (*RoutineEstimate, error) {
+	perRep, err := db.GetSecondsPerRep()
+	if err != nil {
+		return nil, err
+	}
+
+	return EstimateRoutine(routine, perRep), nil
+}
+
+// GetRoutineEstimates estimates every routine, by routine ID
+func (db *Database) GetRoutineEstimates() (map[uint]*RoutineEstimate, error) {
+	perRep, err := db.GetSecondsPerRep()
+	if err != nil {
+		return nil, err
+	}
+
+	var routines []Routine
+	err = db.
+		Preload("RoutineItems").
+		Preload("RoutineItems.ExerciseItems").
+		Preload("RoutineItems.ExerciseItems.Exercise").
+		Preload("RoutineItems.ExerciseItems.Sets").
+		Find(&routines).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve routines: %w", err)
+	}
+
+	estimates := make(map[uint]*RoutineEstimate, len(routines))
+	for i := range routines {
+		estimates[routines[i].ID] = EstimateRoutine(&routines[i], perRep)
+	}
+
+	return estimates, nil
+}
@@ -0,0 +1,94 @@
+package database
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+// timedRecord is a finished workout of one exercise with sets of the given reps, all done
+func timedRecord(duration uint, rest uint, reps ...uint) RecordRoutine {
+	done := time.Now()
+	rei := RecordExerciseItem{RestTime: rest}
+	for _, r := range reps {
+		rei.RecordSets = append(rei.RecordSets, RecordSet{Reps: ptr(r), CompletedAt: &done})
+	}
+	rei.RecordSets = append(rei.RecordSets, RecordSet{Reps: ptr[uint](10)}) // Not done
+	return RecordRoutine{
+		Duration:           &duration,
+		RecordRoutineItems: []RecordRoutineItem{{RecordExerciseItems: []RecordExerciseItem{rei}}},
+	}
+}
+
+func TestSecondsPerRep(t *testing.T) {
+	timed := timedRecord(400, 60)
+	timed.RecordRoutineItems[0].RecordExerciseItems[0].RecordSets = []RecordSet{
+		{Duration: ptr[uint](60), CompletedAt: ptr(time.Now())},
+	}
+	unfinished := timedRecord(300, 60, 10, 10)
+	unfinished.Duration = nil
+
+	tests := []struct {
+		name    string
+		records []RecordRoutine
+		want    float64
+	}{
+		{"no workouts", nil, defaultSecondsPerRep},
+		// 300s minus 60s of setup and 2x60s of rest, for 20 reps
+		{"one workout", []RecordRoutine{timedRecord(300, 60, 10, 10)}, 6},
+		{"several workouts", []RecordRoutine{timedRecord(300, 60, 10, 10), timedRecord(120, 30, 10)}, 5},
+		{"timed sets and unfinished workouts are ignored", []RecordRoutine{timed, unfinished, timedRecord(300, 60, 10, 10)}, 6},
+		{"too slow", []RecordRoutine{timedRecord(3600, 60, 10, 10)}, maxSecondsPerRep},
+		{"too fast", []RecordRoutine{timedRecord(190, 60, 10, 10)}, minSecondsPerRep},
+		{"nothing left for the reps", []RecordRoutine{timedRecord(100, 60, 10, 10)}, defaultSecondsPerRep},
+	}
+	for _, tt := range tests {
+		if got := secondsPerRep(tt.records); got != tt.want {
+			t.Errorf("%s: seconds per rep = %g, want %g", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestEstimateRoutine(t *testing.T) {
+	routine := &Routine{RoutineItems: []RoutineItem{
+		{ExerciseItems: []ExerciseItem{{
+			RestTime: 120,
+			Exercise: Exercise{PrimaryMuscles: ptr("quadriceps"), SecondaryMuscles: ptr("glutes, hamstrings")},
+			Sets:     []Set{{Reps: ptr[uint](5)}, {Reps: ptr[uint](5)}, {Reps: ptr[uint](5)}},
+		}}},
+		{ExerciseItems: []ExerciseItem{
+			{
+				RestTime: 30,
+				Exercise: Exercise{PrimaryMuscles: ptr("abdominals")},
+				Sets:     []Set{{Duration: ptr[uint](60)}, {Duration: ptr[uint](60)}},
+			},
+			{Exercise: Exercise{PrimaryMuscles: ptr("calves")}}, // No sets
+		}},
+	}}
+
+	estimate := EstimateRoutine(routine, 3)
+	// 60s of setup and 3x(5x3s + 120s) for the squats, 60s and 2x(60s + 30s) for the plank
+	if estimate.Duration != 705 || estimate.Sets != 5 || estimate.SecondsPerRep != 3 {
+		t.Errorf("estimate = %ds for %d sets at %gs per rep, want 705s for 5 sets at 3s", estimate.Duration, estimate.Sets, estimate.SecondsPerRep)
+	}
+
+	want := []MuscleVolume{{"quadriceps", 3, 0}, {"abdominals", 2, 0}, {"glutes", 0, 3}, {"hamstrings", 0, 3}}
+	if fmt.Sprint(estimate.Muscles) != fmt.Sprint(want) {
+		t.Errorf("muscles = %v, want %v", estimate.Muscles, want)
+	}
+}
+
+func TestGetRoutineEstimates(t *testing.T) {
+	forEachMigratedDB(t, func(t *testing.T, db *Database) {
+		routine := programRoutine(t, db, "Legs")
+
+		estimates, err := db.GetRoutineEstimates()
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Without finished workouts the default time per rep is used
+		if e := estimates[routine.ID]; e == nil || e.Duration != setupTime+3*5*defaultSecondsPerRep || e.Sets != 3 {
+			t.Errorf("estimate of %s = %+v", routine.Name, e)
+		}
+	})
+}
@@ -4,10 +4,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"net/http"
 	"strings"
 	"time"
+
+	"github.com/birabittoh/go-lift/src/config"
+	"github.com/birabittoh/go-lift/src/metrics"
 )
 
 // ImportedExercise represents the JSON structure from the input file
@@ -118,7 +121,7 @@ func stringPointersEqual(a, b *string) bool {
 // downloadExercises downloads exercises from the JSON URL
 func downloadExercises() ([]importedExercise, error) {
 	// Download exercises.json from the URL
-	resp, err := http.Get(jsonURL)
+	resp, err := http.Get(catalogURL + "dist/exercises.json")
 	if err != nil {
 		return nil, fmt.Errorf("failed to download exercises.json: %w", err)
 	}
@@ -143,20 +146,16 @@ func downloadExercises() ([]importedExercise, error) {
 	return exercises, nil
 }
 
-const (
-	dbDir       = "data"
-	dbName      = "fitness.sqlite"
-	baseURL     = "https://raw.githubusercontent.com/yuhonas/free-exercise-db/main/"
-	jsonURL     = baseURL + "dist/exercises.json"
-	imageFormat = baseURL + "exercises/%s/%d.jpg"
-	imageAmount = 2
-)
+const imageAmount = 2
+
+// catalogURL is the base URL of the exercise catalog, see config.CatalogURL
+var catalogURL = config.DefaultCatalogURL
 
 var lastUpdate = time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC)
 
 func (e Exercise) GetImages() (images []string) {
 	for i := range imageAmount {
-		images = append(images, fmt.Sprintf(imageFormat, e.ID, i))
+		images = append(images, fmt.Sprintf("%sexercises/%s/%d.jpg", catalogURL, e.ID, i))
 	}
 	return
 }
@@ -178,10 +177,11 @@ func (db *Database) UpdateExercises() (err error) {
 	// Load exercises
 	exercises, err := downloadExercises()
 	if err != nil {
-		log.Fatalf("Failed to load exercises: %v", err)
+		metrics.CatalogSynced(0, 0, 0, err)
+		return fmt.Errorf("failed to load exercises: %w", err)
 	}
 
-	log.Printf("Successfully loaded %d exercises from JSON", len(exercises))
+	slog.Info("Exercises downloaded", "exercises", len(exercises))
 
 	var successCount, createCount, updateCount int
 
@@ -189,7 +189,7 @@ func (db *Database) UpdateExercises() (err error) {
 	for i, exercise := range exercises {
 		didSave, isUpdate, err := db.upsertExercise(exercise)
 		if err != nil {
-			log.Printf("Failed to upsert exercise %d (%s): %v", i+1, exercise.Name, err)
+			slog.Warn("Failed to upsert exercise", "index", i+1, "name", exercise.Name, "error", err)
 			continue
 		}
 
@@ -204,8 +204,9 @@ func (db *Database) UpdateExercises() (err error) {
 	}
 
 	lastUpdate = time.Now()
+	metrics.CatalogSynced(createCount, updateCount, len(exercises)-successCount, nil)
 
-	log.Printf("Update completed successfully! Processed %d out of %d exercises (%d created, %d updated)", successCount, len(exercises), createCount, updateCount)
+	slog.Info("Exercises updated", "processed", successCount, "total", len(exercises), "created", createCount, "updated", updateCount)
 	return
 }
 
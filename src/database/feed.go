@@ -0,0 +1,123 @@
+package database
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+const feedTokenBytes = 24
+
+// WorkoutSummary describes a finished workout
+type WorkoutSummary struct {
+	RecordRoutineID uint      `json:"recordRoutineId"`
+	RoutineName     string    `json:"routineName"`
+	Start           time.Time `json:"start"`
+	Duration        uint      `json:"duration"` // In seconds
+	Volume          float64   `json:"volume"`   // In kg
+	Sets            int       `json:"sets"`     // Completed sets
+	Exercises       []string  `json:"exercises"`
+	ProgramName     string    `json:"programName,omitempty"`
+	ProgramWeek     *int      `json:"programWeek,omitempty"`
+}
+
+func newFeedToken() (string, error) {
+	b := make([]byte, feedTokenBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// GetFeedToken returns the calendar feed token of a user, creating it if needed
+func (db *Database) GetFeedToken(user *User) (string, error) {
+	if user.FeedToken != nil {
+		return *user.FeedToken, nil
+	}
+	return db.ResetFeedToken(user)
+}
+
+// ResetFeedToken replaces the calendar feed token of a user, revoking the old feed URL
+func (db *Database) ResetFeedToken(user *User) (string, error) {
+	token, err := newFeedToken()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate feed token: %w", err)
+	}
+
+	if err := db.Model(user).Update("feed_token", token).Error; err != nil {
+		return "", fmt.Errorf("failed to save feed token: %w", err)
+	}
+	user.FeedToken = &token
+
+	return token, nil
+}
+
+func (db *Database) GetUserByFeedToken(token string) (*User, error) {
+	if token == "" {
+		return nil, fmt.Errorf("missing feed token")
+	}
+
+	var user User
+	if err := db.Where("feed_token = ?", token).First(&user).Error; err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+// GetWorkoutSummaries summarizes the workouts finished since a given date
+func (db *Database) GetWorkoutSummaries(since time.Time) ([]WorkoutSummary, error) {
+	var records []RecordRoutine
+	err := db.
+		Preload("Program").
+		Preload("RecordRoutineItems").
+		Preload("RecordRoutineItems.RecordExerciseItems").
+		Preload("RecordRoutineItems.RecordExerciseItems.RecordSets").
+		Where("duration IS NOT NULL AND created_at >= ?", since).
+		Order("created_at").
+		Find(&records).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve workouts: %w", err)
+	}
+
+	bw, err := db.getBodyWeights()
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve body weight: %w", err)
+	}
+
+	summaries := make([]WorkoutSummary, 0, len(records))
+	for i := range records {
+		record := &records[i]
+		sortRecordRoutine(record)
+
+		summary := WorkoutSummary{
+			RecordRoutineID: record.ID,
+			RoutineName:     record.RoutineName,
+			Start:           record.CreatedAt,
+			Duration:        *record.Duration,
+			Volume:          recordVolume(record, bw.At(record.CreatedAt)),
+			Exercises:       []string{},
+		}
+
+		if record.Program != nil {
+			summary.ProgramName = record.Program.Name
+			summary.ProgramWeek = record.ProgramWeek
+		}
+
+		for _, rri := range record.RecordRoutineItems {
+			for _, rei := range rri.RecordExerciseItems {
+				summary.Exercises = append(summary.Exercises, rei.ExerciseName)
+				for _, set := range rei.RecordSets {
+					if set.CompletedAt != nil {
+						summary.Sets++
+					}
+				}
+			}
+		}
+
+		summaries = append(summaries, summary)
+	}
+
+	return summaries, nil
+}
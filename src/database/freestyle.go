@@ -0,0 +1,178 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+
+	g "github.com/birabittoh/go-lift/src/globals"
+)
+
+const freestyleName = "Freestyle workout"
+
+// NewFreestyleRecordRoutine starts a workout without a routine, exercises and
+// sets are added while training.
+func (db *Database) NewFreestyleRecordRoutine(name string) (*RecordRoutine, error) {
+	if db.GetCurrentWorkout() != nil {
+		return nil, fmt.Errorf("a workout is already in progress")
+	}
+
+	name = strings.TrimSpace(name)
+	if name == "" {
+		name = freestyleName
+	}
+
+	record := &RecordRoutine{RoutineName: g.Truncate(name, 100)}
+	if err := db.Create(record).Error; err != nil {
+		return nil, fmt.Errorf("failed to create new record routine: %w", err)
+	}
+
+	return record, nil
+}
+
+// AddRecordExercise appends an exercise with one empty set to a workout in progress
+func (db *Database) AddRecordExercise(record *RecordRoutine, exerciseID string) (*RecordExerciseItem, error) {
+	if record.Duration != nil {
+		return nil, fmt.Errorf("workout is already finished")
+	}
+
+	exercise, err := db.GetExerciseByID(exerciseID)
+	if err != nil {
+		return nil, fmt.Errorf("exercise not found: %s", exerciseID)
+	}
+
+	var count int64
+	if err := db.Model(&RecordRoutineItem{}).Where("record_routine_id = ?", record.ID).Count(&count).Error; err != nil {
+		return nil, fmt.Errorf("failed to count record routine items: %w", err)
+	}
+
+	item := &RecordRoutineItem{
+		RecordRoutineID: record.ID,
+		OrderIndex:      int(count),
+		RecordExerciseItems: []RecordExerciseItem{{
+			ExerciseID:   exercise.ID,
+			ExerciseName: exercise.Name,
+			RecordSets:   []RecordSet{{}},
+		}},
+	}
+	if err := db.Omit("RecordRoutine").Create(item).Error; err != nil {
+		return nil, fmt.Errorf("failed to add exercise: %w", err)
+	}
+
+	return &item.RecordExerciseItems[0], nil
+}
+
+// findRecordExerciseItem looks an exercise up within a loaded workout
+func findRecordExerciseItem(record *RecordRoutine, id uint) *RecordExerciseItem {
+	for i := range record.RecordRoutineItems {
+		items := record.RecordRoutineItems[i].RecordExerciseItems
+		for j := range items {
+			if items[j].ID == id {
+				return &items[j]
+			}
+		}
+	}
+	return nil
+}
+
+// AddRecordSet appends a set to an exercise of a workout in progress, filled
+// in with the values of the last set. The record must be loaded with its sets.
+func (db *Database) AddRecordSet(record *RecordRoutine, recordExerciseItemID uint) (*RecordSet, error) {
+	if record.Duration != nil {
+		return nil, fmt.Errorf("workout is already finished")
+	}
+
+	rei := findRecordExerciseItem(record, recordExerciseItemID)
+	if rei == nil {
+		return nil, fmt.Errorf("exercise %d is not part of this workout", recordExerciseItemID)
+	}
+
+	l := len(rei.RecordSets)
+	set := &RecordSet{RecordExerciseItemID: rei.ID, OrderIndex: l}
+	if l > 0 {
+		lastSet := rei.RecordSets[l-1]
+
+		set.Reps = lastSet.Reps
+		set.Weight = lastSet.Weight
+		set.Duration = lastSet.Duration
+		set.OrderIndex = lastSet.OrderIndex + 1
+	}
+
+	if err := db.Create(set).Error; err != nil {
+		return nil, fmt.Errorf("failed to create new record set: %w", err)
+	}
+
+	return set, nil
+}
+
+// SaveRecordAsRoutine creates a routine from what was done in a workout, using
+// the completed sets of each exercise, or all of them if none was completed.
+// Exercises missing from the catalog are left out. A workout without a routine
+// is linked to the new one.
+func (db *Database) SaveRecordAsRoutine(record *RecordRoutine, name string) (*Routine, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		name = record.RoutineName
+	}
+
+	routine := &Routine{
+		Name:        g.Truncate(name, 100),
+		Description: "Saved from the workout of " + record.CreatedAt.Format("02 Jan 2006") + ".",
+	}
+
+	for _, rri := range record.RecordRoutineItems {
+		item := RoutineItem{OrderIndex: len(routine.RoutineItems)}
+		for _, rei := range rri.RecordExerciseItems {
+			exercise, err := db.GetExerciseByID(rei.ExerciseID)
+			if err != nil {
+				continue
+			}
+
+			sets := rei.RecordSets
+			var completed []RecordSet
+			for _, set := range sets {
+				if set.CompletedAt != nil {
+					completed = append(completed, set)
+				}
+			}
+			if len(completed) > 0 {
+				sets = completed
+			}
+
+			exerciseItem := ExerciseItem{
+				ExerciseID: exercise.ID,
+				RestTime:   rei.RestTime,
+				Notes:      rei.Notes,
+				OrderIndex: len(item.ExerciseItems),
+			}
+			for i, set := range sets {
+				s := SetSnapshot{Reps: set.Reps, Weight: set.Weight, Duration: set.Duration}
+				if err := validateSetSnapshot(s, exercise.IsBodyweight()); err != nil {
+					return nil, fmt.Errorf("%s, set %d: %w", exercise.Name, i+1, err)
+				}
+				exerciseItem.Sets = append(exerciseItem.Sets, Set{Reps: set.Reps, Weight: set.Weight, Duration: set.Duration})
+			}
+			item.ExerciseItems = append(item.ExerciseItems, exerciseItem)
+		}
+
+		if len(item.ExerciseItems) > 0 {
+			routine.RoutineItems = append(routine.RoutineItems, item)
+		}
+	}
+
+	if len(routine.RoutineItems) == 0 {
+		return nil, fmt.Errorf("workout has no exercises to save")
+	}
+
+	if err := db.NewRoutine(routine); err != nil {
+		return nil, err
+	}
+
+	if record.RoutineID == nil {
+		if err := db.Model(record).Update("routine_id", routine.ID).Error; err != nil {
+			return nil, fmt.Errorf("failed to link workout to routine: %w", err)
+		}
+		record.RoutineID = &routine.ID
+	}
+
+	return routine, nil
+}
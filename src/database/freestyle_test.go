@@ -0,0 +1,98 @@
+package database
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFreestyleWorkout(t *testing.T) {
+	forEachMigratedDB(t, func(t *testing.T, db *Database) {
+		record, err := db.NewFreestyleRecordRoutine("  ")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if record.RoutineName != freestyleName || record.RoutineID != nil {
+			t.Errorf("record = %q with routine %v, want %q without one", record.RoutineName, record.RoutineID, freestyleName)
+		}
+		if _, err := db.NewFreestyleRecordRoutine("Again"); err == nil {
+			t.Error("started a second workout while one is in progress")
+		}
+
+		if _, err := db.AddRecordExercise(record, "Missing"); err == nil {
+			t.Error("added an exercise missing from the catalog")
+		}
+		squat, err := db.AddRecordExercise(record, "Barbell_Squat")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := db.AddRecordExercise(record, "Pullups"); err != nil {
+			t.Fatal(err)
+		}
+
+		// The first set of the squats is filled in and completed, the new
+		// set copies it and the pullups are never done
+		completed := time.Now()
+		err = db.Model(&squat.RecordSets[0]).Updates(RecordSet{Reps: ptr[uint](5), Weight: ptr(80.0), CompletedAt: &completed}).Error
+		if err != nil {
+			t.Fatal(err)
+		}
+		record, err = db.GetRecordRoutineByID(record.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		set, err := db.AddRecordSet(record, squat.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if set.OrderIndex != 1 || set.Reps == nil || *set.Reps != 5 || set.Weight == nil || *set.Weight != 80 || set.CompletedAt != nil {
+			t.Errorf("new set = %+v, want the values of the last one, not completed", set)
+		}
+
+		record, err = db.GetRecordRoutineByID(record.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(record.RecordRoutineItems) != 2 || record.RecordRoutineItems[0].RecordExerciseItems[0].ExerciseID != "Barbell_Squat" {
+			t.Fatalf("workout items = %+v, want the squats then the pullups", record.RecordRoutineItems)
+		}
+
+		err = db.Model(&RecordSet{}).Where("record_exercise_item_id = ?", record.RecordRoutineItems[1].RecordExerciseItems[0].ID).Update("reps", 8).Error
+		if err != nil {
+			t.Fatal(err)
+		}
+		record, err = db.GetRecordRoutineByID(record.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		routine, err := db.SaveRecordAsRoutine(record, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if record.RoutineID == nil || *record.RoutineID != routine.ID {
+			t.Errorf("workout routine = %v, want the saved one %d", record.RoutineID, routine.ID)
+		}
+
+		saved, err := db.GetRoutineByID(routine.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if saved.Name != freestyleName || len(saved.RoutineItems) != 2 {
+			t.Fatalf("saved routine = %q with %d items, want %q with 2", saved.Name, len(saved.RoutineItems), freestyleName)
+		}
+		if sets := saved.RoutineItems[0].ExerciseItems[0].Sets; len(sets) != 1 || *sets[0].Reps != 5 || *sets[0].Weight != 80 {
+			t.Errorf("squat sets = %+v, want only the completed one", sets)
+		}
+		if sets := saved.RoutineItems[1].ExerciseItems[0].Sets; len(sets) != 1 || *sets[0].Reps != 8 {
+			t.Errorf("pullup sets = %+v, want the planned one", sets)
+		}
+
+		stored, err := db.GetRecordRoutineByID(record.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stored.RoutineID == nil || *stored.RoutineID != routine.ID {
+			t.Errorf("stored workout routine = %v, want %d", stored.RoutineID, routine.ID)
+		}
+	})
+}
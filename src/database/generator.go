@@ -0,0 +1,280 @@
+package database
+
+import (
+	"fmt"
+	"math/rand"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	g "github.com/birabittoh/go-lift/src/globals"
+	"gorm.io/gorm"
+)
+
+// Training goals of generated routines
+const (
+	GoalStrength    = "strength"
+	GoalHypertrophy = "hypertrophy"
+	GoalEndurance   = "endurance"
+)
+
+var exerciseLevels = []string{"beginner", "intermediate", "expert"}
+
+// goalScheme is the set and rep scheme used for a goal
+type goalScheme struct {
+	Sets       int
+	Reps       uint
+	RestTime   uint     // In seconds
+	Categories []string // Exercise categories that fit the goal
+}
+
+var goalSchemes = map[string]goalScheme{
+	GoalStrength:    {Sets: 5, Reps: 5, RestTime: 180, Categories: []string{"strength", "powerlifting"}},
+	GoalHypertrophy: {Sets: 4, Reps: 10, RestTime: 90, Categories: []string{"strength"}},
+	GoalEndurance:   {Sets: 3, Reps: 15, RestTime: 45, Categories: []string{"strength", "plyometrics"}},
+}
+
+// sessionTemplate lists the muscles a session trains, most important first
+type sessionTemplate struct {
+	Name    string
+	Muscles []string
+}
+
+var (
+	sessionFullBody = sessionTemplate{"Full body", []string{"quadriceps", "chest", "lats", "hamstrings", "shoulders", "middle back", "glutes", "abdominals", "biceps", "triceps", "calves"}}
+	sessionUpper    = sessionTemplate{"Upper", []string{"chest", "lats", "shoulders", "middle back", "biceps", "triceps", "traps", "forearms"}}
+	sessionLower    = sessionTemplate{"Lower", []string{"quadriceps", "hamstrings", "glutes", "calves", "lower back", "abdominals", "adductors", "abductors"}}
+	sessionPush     = sessionTemplate{"Push", []string{"chest", "shoulders", "triceps"}}
+	sessionPull     = sessionTemplate{"Pull", []string{"lats", "middle back", "biceps", "traps", "forearms", "lower back"}}
+	sessionLegs     = sessionTemplate{"Legs", []string{"quadriceps", "hamstrings", "glutes", "calves", "abdominals"}}
+)
+
+// weeklySplits gives the sessions and their days (0 is Monday) for each number of days per week
+var weeklySplits = map[int]struct {
+	Sessions []sessionTemplate
+	Days     []int
+}{
+	1: {[]sessionTemplate{sessionFullBody}, []int{0}},
+	2: {[]sessionTemplate{sessionFullBody, sessionFullBody}, []int{0, 3}},
+	3: {[]sessionTemplate{sessionPush, sessionPull, sessionLegs}, []int{0, 2, 4}},
+	4: {[]sessionTemplate{sessionUpper, sessionLower, sessionUpper, sessionLower}, []int{0, 1, 3, 4}},
+	5: {[]sessionTemplate{sessionPush, sessionPull, sessionLegs, sessionUpper, sessionLower}, []int{0, 1, 2, 3, 4}},
+	6: {[]sessionTemplate{sessionPush, sessionPull, sessionLegs, sessionPush, sessionPull, sessionLegs}, []int{0, 1, 2, 3, 4, 5}},
+	7: {[]sessionTemplate{sessionPush, sessionPull, sessionLegs, sessionPush, sessionPull, sessionLegs, sessionFullBody}, []int{0, 1, 2, 3, 4, 5, 6}},
+}
+
+// GeneratorOptions are the goals and constraints of a generated routine set
+type GeneratorOptions struct {
+	Name           string   `json:"name"` // Prefix of the routine names
+	DaysPerWeek    int      `json:"daysPerWeek"`
+	SessionMinutes int      `json:"sessionMinutes"`
+	Equipment      []string `json:"equipment"` // Empty to allow any equipment
+	Level          string   `json:"level"`
+	Goal           string   `json:"goal"`
+	Seed           int64    `json:"seed"` // The same seed and options give the same routines, 0 picks one
+}
+
+func (o *GeneratorOptions) validate() error {
+	if _, ok := weeklySplits[o.DaysPerWeek]; !ok {
+		return fmt.Errorf("days per week must be between 1 and 7")
+	}
+	if o.SessionMinutes < 15 || o.SessionMinutes > 240 {
+		return fmt.Errorf("session length must be between 15 and 240 minutes")
+	}
+	if !slices.Contains(exerciseLevels, o.Level) {
+		return fmt.Errorf("unknown level: %s", o.Level)
+	}
+	if _, ok := goalSchemes[o.Goal]; !ok {
+		return fmt.Errorf("unknown goal: %s", o.Goal)
+	}
+	return nil
+}
+
+// exercisesPerSession fits as many exercises as the session length allows
+func (s goalScheme) exercisesPerSession(minutes int) int {
+	perExercise := s.Sets*(int(s.Reps)*defaultSecondsPerRep+int(s.RestTime)) + setupTime
+	return min(max(minutes*60/perExercise, 2), 10)
+}
+
+// generator picks exercises for the sessions of a routine set
+type generator struct {
+	rng        *rand.Rand
+	candidates map[string][]Exercise // Candidate exercises by primary muscle, sorted by ID
+	used       map[string]int        // Times an exercise was picked this week
+}
+
+func newGenerator(exercises []Exercise, opts *GeneratorOptions) *generator {
+	scheme := goalSchemes[opts.Goal]
+	maxLevel := slices.Index(exerciseLevels, opts.Level)
+
+	gen := &generator{
+		rng:        rand.New(rand.NewSource(opts.Seed)),
+		candidates: map[string][]Exercise{},
+		used:       map[string]int{},
+	}
+
+	// The catalog order is not guaranteed, sorting keeps the result reproducible
+	sort.Slice(exercises, func(i, j int) bool { return exercises[i].ID < exercises[j].ID })
+
+	for _, e := range exercises {
+		if !slices.Contains(scheme.Categories, e.Category) || !equipmentAllowed(opts.Equipment, e.Equipment) {
+			continue
+		}
+		if level := slices.Index(exerciseLevels, e.Level); level < 0 || level > maxLevel {
+			continue
+		}
+		for _, muscle := range splitList(e.PrimaryMuscles) {
+			gen.candidates[muscle] = append(gen.candidates[muscle], e)
+		}
+	}
+
+	return gen
+}
+
+// pick chooses an exercise for a muscle, preferring compound exercises for the
+// first slots and exercises not used yet this week
+func (gen *generator) pick(muscle string, compound bool, session map[string]bool) *Exercise {
+	var best []Exercise
+	bestRank := -1
+	for _, e := range gen.candidates[muscle] {
+		if session[e.ID] {
+			continue
+		}
+
+		rank := 0
+		if gen.used[e.ID] == 0 {
+			rank += 2
+		}
+		if compound == (e.Mechanic != nil && *e.Mechanic == "compound") {
+			rank++
+		}
+
+		if rank > bestRank {
+			best, bestRank = nil, rank
+		}
+		if rank == bestRank {
+			best = append(best, e)
+		}
+	}
+
+	if len(best) == 0 {
+		return nil
+	}
+
+	e := best[gen.rng.Intn(len(best))]
+	gen.used[e.ID]++
+	return &e
+}
+
+// session picks the exercises of a session, going through its muscles in turn
+// so that they are all covered before any is trained twice. offset rotates the
+// starting muscle between sessions of the same kind.
+func (gen *generator) session(template sessionTemplate, count, offset int) []Exercise {
+	picked := map[string]bool{}
+	var exercises []Exercise
+
+	for attempt := 0; len(exercises) < count && attempt < count*len(template.Muscles); attempt++ {
+		muscle := template.Muscles[(attempt+offset)%len(template.Muscles)]
+		compound := len(exercises) < (count+1)/2
+
+		if e := gen.pick(muscle, compound, picked); e != nil {
+			picked[e.ID] = true
+			exercises = append(exercises, *e)
+		}
+	}
+
+	return exercises
+}
+
+// GenerateRoutines creates a routine for every training day of the week, with
+// exercises from the catalog that cover the muscles of each session.
+func (db *Database) GenerateRoutines(opts GeneratorOptions) ([]Routine, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+	if opts.Seed == 0 {
+		opts.Seed = time.Now().UnixNano()
+	}
+	opts.Name = g.Truncate(strings.TrimSpace(opts.Name), 80)
+	if opts.Name == "" {
+		opts.Name = "Generated"
+	}
+	for i, e := range opts.Equipment {
+		opts.Equipment[i] = strings.ToLower(strings.TrimSpace(e))
+	}
+
+	exercises, err := db.GetExercises()
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve exercises: %w", err)
+	}
+
+	routines, err := generateRoutines(exercises, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		for i := range routines {
+			if err := tx.Create(&routines[i]).Error; err != nil {
+				return fmt.Errorf("failed to create routine: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return routines, nil
+}
+
+// generateRoutines builds the routines of GenerateRoutines from the given
+// catalog without saving them; the same catalog and seed give the same result.
+func generateRoutines(exercises []Exercise, opts GeneratorOptions) ([]Routine, error) {
+	gen := newGenerator(exercises, &opts)
+	scheme := goalSchemes[opts.Goal]
+	count := scheme.exercisesPerSession(opts.SessionMinutes)
+	split := weeklySplits[opts.DaysPerWeek]
+
+	// Sessions of the same kind are told apart by a letter
+	kinds := map[string]int{}
+	for _, s := range split.Sessions {
+		kinds[s.Name]++
+	}
+	seen := map[string]int{}
+
+	var routines []Routine
+	for i, template := range split.Sessions {
+		routineName := opts.Name + " " + template.Name
+		if kinds[template.Name] > 1 {
+			routineName += " " + string(rune('A'+seen[template.Name]))
+		}
+
+		routine := Routine{
+			Name:        routineName,
+			Description: fmt.Sprintf("%s, %d minutes, %s level (seed %d).", g.Capitalize(opts.Goal), opts.SessionMinutes, opts.Level, opts.Seed),
+			Days:        []Day{*dayByName(weekDays[split.Days[i]])},
+		}
+
+		for _, e := range gen.session(template, count, seen[template.Name]*count) {
+			item := ExerciseItem{ExerciseID: e.ID, RestTime: scheme.RestTime}
+			for range scheme.Sets {
+				reps := scheme.Reps
+				item.Sets = append(item.Sets, Set{Reps: &reps})
+			}
+			routine.RoutineItems = append(routine.RoutineItems, RoutineItem{
+				OrderIndex:    len(routine.RoutineItems),
+				ExerciseItems: []ExerciseItem{item},
+			})
+		}
+		seen[template.Name]++
+
+		if len(routine.RoutineItems) == 0 {
+			return nil, fmt.Errorf("no exercises match the %s session with the given equipment and level", template.Name)
+		}
+		routines = append(routines, routine)
+	}
+
+	return routines, nil
+}
@@ -0,0 +1,125 @@
+package database
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// generatorCatalog has several exercises of every kind for each muscle the
+// sessions train, so that seeds have a choice to make.
+func generatorCatalog() []Exercise {
+	muscles := []string{"quadriceps", "chest", "lats", "hamstrings", "shoulders", "middle back", "glutes",
+		"abdominals", "biceps", "triceps", "calves", "traps", "forearms", "lower back", "adductors", "abductors"}
+
+	var exercises []Exercise
+	for _, muscle := range muscles {
+		for i, equipment := range []string{"barbell", "dumbbell", "cable", "barbell", "dumbbell", "machine"} {
+			mechanic, level := "compound", "beginner"
+			if i%2 == 1 {
+				mechanic = "isolation"
+			}
+			if i == 5 {
+				level = "expert"
+			}
+			id := fmt.Sprintf("%s_%d", strings.ReplaceAll(muscle, " ", "_"), i)
+			exercises = append(exercises, Exercise{
+				ID:             id,
+				Name:           id,
+				Level:          level,
+				Category:       "strength",
+				Mechanic:       ptr(mechanic),
+				Equipment:      ptr(equipment),
+				PrimaryMuscles: ptr(muscle),
+			})
+		}
+		exercises = append(exercises, Exercise{
+			ID:             muscle + "_stretch",
+			Name:           muscle + " stretch",
+			Level:          "beginner",
+			Category:       "stretching",
+			PrimaryMuscles: ptr(muscle),
+		})
+	}
+	return exercises
+}
+
+// routinesSummary describes generated routines by name, day and exercises
+func routinesSummary(routines []Routine) string {
+	var b strings.Builder
+	for _, r := range routines {
+		b.WriteString(r.Name + " on " + r.Days[0].Name + ":")
+		for _, ri := range r.RoutineItems {
+			b.WriteString(" " + ri.ExerciseItems[0].ExerciseID)
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+func TestGenerateRoutinesSeed(t *testing.T) {
+	catalog := generatorCatalog()
+	byID := map[string]Exercise{}
+	for _, e := range catalog {
+		byID[e.ID] = e
+	}
+
+	tests := []struct {
+		name string
+		opts GeneratorOptions
+	}{
+		{"full body", GeneratorOptions{DaysPerWeek: 2, SessionMinutes: 60, Level: "beginner", Goal: GoalHypertrophy}},
+		{"push pull legs", GeneratorOptions{DaysPerWeek: 3, SessionMinutes: 90, Level: "expert", Goal: GoalStrength}},
+		{"upper lower with dumbbells", GeneratorOptions{DaysPerWeek: 4, SessionMinutes: 45, Level: "intermediate", Goal: GoalEndurance, Equipment: []string{"dumbbell", "cable"}}},
+		{"every day", GeneratorOptions{DaysPerWeek: 7, SessionMinutes: 30, Level: "beginner", Goal: GoalHypertrophy}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			generate := func(catalog []Exercise, seed int64) string {
+				t.Helper()
+				opts := tt.opts
+				opts.Name = "Test"
+				opts.Seed = seed
+				routines, err := generateRoutines(slices.Clone(catalog), opts)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(routines) != opts.DaysPerWeek {
+					t.Fatalf("got %d routines, want %d", len(routines), opts.DaysPerWeek)
+				}
+
+				for _, r := range routines {
+					seen := map[string]bool{}
+					for _, ri := range r.RoutineItems {
+						e := byID[ri.ExerciseItems[0].ExerciseID]
+						if seen[e.ID] {
+							t.Errorf("%s has %s twice", r.Name, e.ID)
+						}
+						seen[e.ID] = true
+						if e.Category != "strength" || !equipmentAllowed(opts.Equipment, e.Equipment) || (opts.Level != "expert" && e.Level == "expert") {
+							t.Errorf("%s has %s, which does not match the options", r.Name, e.ID)
+						}
+					}
+				}
+				return routinesSummary(routines)
+			}
+
+			first := generate(catalog, 1)
+			if again := generate(catalog, 1); again != first {
+				t.Errorf("the same seed gave different routines:\n%s\n%s", first, again)
+			}
+
+			// The order of the catalog does not matter
+			reversed := slices.Clone(catalog)
+			slices.Reverse(reversed)
+			if again := generate(reversed, 1); again != first {
+				t.Errorf("the same seed gave different routines from a reordered catalog:\n%s\n%s", first, again)
+			}
+
+			if other := generate(catalog, 2); other == first {
+				t.Errorf("another seed gave the same routines:\n%s", first)
+			}
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package database
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/birabittoh/go-lift/src/config"
+	"gorm.io/gorm"
+)
+
+// testDSNEnv names the Postgres database the tests also run against. The
+// database is emptied by every test, don't point it to one in use.
+const testDSNEnv = "GOLIFT_TEST_DSN"
+
+// testConfigs returns the databases the tests run against: SQLite in a
+// temporary directory and, when testDSNEnv is set, Postgres
+func testConfigs(t *testing.T) map[string]*config.Config {
+	t.Helper()
+
+	sqlite := config.Default()
+	sqlite.DBPath = filepath.Join(t.TempDir(), "fitness.sqlite")
+	sqlite.DBLogLevel = config.LogSilent
+	configs := map[string]*config.Config{config.DriverSQLite: sqlite}
+
+	if dsn := os.Getenv(testDSNEnv); dsn != "" {
+		postgres := config.Default()
+		postgres.DBDriver = config.DriverPostgres
+		postgres.DBDSN = dsn
+		postgres.DBLogLevel = config.LogSilent
+		postgres.DBPath = filepath.Join(t.TempDir(), "fitness.sqlite") // Migration backups are written next to it
+		configs[config.DriverPostgres] = postgres
+	} else if os.Getenv("CI") != "" {
+		t.Fatalf("%s is required in CI, the Postgres tests would be skipped", testDSNEnv)
+	} else {
+		configs[config.DriverPostgres] = nil
+	}
+
+	return configs
+}
+
+// forEachDriver runs a test against every database of testConfigs, starting
+// with an empty one
+func forEachDriver(t *testing.T, fn func(t *testing.T, db *Database)) {
+	for driver, cfg := range testConfigs(t) {
+		t.Run(driver, func(t *testing.T) {
+			if cfg == nil {
+				t.Skipf("%s is not set", testDSNEnv)
+			}
+
+			db, err := OpenDB(cfg)
+			if err != nil {
+				t.Fatalf("failed to open database: %v", err)
+			}
+			t.Cleanup(func() { db.Close() })
+
+			if driver == config.DriverPostgres {
+				err := db.Exec("DROP SCHEMA public CASCADE").Error
+				if err == nil {
+					err = db.Exec("CREATE SCHEMA public").Error
+				}
+				if err != nil {
+					t.Fatalf("failed to empty database: %v", err)
+				}
+			}
+
+			fn(t, db)
+		})
+	}
+}
+
+// testExercises are added to the test databases instead of the catalog
+var testExercises = []Exercise{
+	{ID: "Barbell_Squat", Name: "Barbell Squat", Level: "beginner", Category: "strength", Equipment: ptr("barbell"), PrimaryMuscles: ptr("quadriceps")},
+	{ID: "Barbell_Bench_Press", Name: "Barbell Bench Press", Level: "beginner", Category: "strength", Equipment: ptr("barbell"), PrimaryMuscles: ptr("chest")},
+	{ID: "Pullups", Name: "Pullups", Level: "beginner", Category: "strength", Equipment: ptr("body only"), PrimaryMuscles: ptr("lats")},
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+// forEachMigratedDB runs a test against every database of testConfigs, migrated
+// and with the initial data and testExercises
+func forEachMigratedDB(t *testing.T, fn func(t *testing.T, db *Database)) {
+	forEachDriver(t, func(t *testing.T, db *Database) {
+		if err := db.MigrateUp(0); err != nil {
+			t.Fatalf("failed to migrate: %v", err)
+		}
+		if err := db.Create(&testExercises).Error; err != nil {
+			t.Fatalf("failed to add exercises: %v", err)
+		}
+		if err := db.CheckInitialData(); err != nil {
+			t.Fatalf("failed to add initial data: %v", err)
+		}
+		fn(t, db)
+	})
+}
+
+// mustCreate inserts rows, failing the test on errors
+func mustCreate(t *testing.T, tx *gorm.DB, value any) {
+	t.Helper()
+	if err := tx.Create(value).Error; err != nil {
+		t.Fatalf("failed to create %T: %v", value, err)
+	}
+}
+
+// addWorkout adds a routine and a finished workout recorded from it
+func addWorkout(t *testing.T, db *Database, name string) *RecordRoutine {
+	t.Helper()
+
+	routine := &Routine{Name: name, RoutineItems: []RoutineItem{{
+		ExerciseItems: []ExerciseItem{{ExerciseID: "Barbell_Squat", Sets: []Set{
+			{Reps: ptr[uint](5), Weight: ptr(100.0)},
+			{Reps: ptr[uint](5), Weight: ptr(100.0)},
+		}}},
+	}}}
+	mustCreate(t, db.DB, routine)
+
+	completed := time.Date(2024, 3, 4, 18, 30, 0, 0, time.UTC)
+	record := &RecordRoutine{
+		RoutineID:   &routine.ID,
+		RoutineName: name,
+		Duration:    ptr[uint](3600),
+		RecordRoutineItems: []RecordRoutineItem{{
+			RoutineItemID: &routine.RoutineItems[0].ID,
+			RecordExerciseItems: []RecordExerciseItem{{
+				ExerciseItemID: &routine.RoutineItems[0].ExerciseItems[0].ID,
+				ExerciseID:     "Barbell_Squat",
+				ExerciseName:   "Barbell Squat",
+				RecordSets: []RecordSet{
+					{SetID: &routine.RoutineItems[0].ExerciseItems[0].Sets[0].ID, Reps: ptr[uint](5), Weight: ptr(100.0), CompletedAt: &completed},
+					{SetID: &routine.RoutineItems[0].ExerciseItems[0].Sets[1].ID, Reps: ptr[uint](4), Weight: ptr(100.0), CompletedAt: &completed, OrderIndex: 1},
+				},
+			}},
+		}},
+	}
+	mustCreate(t, db.DB, record)
+	return record
+}
@@ -0,0 +1,104 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/birabittoh/go-lift/src/config"
+	"github.com/birabittoh/go-lift/src/logging"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+const slowQueryThreshold = time.Second
+
+// gormLogLevels maps the database log level to GORM's. Statements are logged
+// at debug, slow ones at warn and failed ones at error.
+var gormLogLevels = map[string]logger.LogLevel{
+	config.LogDebug:  logger.Info,
+	config.LogInfo:   logger.Warn,
+	config.LogWarn:   logger.Warn,
+	config.LogError:  logger.Error,
+	config.LogSilent: logger.Silent,
+}
+
+// querySource returns the file and line of the app that ran a query, skipping
+// GORM, the database drivers and this logger
+func querySource() string {
+	pcs := make([]uintptr, 64)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(3, pcs)])
+	for {
+		frame, more := frames.Next()
+		if strings.HasPrefix(frame.Function, "github.com/birabittoh/go-lift/") && !strings.Contains(frame.Function, "slogLogger") {
+			return fmt.Sprintf("%s:%d", frame.File, frame.Line)
+		}
+		if !more {
+			return ""
+		}
+	}
+}
+
+// slogLogger writes the GORM logs through slog
+type slogLogger struct {
+	log   *slog.Logger
+	level logger.LogLevel
+}
+
+func newLogger(cfg *config.Config) logger.Interface {
+	return &slogLogger{
+		log:   logging.New(cfg, cfg.DBLogLevel).With("component", "database"),
+		level: gormLogLevels[cfg.DBLogLevel],
+	}
+}
+
+func (l *slogLogger) LogMode(level logger.LogLevel) logger.Interface {
+	copy := *l
+	copy.level = level
+	return &copy
+}
+
+func (l *slogLogger) Info(ctx context.Context, msg string, data ...any) {
+	if l.level >= logger.Info {
+		l.log.InfoContext(ctx, fmt.Sprintf(msg, data...))
+	}
+}
+
+func (l *slogLogger) Warn(ctx context.Context, msg string, data ...any) {
+	if l.level >= logger.Warn {
+		l.log.WarnContext(ctx, fmt.Sprintf(msg, data...))
+	}
+}
+
+func (l *slogLogger) Error(ctx context.Context, msg string, data ...any) {
+	if l.level >= logger.Error {
+		l.log.ErrorContext(ctx, fmt.Sprintf(msg, data...))
+	}
+}
+
+// Trace logs a statement after it ran. Missing records are expected, so they
+// are not logged as errors.
+func (l *slogLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	if l.level <= logger.Silent {
+		return
+	}
+
+	elapsed := time.Since(begin)
+	attrs := func() []any {
+		sql, rows := fc()
+		return []any{"sql", sql, "rows", rows, "duration", elapsed, "source", querySource()}
+	}
+
+	switch {
+	case err != nil && l.level >= logger.Error && !errors.Is(err, gorm.ErrRecordNotFound):
+		l.log.ErrorContext(ctx, "Query failed", append(attrs(), "error", err)...)
+	case elapsed > slowQueryThreshold && l.level >= logger.Warn:
+		l.log.WarnContext(ctx, "Slow query", attrs()...)
+	case l.level >= logger.Info:
+		l.log.DebugContext(ctx, "Query", attrs()...)
+	}
+}
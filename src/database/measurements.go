@@ -0,0 +1,304 @@
+package database
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// MeasurementImportResult summarizes a CSV import of body measurements
+type MeasurementImportResult struct {
+	Imported       int      `json:"imported"`
+	CreatedMetrics []string `json:"createdMetrics"`
+	Errors         []string `json:"errors"`
+}
+
+// measurementDateFormats are the unambiguous date layouts accepted by imports.
+// Day/month and month/day orders can't be told apart, so neither is accepted.
+var measurementDateFormats = []string{"2006-01-02", "2006-01-02 15:04:05", time.RFC3339}
+
+func (db *Database) GetBodyMetrics() ([]BodyMetric, error) {
+	var metrics []BodyMetric
+	err := db.Order("order_index, id").Find(&metrics).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range metrics {
+		var latest BodyMeasurement
+		err := db.Where("body_metric_id = ?", metrics[i].ID).Order("date DESC").First(&latest).Error
+		if err == nil {
+			metrics[i].Latest = &latest
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+	}
+
+	return metrics, nil
+}
+
+func (db *Database) GetBodyMetricByID(id uint) (*BodyMetric, error) {
+	var metric BodyMetric
+	err := db.
+		Preload("BodyMeasurements", func(tx *gorm.DB) *gorm.DB {
+			return tx.Order("date")
+		}).
+		First(&metric, id).Error
+	if err != nil {
+		return nil, err
+	}
+
+	if l := len(metric.BodyMeasurements); l > 0 {
+		latest := metric.BodyMeasurements[l-1]
+		metric.Latest = &latest
+	}
+
+	return &metric, nil
+}
+
+func (db *Database) getBodyMetricByName(name string) (*BodyMetric, error) {
+	var metric BodyMetric
+	err := db.Where("LOWER(name) = ?", strings.ToLower(strings.TrimSpace(name))).First(&metric).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &metric, nil
+}
+
+func (db *Database) NewBodyMetric(metric *BodyMetric) error {
+	metric.Name = strings.TrimSpace(metric.Name)
+	if metric.Name == "" || len(metric.Name) > 50 {
+		return fmt.Errorf("invalid metric name")
+	}
+
+	if !isValidUnit(metric.Unit) {
+		return fmt.Errorf("invalid unit: %s", metric.Unit)
+	}
+
+	var count int64
+	if err := db.Model(&BodyMetric{}).Count(&count).Error; err != nil {
+		return err
+	}
+	metric.OrderIndex = int(count)
+
+	if err := db.Create(metric).Error; err != nil {
+		return fmt.Errorf("failed to create metric: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateBodyMetric saves a metric; the unit can only change within the same dimension
+// because stored values are always kept in the canonical unit.
+func (db *Database) UpdateBodyMetric(metric *BodyMetric) error {
+	if metric.ID == 0 {
+		return fmt.Errorf("metric ID is required for update")
+	}
+
+	metric.Name = strings.TrimSpace(metric.Name)
+	if metric.Name == "" || len(metric.Name) > 50 {
+		return fmt.Errorf("invalid metric name")
+	}
+
+	if !isValidUnit(metric.Unit) {
+		return fmt.Errorf("invalid unit: %s", metric.Unit)
+	}
+
+	var existing BodyMetric
+	if err := db.First(&existing, metric.ID).Error; err != nil {
+		return err
+	}
+
+	if !sameDimension(existing.Unit, metric.Unit) {
+		return fmt.Errorf("cannot change unit from %s to %s", existing.Unit, metric.Unit)
+	}
+
+	if err := db.Omit("BodyMeasurements").Save(metric).Error; err != nil {
+		return fmt.Errorf("failed to update metric: %w", err)
+	}
+
+	return nil
+}
+
+func (db *Database) DeleteBodyMetric(id uint) error {
+	if id == 0 {
+		return fmt.Errorf("metric ID is required for deletion")
+	}
+
+	if err := db.Delete(&BodyMetric{}, id).Error; err != nil {
+		return fmt.Errorf("failed to delete metric: %w", err)
+	}
+
+	return nil
+}
+
+// NewBodyMeasurement stores a value expressed in the metric's display unit
+func (db *Database) NewBodyMeasurement(metric *BodyMetric, value float64, date time.Time, notes string) (*BodyMeasurement, error) {
+	if metric.ID == 0 {
+		return nil, fmt.Errorf("metric ID is required for new measurement")
+	}
+
+	canonical := ToCanonical(value, metric.Unit)
+	if canonical < 0 || (units[metric.Unit].Dimension == dimensionPercent && canonical > 100) {
+		return nil, fmt.Errorf("invalid value: %v", value)
+	}
+
+	if date.IsZero() {
+		date = time.Now()
+	}
+
+	if date.After(time.Now().Add(24 * time.Hour)) {
+		return nil, fmt.Errorf("invalid date: %v", date)
+	}
+
+	if len(notes) > 200 {
+		return nil, fmt.Errorf("notes are too long")
+	}
+
+	measurement := &BodyMeasurement{
+		BodyMetricID: metric.ID,
+		Value:        canonical,
+		Date:         date,
+		Notes:        notes,
+	}
+
+	if err := db.Create(measurement).Error; err != nil {
+		return nil, fmt.Errorf("failed to save measurement: %w", err)
+	}
+
+	return measurement, nil
+}
+
+func (db *Database) GetBodyMeasurementByID(id uint) (*BodyMeasurement, error) {
+	var measurement BodyMeasurement
+	err := db.First(&measurement, id).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &measurement, nil
+}
+
+func (db *Database) DeleteBodyMeasurement(measurement *BodyMeasurement) (uint, error) {
+	if measurement.ID == 0 {
+		return 0, fmt.Errorf("measurement ID is required for deletion")
+	}
+
+	if err := db.Delete(measurement).Error; err != nil {
+		return 0, fmt.Errorf("failed to delete measurement: %w", err)
+	}
+
+	return measurement.BodyMetricID, nil
+}
+
+func parseMeasurementDate(s string) (time.Time, error) {
+	for _, format := range measurementDateFormats {
+		if t, err := time.ParseInLocation(format, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognized date: %s", s)
+}
+
+// ImportBodyMeasurementsCSV reads rows of "date,metric,value[,unit[,notes]]".
+// Unknown metrics are created using the row unit (cm if missing); rows with
+// a unit different from the metric's are converted.
+func (db *Database) ImportBodyMeasurementsCSV(r io.Reader) (*MeasurementImportResult, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, required := range []string{"date", "metric", "value"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("missing required column: %s", required)
+		}
+	}
+
+	field := func(row []string, name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(row) {
+			return ""
+		}
+		return strings.TrimSpace(row[i])
+	}
+
+	result := &MeasurementImportResult{}
+	line := 1
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		line++
+		if err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("line %d: %v", line, err))
+			continue
+		}
+
+		date, err := parseMeasurementDate(field(row, "date"))
+		if err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("line %d: %v", line, err))
+			continue
+		}
+
+		value, err := strconv.ParseFloat(field(row, "value"), 64)
+		if err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("line %d: invalid value", line))
+			continue
+		}
+
+		rowUnit := field(row, "unit")
+		if rowUnit != "" && !isValidUnit(rowUnit) {
+			result.Errors = append(result.Errors, fmt.Sprintf("line %d: invalid unit %s", line, rowUnit))
+			continue
+		}
+
+		name := field(row, "metric")
+		metric, err := db.getBodyMetricByName(name)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			metric = &BodyMetric{Name: name, Unit: rowUnit}
+			if metric.Unit == "" {
+				metric.Unit = "cm"
+			}
+			if err := db.NewBodyMetric(metric); err != nil {
+				result.Errors = append(result.Errors, fmt.Sprintf("line %d: %v", line, err))
+				continue
+			}
+			result.CreatedMetrics = append(result.CreatedMetrics, metric.Name)
+		} else if err != nil {
+			return result, err
+		}
+
+		if rowUnit != "" && rowUnit != metric.Unit {
+			if !sameDimension(rowUnit, metric.Unit) {
+				result.Errors = append(result.Errors, fmt.Sprintf("line %d: unit %s is not compatible with %s", line, rowUnit, metric.Unit))
+				continue
+			}
+			value = FromCanonical(ToCanonical(value, rowUnit), metric.Unit)
+		}
+
+		if _, err := db.NewBodyMeasurement(metric, value, date, field(row, "notes")); err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("line %d: %v", line, err))
+			continue
+		}
+		result.Imported++
+	}
+
+	return result, nil
+}
@@ -0,0 +1,57 @@
+package database
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestBodyMetricFromCanonical(t *testing.T) {
+	forEachMigratedDB(t, func(t *testing.T, db *Database) {
+		metric := &BodyMetric{Name: "Wrist", Unit: "in"}
+		if err := db.NewBodyMetric(metric); err != nil {
+			t.Fatal(err)
+		}
+		for i, value := range []float64{14, 14.5} {
+			if _, err := db.NewBodyMeasurement(metric, value, time.Now().AddDate(0, 0, i-2), ""); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		got, err := db.GetBodyMetricByID(metric.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v := got.BodyMeasurements[0].Value; math.Abs(v-35.56) > 1e-9 {
+			t.Errorf("stored value = %g, want 35.56 cm", v)
+		}
+
+		BodyMetricFromCanonical(got)
+		for i, want := range []float64{14, 14.5} {
+			if v := got.BodyMeasurements[i].Value; math.Abs(v-want) > 1e-9 {
+				t.Errorf("measurement %d = %g in, want %g", i, v, want)
+			}
+		}
+		if v := got.Latest.Value; math.Abs(v-14.5) > 1e-9 {
+			t.Errorf("latest measurement = %g in, want 14.5", v)
+		}
+	})
+}
+
+func TestParseMeasurementDate(t *testing.T) {
+	tests := []struct {
+		value   string
+		wantErr bool
+	}{
+		{"2024-03-04", false},
+		{"2024-03-04 07:30:00", false},
+		{"2024-03-04T07:30:00Z", false},
+		{"04/03/2024", true}, // March 4th or April 3rd
+		{"", true},
+	}
+	for _, tt := range tests {
+		if _, err := parseMeasurementDate(tt.value); (err != nil) != tt.wantErr {
+			t.Errorf("parseMeasurementDate(%q) error = %v, want error %v", tt.value, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package database
+
+import (
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/birabittoh/go-lift/src/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"gorm.io/gorm"
+)
+
+const queryStartKey = "metrics:start"
+
+// metricsPlugin times every statement run through GORM
+type metricsPlugin struct{}
+
+func (metricsPlugin) Name() string {
+	return "metrics"
+}
+
+func startQuery(tx *gorm.DB) {
+	tx.InstanceSet(queryStartKey, time.Now())
+}
+
+func observeQuery(operation string) func(tx *gorm.DB) {
+	return func(tx *gorm.DB) {
+		start, ok := tx.InstanceGet(queryStartKey)
+		if !ok {
+			return
+		}
+		metrics.DBQueryDuration.
+			WithLabelValues(operation, tx.Statement.Table).
+			Observe(time.Since(start.(time.Time)).Seconds())
+	}
+}
+
+func (metricsPlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	return errors.Join(
+		cb.Create().Before("*").Register("metrics:start_create", startQuery),
+		cb.Create().After("*").Register("metrics:observe_create", observeQuery("create")),
+		cb.Query().Before("*").Register("metrics:start_query", startQuery),
+		cb.Query().After("*").Register("metrics:observe_query", observeQuery("query")),
+		cb.Update().Before("*").Register("metrics:start_update", startQuery),
+		cb.Update().After("*").Register("metrics:observe_update", observeQuery("update")),
+		cb.Delete().Before("*").Register("metrics:start_delete", startQuery),
+		cb.Delete().After("*").Register("metrics:observe_delete", observeQuery("delete")),
+		cb.Row().Before("*").Register("metrics:start_row", startQuery),
+		cb.Row().After("*").Register("metrics:observe_row", observeQuery("row")),
+		cb.Raw().Before("*").Register("metrics:start_raw", startQuery),
+		cb.Raw().After("*").Register("metrics:observe_raw", observeQuery("raw")),
+	)
+}
+
+var (
+	activeWorkoutsDesc = prometheus.NewDesc(prometheus.BuildFQName(metrics.Namespace, "", "active_workouts"), "Workouts started and not finished yet.", nil, nil)
+	workoutRecordsDesc = prometheus.NewDesc(prometheus.BuildFQName(metrics.Namespace, "", "workout_records"), "Workout records, finished or not.", nil, nil)
+)
+
+// statsCollector counts the workouts when metrics are scraped
+type statsCollector struct {
+	db *Database
+}
+
+// MetricsCollector returns a collector of the domain gauges
+func (db *Database) MetricsCollector() prometheus.Collector {
+	return statsCollector{db}
+}
+
+func (c statsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- activeWorkoutsDesc
+	ch <- workoutRecordsDesc
+}
+
+func (c statsCollector) Collect(ch chan<- prometheus.Metric) {
+	var active, records int64
+	if err := c.db.Model(&RecordRoutine{}).Where("duration IS NULL").Count(&active).Error; err != nil {
+		slog.Warn("Failed to count active workouts", "error", err)
+		return
+	}
+	if err := c.db.Model(&RecordRoutine{}).Count(&records).Error; err != nil {
+		slog.Warn("Failed to count workout records", "error", err)
+		return
+	}
+
+	ch <- prometheus.MustNewConstMetric(activeWorkoutsDesc, prometheus.GaugeValue, float64(active))
+	ch <- prometheus.MustNewConstMetric(workoutRecordsDesc, prometheus.GaugeValue, float64(records))
+}
@@ -0,0 +1,47 @@
+package database
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/birabittoh/go-lift/src/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func TestMetricsCollector(t *testing.T) {
+	forEachMigratedDB(t, func(t *testing.T, db *Database) {
+		addWorkout(t, db, "Legs")
+		mustCreate(t, db.DB, &RecordRoutine{RoutineName: "Freestyle workout"})
+
+		want := `
+# HELP golift_active_workouts Workouts started and not finished yet.
+# TYPE golift_active_workouts gauge
+golift_active_workouts 1
+# HELP golift_workout_records Workout records, finished or not.
+# TYPE golift_workout_records gauge
+golift_workout_records 2
+`
+		if err := testutil.CollectAndCompare(db.MetricsCollector(), strings.NewReader(want)); err != nil {
+			t.Error(err)
+		}
+
+		// Statements are timed by operation and table
+		queries := func() uint64 {
+			t.Helper()
+			var m dto.Metric
+			if err := metrics.DBQueryDuration.WithLabelValues("query", "body_metrics").(prometheus.Histogram).Write(&m); err != nil {
+				t.Fatal(err)
+			}
+			return m.GetHistogram().GetSampleCount()
+		}
+		before := queries()
+		if _, err := db.GetBodyMetrics(); err != nil {
+			t.Fatal(err)
+		}
+		if got := queries() - before; got != 1 {
+			t.Errorf("timed body metric queries = %d, want 1", got)
+		}
+	})
+}
@@ -0,0 +1,356 @@
+package database
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/birabittoh/go-lift/src/database/schemav1"
+	"gorm.io/gorm"
+)
+
+// models lists every model in the order they must be migrated
+var models = []any{
+	&Day{},
+	&User{},
+	&HeightMeasurement{},
+	&WeightMeasurement{},
+	&BodyMetric{},
+	&BodyMeasurement{},
+	&EquipmentInventory{},
+	&Exercise{},
+	&Routine{},
+	&RoutineVersion{},
+	&RoutineItem{},
+	&ExerciseItem{},
+	&Set{},
+	&Program{},
+	&ProgramWeek{},
+	&ProgramRoutine{},
+	&RecordRoutine{},
+	&RecordRoutineItem{},
+	&RecordExerciseItem{},
+	&RecordSet{},
+}
+
+// recordConstraints are the references from workout records to the routine
+// they were started from; they used to cascade deletions.
+var recordConstraints = []struct {
+	model any
+	name  string
+}{
+	{&schemav1.RecordRoutine{}, "Routine"},
+	{&schemav1.RecordRoutineItem{}, "RoutineItem"},
+	{&schemav1.RecordExerciseItem{}, "ExerciseItem"},
+	{&schemav1.RecordSet{}, "Set"},
+}
+
+// migration is a versioned change to the schema or the data. Migrations are
+// applied in order and never changed once released, a change goes in a new
+// one. They must not use the current models, which keep changing: tables and
+// columns are described by structs of their own, frozen with the migration.
+type migration struct {
+	Version int
+	Name    string
+	Up      func(tx *gorm.DB) error
+	Down    func(tx *gorm.DB) error // Nil if the migration can't be reverted
+}
+
+var migrations = []migration{
+	{Version: 1, Name: "initial schema", Up: migrateInitialSchema, Down: dropSchema},
+	{Version: 2, Name: "user credentials", Up: addUserCredentials, Down: dropUserCredentials},
+}
+
+// SchemaVersion identifies the layout of the data, it is the version of the
+// latest migration.
+var SchemaVersion = migrations[len(migrations)-1].Version
+
+// schemaMigration records an applied migration
+type schemaMigration struct {
+	Version   int       `gorm:"primaryKey;autoIncrement:false"`
+	Name      string    `gorm:"size:100;not null"`
+	AppliedAt time.Time `gorm:"not null"`
+}
+
+func (schemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// MigrationStatus tells whether a migration was applied to the database
+type MigrationStatus struct {
+	Version    int        `json:"version"`
+	Name       string     `json:"name"`
+	AppliedAt  *time.Time `json:"appliedAt"`
+	Reversible bool       `json:"reversible"`
+}
+
+// migrateInitialSchema creates the schema of schemav1. Databases created
+// before versioned migrations are upgraded to it, copying the planned data
+// into workout records if they still reference their routine.
+func migrateInitialSchema(tx *gorm.DB) error {
+	m := tx.Migrator()
+	snapshotRecords := m.HasTable(&schemav1.RecordSet{}) && !m.HasColumn(&schemav1.RecordSet{}, "PlannedReps")
+
+	if err := tx.AutoMigrate(schemav1.Models...); err != nil {
+		return err
+	}
+
+	if snapshotRecords {
+		if err := snapshotRecordData(tx); err != nil {
+			return fmt.Errorf("failed to migrate workout records: %w", err)
+		}
+
+		// SQLite rebuilds the tables to change their constraints, which drops
+		// their indexes
+		if err := tx.AutoMigrate(schemav1.Models...); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// dropSchema deletes every table of schemav1 along with its data
+func dropSchema(tx *gorm.DB) error {
+	tables := []any{"routine_days"}
+	for i := len(schemav1.Models) - 1; i >= 0; i-- {
+		tables = append(tables, schemav1.Models[i])
+	}
+	return tx.Migrator().DropTable(tables...)
+}
+
+// userCredentials are the columns added to users by migration 2
+type userCredentials struct {
+	Username     *string `gorm:"size:50;uniqueIndex"`
+	PasswordHash string  `gorm:"size:200;not null;default:''"`
+}
+
+func (userCredentials) TableName() string {
+	return "users"
+}
+
+func addUserCredentials(tx *gorm.DB) error {
+	m := tx.Migrator()
+	for _, field := range []string{"Username", "PasswordHash"} {
+		if err := m.AddColumn(&userCredentials{}, field); err != nil {
+			return err
+		}
+	}
+	return m.CreateIndex(&userCredentials{}, "Username")
+}
+
+func dropUserCredentials(tx *gorm.DB) error {
+	m := tx.Migrator()
+	if err := m.DropIndex(&userCredentials{}, "Username"); err != nil {
+		return err
+	}
+	for _, field := range []string{"Username", "PasswordHash"} {
+		if err := m.DropColumn(&userCredentials{}, field); err != nil {
+			return err
+		}
+	}
+
+	// SQLite rebuilds the table to drop a column, which drops its indexes
+	if !m.HasIndex(&schemav1.User{}, "FeedToken") {
+		return m.CreateIndex(&schemav1.User{}, "FeedToken")
+	}
+	return nil
+}
+
+// withoutForeignKeys runs migrations on a single connection with foreign keys
+// disabled. SQLite rebuilds a table to change its columns or constraints, and
+// the rebuild would otherwise cascade to the rows that reference it.
+func (db *Database) withoutForeignKeys(fn func(conn *Database) error) error {
+	return db.Connection(func(tx *gorm.DB) error {
+		if db.IsSQLite() {
+			if err := tx.Exec("PRAGMA foreign_keys = OFF").Error; err != nil {
+				return fmt.Errorf("failed to disable foreign keys: %w", err)
+			}
+			defer tx.Exec("PRAGMA foreign_keys = ON")
+		}
+
+		// A new session keeps the statements from sharing their table
+		return fn(&Database{DB: tx.Session(&gorm.Session{}), Config: db.Config})
+	})
+}
+
+// appliedMigrations returns the applied migrations by version
+func (db *Database) appliedMigrations() (map[int]schemaMigration, error) {
+	if err := db.AutoMigrate(&schemaMigration{}); err != nil {
+		return nil, fmt.Errorf("failed to create migrations table: %w", err)
+	}
+
+	var rows []schemaMigration
+	if err := db.Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to retrieve applied migrations: %w", err)
+	}
+
+	applied := make(map[int]schemaMigration, len(rows))
+	for _, row := range rows {
+		applied[row.Version] = row
+	}
+	return applied, nil
+}
+
+// currentVersion is the highest applied migration, 0 if none
+func currentVersion(applied map[int]schemaMigration) (version int) {
+	for v := range applied {
+		version = max(version, v)
+	}
+	return
+}
+
+// backupBeforeMigration saves the data in the data directory before it is
+// changed
+func (db *Database) backupBeforeMigration(version int) error {
+	return db.backupToDataDir(fmt.Sprintf("-v%d", version))
+}
+
+// GetMigrationStatus lists every known migration and when it was applied
+func (db *Database) GetMigrationStatus() ([]MigrationStatus, error) {
+	applied, err := db.appliedMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	status := make([]MigrationStatus, len(migrations))
+	for i, m := range migrations {
+		status[i] = MigrationStatus{Version: m.Version, Name: m.Name, Reversible: m.Down != nil}
+		if row, ok := applied[m.Version]; ok {
+			status[i].AppliedAt = &row.AppliedAt
+		}
+	}
+	return status, nil
+}
+
+// MigrateUp applies the pending migrations up to a version, 0 for the latest.
+// The database is backed up first.
+func (db *Database) MigrateUp(target int) error {
+	if target == 0 {
+		target = SchemaVersion
+	}
+	if target < 0 || target > SchemaVersion {
+		return fmt.Errorf("unknown schema version: %d", target)
+	}
+
+	return db.withoutForeignKeys(func(conn *Database) error {
+		applied, err := conn.appliedMigrations()
+		if err != nil {
+			return err
+		}
+
+		var pending []migration
+		for _, m := range migrations {
+			if _, ok := applied[m.Version]; !ok && m.Version <= target {
+				pending = append(pending, m)
+			}
+		}
+		if len(pending) == 0 {
+			return nil
+		}
+
+		if err := conn.backupBeforeMigration(currentVersion(applied)); err != nil {
+			return err
+		}
+
+		for _, m := range pending {
+			err := conn.Transaction(func(tx *gorm.DB) error {
+				if err := m.Up(tx); err != nil {
+					return err
+				}
+				return tx.Create(&schemaMigration{Version: m.Version, Name: m.Name, AppliedAt: time.Now()}).Error
+			})
+			if err != nil {
+				return fmt.Errorf("failed to apply migration %d (%s): %w", m.Version, m.Name, err)
+			}
+			slog.Info("Applied migration", "version", m.Version, "name", m.Name)
+		}
+
+		return nil
+	})
+}
+
+// MigrateDown reverts the applied migrations above a version, latest first.
+// The database is backed up first.
+func (db *Database) MigrateDown(target int) error {
+	if target < 0 || target > SchemaVersion {
+		return fmt.Errorf("unknown schema version: %d", target)
+	}
+
+	return db.withoutForeignKeys(func(conn *Database) error {
+		applied, err := conn.appliedMigrations()
+		if err != nil {
+			return err
+		}
+
+		var revert []migration
+		for i := len(migrations) - 1; i >= 0; i-- {
+			m := migrations[i]
+			if _, ok := applied[m.Version]; !ok || m.Version <= target {
+				continue
+			}
+			if m.Down == nil {
+				return fmt.Errorf("migration %d (%s) can't be reverted", m.Version, m.Name)
+			}
+			revert = append(revert, m)
+		}
+		if len(revert) == 0 {
+			return nil
+		}
+
+		if err := conn.backupBeforeMigration(currentVersion(applied)); err != nil {
+			return err
+		}
+
+		for _, m := range revert {
+			err := conn.Transaction(func(tx *gorm.DB) error {
+				if err := m.Down(tx); err != nil {
+					return err
+				}
+				return tx.Delete(&schemaMigration{Version: m.Version}).Error
+			})
+			if err != nil {
+				return fmt.Errorf("failed to revert migration %d (%s): %w", m.Version, m.Name, err)
+			}
+			slog.Info("Reverted migration", "version", m.Version, "name", m.Name)
+		}
+
+		return nil
+	})
+}
+
+// snapshotRecordData stops workout records from being deleted along with
+// their routine and copies the planned data they used to reference.
+func snapshotRecordData(tx *gorm.DB) error {
+	m := tx.Migrator()
+	for _, c := range recordConstraints {
+		if err := m.DropConstraint(c.model, c.name); err != nil {
+			return err
+		}
+		if err := m.CreateConstraint(c.model, c.name); err != nil {
+			return err
+		}
+	}
+
+	statements := []string{
+		`UPDATE record_routines SET routine_name = COALESCE(
+			(SELECT name FROM routines WHERE routines.id = record_routines.routine_id), '')`,
+		`UPDATE record_exercise_items SET
+			exercise_id = COALESCE((SELECT exercise_id FROM exercise_items
+				WHERE exercise_items.id = record_exercise_items.exercise_item_id), ''),
+			exercise_name = COALESCE((SELECT exercises.name FROM exercise_items
+				JOIN exercises ON exercises.id = exercise_items.exercise_id
+				WHERE exercise_items.id = record_exercise_items.exercise_item_id), '')`,
+		`UPDATE record_sets SET
+			planned_reps = (SELECT reps FROM sets WHERE sets.id = record_sets.set_id),
+			planned_weight = (SELECT weight FROM sets WHERE sets.id = record_sets.set_id),
+			planned_duration = (SELECT duration FROM sets WHERE sets.id = record_sets.set_id)`,
+	}
+	for _, statement := range statements {
+		if err := tx.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
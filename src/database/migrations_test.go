@@ -0,0 +1,140 @@
+package database
+
+import (
+	"os"
+	"slices"
+	"testing"
+
+	"github.com/birabittoh/go-lift/src/config"
+)
+
+// indexes lists the indexes of the tables, by name
+func indexes(t *testing.T, db *Database) []string {
+	t.Helper()
+
+	tables, err := db.Migrator().GetTables()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for _, table := range tables {
+		list, err := db.Migrator().GetIndexes(table)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, index := range list {
+			if primary, _ := index.PrimaryKey(); !primary {
+				names = append(names, table+"."+index.Name())
+			}
+		}
+	}
+
+	slices.Sort(names)
+	return names
+}
+
+func TestMigrateUpAndDown(t *testing.T) {
+	forEachDriver(t, func(t *testing.T, db *Database) {
+		if err := db.MigrateUp(0); err != nil {
+			t.Fatal(err)
+		}
+		want := indexes(t, db)
+
+		status, err := db.GetMigrationStatus()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, s := range status {
+			if s.AppliedAt == nil {
+				t.Errorf("migration %d (%s) is not applied", s.Version, s.Name)
+			}
+		}
+
+		// Revert one migration at a time, then apply them again
+		for version := SchemaVersion - 1; version >= 0; version-- {
+			if err := db.MigrateDown(version); err != nil {
+				t.Fatalf("failed to migrate down to %d: %v", version, err)
+			}
+		}
+		for _, model := range append([]any{"routine_days"}, models...) {
+			if db.Migrator().HasTable(model) {
+				t.Errorf("table of %T still exists after reverting every migration", model)
+			}
+		}
+
+		for version := 1; version <= SchemaVersion; version++ {
+			if err := db.MigrateUp(version); err != nil {
+				t.Fatalf("failed to migrate up to %d: %v", version, err)
+			}
+		}
+		if got := indexes(t, db); !slices.Equal(got, want) {
+			t.Errorf("indexes after migrating again = %v, want %v", got, want)
+		}
+	})
+}
+
+func TestMigrateBaselineSchema(t *testing.T) {
+	fresh, err := OpenDB(testConfigs(t)[config.DriverSQLite])
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fresh.Close()
+	if err := fresh.MigrateUp(0); err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := OpenDB(testConfigs(t)[config.DriverSQLite])
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	baseline, err := os.ReadFile("testdata/baseline_sqlite.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Exec(string(baseline)).Error; err != nil {
+		t.Fatalf("failed to create the baseline schema: %v", err)
+	}
+
+	if err := db.MigrateUp(0); err != nil {
+		t.Fatal(err)
+	}
+
+	// The planned data is copied into the record
+	var set RecordSet
+	if err := db.Preload("RecordExerciseItem.RecordRoutineItem.RecordRoutine").First(&set).Error; err != nil {
+		t.Fatal(err)
+	}
+	if set.PlannedReps == nil || *set.PlannedReps != 5 || set.PlannedWeight == nil || *set.PlannedWeight != 100 {
+		t.Errorf("planned reps and weight = %v, %v, want 5, 100", set.PlannedReps, set.PlannedWeight)
+	}
+	if name := set.RecordExerciseItem.ExerciseName; name != "Barbell Squat" {
+		t.Errorf("exercise name = %q, want %q", name, "Barbell Squat")
+	}
+	if name := set.RecordExerciseItem.RecordRoutineItem.RecordRoutine.RoutineName; name != "Legs" {
+		t.Errorf("routine name = %q, want %q", name, "Legs")
+	}
+
+	// Records outlive their routine
+	if err := db.Delete(&Routine{ID: 1}).Error; err != nil {
+		t.Fatal(err)
+	}
+	var records int64
+	db.Model(&RecordSet{}).Count(&records)
+	if records != 1 {
+		t.Errorf("record sets after deleting the routine = %d, want 1", records)
+	}
+
+	if got, want := indexes(t, db), indexes(t, fresh); !slices.Equal(got, want) {
+		t.Errorf("indexes = %v, want the ones of a new database %v", got, want)
+	}
+
+	if err := db.MigrateDown(0); err != nil {
+		t.Fatal(err)
+	}
+	if db.Migrator().HasTable(&RecordSet{}) {
+		t.Error("record_sets still exists after reverting every migration")
+	}
+}
@@ -1,18 +1,15 @@
 package database
 
 import (
-	"log"
-	"os"
-	"path/filepath"
 	"time"
 
-	"github.com/glebarez/sqlite"
+	"github.com/birabittoh/go-lift/src/config"
 	"gorm.io/gorm"
-	"gorm.io/gorm/logger"
 )
 
 type Database struct {
 	*gorm.DB
+	Config *config.Config
 }
 
 // Day model represents a week day
@@ -23,12 +20,21 @@ type Day struct {
 
 // User model - kept as is since it's not directly related to routines
 type User struct {
-	ID        uint           `gorm:"primaryKey" json:"id"`
-	Name      string         `gorm:"size:50" json:"name"`
-	IsFemale  bool           `json:"isFemale"`
-	Height    *float64       `json:"height"` // In cm
-	Weight    *float64       `json:"weight"` // In kg
-	BirthDate *time.Time     `json:"birthDate"`
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	Name      string     `gorm:"size:50" json:"name"`
+	IsFemale  bool       `json:"isFemale"`
+	Height    *float64   `json:"height"` // In cm
+	Weight    *float64   `json:"weight"` // In kg
+	BirthDate *time.Time `json:"birthDate"`
+
+	WeightUnit string `gorm:"size:2;not null;default:kg" json:"weightUnit"` // kg or lb
+	LengthUnit string `gorm:"size:2;not null;default:cm" json:"lengthUnit"` // cm or in
+
+	FeedToken *string `gorm:"size:64;uniqueIndex" json:"-"` // Secret of the calendar feed URL
+
+	Username     *string `gorm:"size:50;uniqueIndex" json:"username"` // Sign-in name, see SetUserCredentials
+	PasswordHash string  `gorm:"size:200;not null;default:''" json:"-"`
+
 	CreatedAt time.Time      `json:"createdAt"`
 	UpdatedAt time.Time      `json:"updatedAt"`
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"deletedAt"`
@@ -47,6 +53,51 @@ type WeightMeasurement struct {
 	CreatedAt time.Time `json:"createdAt"`
 }
 
+// BodyMetric represents a kind of body measurement (body fat, waist, chest...)
+type BodyMetric struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	Name       string    `gorm:"size:50;not null;uniqueIndex" json:"name"`
+	Unit       string    `gorm:"size:10;not null" json:"unit"` // Display unit, values are stored in the canonical unit of its dimension
+	OrderIndex int       `gorm:"not null;default:0" json:"orderIndex"`
+	CreatedAt  time.Time `json:"createdAt"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+
+	BodyMeasurements []BodyMeasurement `gorm:"foreignKey:BodyMetricID;constraint:OnDelete:CASCADE" json:"bodyMeasurements,omitempty"`
+
+	// Non-persisted fields
+	Latest *BodyMeasurement `gorm:"-" json:"latest,omitempty"`
+}
+
+// BodyMeasurement records a dated value for a body metric
+type BodyMeasurement struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	BodyMetricID uint      `gorm:"not null;index" json:"bodyMetricId"`
+	Value        float64   `gorm:"not null" json:"value"` // In the canonical unit (%, cm, kg)
+	Date         time.Time `gorm:"not null;index" json:"date"`
+	Notes        string    `gorm:"size:200" json:"notes"`
+	CreatedAt    time.Time `json:"createdAt"`
+	UpdatedAt    time.Time `json:"updatedAt"`
+
+	BodyMetric BodyMetric `gorm:"constraint:OnDelete:CASCADE" json:"-"`
+}
+
+// EquipmentInventory describes the equipment available to a user; all weights are in kg
+type EquipmentInventory struct {
+	ID                uint      `gorm:"primaryKey" json:"id"`
+	UserID            uint      `gorm:"not null;uniqueIndex" json:"userId"`
+	BarWeights        string    `gorm:"size:200" json:"barWeights"` // Comma-separated list
+	Plates            string    `gorm:"size:500" json:"plates"`     // Comma-separated list of "weight" or "weightxcount"
+	DumbbellIncrement float64   `json:"dumbbellIncrement"`
+	DumbbellMax       float64   `json:"dumbbellMax"`
+	MachineIncrement  float64   `json:"machineIncrement"` // Weight stack increment
+	MachineMax        float64   `json:"machineMax"`
+	Available         string    `gorm:"size:500" json:"available"` // Comma-separated equipment types, empty if not restricted
+	CreatedAt         time.Time `json:"createdAt"`
+	UpdatedAt         time.Time `json:"updatedAt"`
+
+	User User `gorm:"constraint:OnDelete:CASCADE" json:"-"`
+}
+
 // Exercise model
 type Exercise struct {
 	ID                 string  `gorm:"primaryKey" json:"id"`
@@ -74,11 +125,22 @@ type Routine struct {
 	ID          uint      `gorm:"primaryKey" json:"id"`
 	Name        string    `gorm:"size:100;not null" json:"name"`
 	Description string    `gorm:"size:500" json:"description"`
+	IsTemplate  bool      `gorm:"not null;default:false" json:"isTemplate"` // Templates are cloned rather than performed
 	CreatedAt   time.Time `json:"createdAt"`
 	UpdatedAt   time.Time `json:"updatedAt"`
 
-	RoutineItems []RoutineItem `gorm:"foreignKey:RoutineID;constraint:OnDelete:CASCADE" json:"routineItems"`
-	Days         []Day         `gorm:"many2many:routine_days;constraint:OnDelete:CASCADE;" json:"days"`
+	RoutineItems []RoutineItem    `gorm:"foreignKey:RoutineID;constraint:OnDelete:CASCADE" json:"routineItems"`
+	Days         []Day            `gorm:"many2many:routine_days;constraint:OnDelete:CASCADE;" json:"days"`
+	Versions     []RoutineVersion `gorm:"foreignKey:RoutineID;constraint:OnDelete:SET NULL" json:"-"`
+}
+
+// RoutineVersion is an immutable snapshot of a routine, taken when a workout starts
+type RoutineVersion struct {
+	ID        uint            `gorm:"primaryKey" json:"id"`
+	RoutineID *uint           `gorm:"constraint:OnDelete:SET NULL;uniqueIndex:idx_routine_version" json:"routineId"` // Null once the routine is deleted
+	Version   int             `gorm:"not null;uniqueIndex:idx_routine_version" json:"version"`
+	Snapshot  RoutineSnapshot `gorm:"serializer:json;not null" json:"snapshot"`
+	CreatedAt time.Time       `json:"createdAt"`
 }
 
 // RoutineItem represents a group of exercises (can be a single exercise or superset)
@@ -122,17 +184,65 @@ type Set struct {
 	ExerciseItem ExerciseItem `gorm:"constraint:OnDelete:CASCADE" json:"-"`
 }
 
+// Program sequences routines across weeks, e.g. a 12-week training block
+type Program struct {
+	ID           uint       `gorm:"primaryKey" json:"id"`
+	Name         string     `gorm:"size:100;not null" json:"name"`
+	Description  string     `gorm:"size:500" json:"description"`
+	Active       bool       `gorm:"not null;default:false" json:"active"`
+	CurrentWeek  int        `gorm:"not null;default:1" json:"currentWeek"`  // 1-based week number
+	CurrentIndex int        `gorm:"not null;default:0" json:"currentIndex"` // Index of the next routine within the week
+	StartedAt    *time.Time `json:"startedAt"`
+	CompletedAt  *time.Time `json:"completedAt"`
+	CreatedAt    time.Time  `json:"createdAt"`
+	UpdatedAt    time.Time  `json:"updatedAt"`
+
+	Weeks    []ProgramWeek    `gorm:"foreignKey:ProgramID;constraint:OnDelete:CASCADE" json:"weeks"`
+	Routines []ProgramRoutine `gorm:"foreignKey:ProgramID;constraint:OnDelete:CASCADE" json:"routines"`
+}
+
+// ProgramWeek holds the modifiers applied to every session of a program week
+type ProgramWeek struct {
+	ID               uint      `gorm:"primaryKey" json:"id"`
+	ProgramID        uint      `gorm:"not null;constraint:OnDelete:CASCADE;uniqueIndex:idx_program_week" json:"programId"`
+	WeekNumber       int       `gorm:"not null;uniqueIndex:idx_program_week" json:"weekNumber"`
+	Intensity        float64   `gorm:"not null;default:100" json:"intensity"`      // Percentage of the planned weight
+	VolumeMultiplier float64   `gorm:"not null;default:1" json:"volumeMultiplier"` // Multiplier of the planned number of sets
+	Deload           bool      `gorm:"not null;default:false" json:"deload"`
+	CreatedAt        time.Time `json:"createdAt"`
+	UpdatedAt        time.Time `json:"updatedAt"`
+}
+
+// ProgramRoutine is a routine performed every week of a program, in order
+type ProgramRoutine struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	ProgramID  uint      `gorm:"not null;constraint:OnDelete:CASCADE" json:"programId"`
+	RoutineID  uint      `gorm:"not null;constraint:OnDelete:CASCADE" json:"routineId"`
+	OrderIndex int       `gorm:"not null;default:0" json:"orderIndex"`
+	CreatedAt  time.Time `json:"createdAt"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+
+	Program Program `gorm:"constraint:OnDelete:CASCADE" json:"-"`
+	Routine Routine `gorm:"constraint:OnDelete:CASCADE" json:"routine"`
+}
+
 // ===== RECORD MODELS (for actual workout completion) =====
 
 // RecordRoutine records a completed workout session
 type RecordRoutine struct {
-	ID        uint      `gorm:"primaryKey" json:"id"`
-	RoutineID uint      `gorm:"not null;constraint:OnDelete:CASCADE" json:"routineId"`
-	Duration  *uint     `json:"duration"` // In seconds, total workout time
-	CreatedAt time.Time `json:"createdAt"`
-	UpdatedAt time.Time `json:"updatedAt"`
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	RoutineID   *uint     `gorm:"constraint:OnDelete:SET NULL" json:"routineId"` // Null once the routine is deleted
+	RoutineName string    `gorm:"size:100;not null;default:''" json:"routineName"`
+	ProgramID   *uint     `gorm:"constraint:OnDelete:SET NULL" json:"programId"`
+	ProgramWeek *int      `json:"programWeek"` // Week of the program the workout belongs to
+	VersionID   *uint     `gorm:"constraint:OnDelete:SET NULL" json:"versionId"`
+	Duration    *uint     `json:"duration"` // In seconds, total workout time
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
 
-	Routine            Routine             `gorm:"constraint:OnDelete:CASCADE" json:"routine"`
+	Routine            *Routine            `gorm:"constraint:OnDelete:SET NULL" json:"routine,omitempty"`
+	Program            *Program            `gorm:"constraint:OnDelete:SET NULL" json:"-"`
+	Version            *RoutineVersion     `gorm:"constraint:OnDelete:SET NULL" json:"-"`
 	RecordRoutineItems []RecordRoutineItem `gorm:"foreignKey:RecordRoutineID;constraint:OnDelete:CASCADE" json:"recordRoutineItems"`
 }
 
@@ -140,29 +250,35 @@ type RecordRoutine struct {
 type RecordRoutineItem struct {
 	ID              uint      `gorm:"primaryKey" json:"id"`
 	RecordRoutineID uint      `gorm:"not null;constraint:OnDelete:CASCADE" json:"recordRoutineId"`
-	RoutineItemID   uint      `gorm:"not null;constraint:OnDelete:CASCADE" json:"routineItemId"`
+	RoutineItemID   *uint     `gorm:"constraint:OnDelete:SET NULL" json:"routineItemId"`
 	OrderIndex      int       `gorm:"not null;default:0" json:"orderIndex"`
 	CreatedAt       time.Time `json:"createdAt"`
 	UpdatedAt       time.Time `json:"updatedAt"`
 
 	RecordRoutine       RecordRoutine        `gorm:"constraint:OnDelete:CASCADE" json:"-"`
-	RoutineItem         RoutineItem          `gorm:"constraint:OnDelete:CASCADE" json:"routineItem"`
+	RoutineItem         *RoutineItem         `gorm:"constraint:OnDelete:SET NULL" json:"routineItem,omitempty"`
 	RecordExerciseItems []RecordExerciseItem `gorm:"foreignKey:RecordRoutineItemID;constraint:OnDelete:CASCADE" json:"recordExerciseItems"`
 }
 
 // RecordExerciseItem records completion of an exercise within a routine item
 type RecordExerciseItem struct {
-	ID                  uint      `gorm:"primaryKey" json:"id"`
-	RecordRoutineItemID uint      `gorm:"not null;constraint:OnDelete:CASCADE" json:"recordRoutineItemId"`
-	ExerciseItemID      uint      `gorm:"not null;constraint:OnDelete:CASCADE" json:"exerciseItemId"`
-	RestTime            uint      `gorm:"not null;default:0" json:"restTime"` // In seconds, actual rest taken after this exercise
-	Notes               string    `gorm:"size:500" json:"notes"`
-	OrderIndex          int       `gorm:"not null;default:0" json:"orderIndex"`
-	CreatedAt           time.Time `json:"createdAt"`
-	UpdatedAt           time.Time `json:"updatedAt"`
+	ID                   uint      `gorm:"primaryKey" json:"id"`
+	RecordRoutineItemID  uint      `gorm:"not null;constraint:OnDelete:CASCADE" json:"recordRoutineItemId"`
+	ExerciseItemID       *uint     `gorm:"constraint:OnDelete:SET NULL" json:"exerciseItemId"`
+	ExerciseID           string    `gorm:"size:100;not null;default:'';index" json:"exerciseId"`
+	ExerciseName         string    `gorm:"size:100;not null;default:''" json:"exerciseName"`
+	OriginalExerciseID   string    `gorm:"size:100;not null;default:''" json:"originalExerciseId"` // Planned exercise, if it was swapped
+	OriginalExerciseName string    `gorm:"size:100;not null;default:''" json:"originalExerciseName"`
+	Skipped              bool      `gorm:"not null;default:false" json:"skipped"`
+	RestTime             uint      `gorm:"not null;default:0" json:"restTime"` // In seconds, actual rest taken after this exercise
+	Notes                string    `gorm:"size:500" json:"notes"`
+	OrderIndex           int       `gorm:"not null;default:0" json:"orderIndex"`
+	CreatedAt            time.Time `json:"createdAt"`
+	UpdatedAt            time.Time `json:"updatedAt"`
 
 	RecordRoutineItem RecordRoutineItem `gorm:"constraint:OnDelete:CASCADE" json:"-"`
-	ExerciseItem      ExerciseItem      `gorm:"constraint:OnDelete:CASCADE" json:"exerciseItem"`
+	ExerciseItem      *ExerciseItem     `gorm:"constraint:OnDelete:SET NULL" json:"exerciseItem,omitempty"`
+	Exercise          Exercise          `gorm:"constraint:-" json:"exercise"` // May be missing from the catalog
 	RecordSets        []RecordSet       `gorm:"foreignKey:RecordExerciseItemID;constraint:OnDelete:CASCADE" json:"recordSets"`
 }
 
@@ -170,50 +286,41 @@ type RecordExerciseItem struct {
 type RecordSet struct {
 	ID                   uint       `gorm:"primaryKey" json:"id"`
 	RecordExerciseItemID uint       `gorm:"not null;constraint:OnDelete:CASCADE" json:"recordExerciseItemId"`
-	SetID                uint       `gorm:"not null;constraint:OnDelete:CASCADE" json:"setId"`
+	SetID                *uint      `gorm:"constraint:OnDelete:SET NULL" json:"setId"`
+	PlannedReps          *uint      `json:"plannedReps"`
+	PlannedWeight        *float64   `json:"plannedWeight"`
+	PlannedDuration      *uint      `json:"plannedDuration"` // In seconds
 	Reps                 *uint      `json:"reps"`
 	Weight               *float64   `json:"weight"`
 	Duration             *uint      `json:"duration"` // In seconds
+	RPE                  *float64   `json:"rpe"`      // Rate of perceived exertion, from 1 to 10
 	CompletedAt          *time.Time `json:"completedAt"`
 	OrderIndex           int        `gorm:"not null;default:0" json:"orderIndex"`
 	CreatedAt            time.Time  `json:"createdAt"`
 	UpdatedAt            time.Time  `json:"updatedAt"`
 
 	RecordExerciseItem RecordExerciseItem `gorm:"constraint:OnDelete:CASCADE" json:"-"`
-	Set                Set                `gorm:"constraint:OnDelete:CASCADE" json:"set"`
+	Set                *Set               `gorm:"constraint:OnDelete:SET NULL" json:"set,omitempty"`
 }
 
-// InitializeDB creates and initializes the SQLite database with all models
-func InitializeDB() (db *Database, err error) {
-	// Create the data directory if it doesn't exist
-	if _, err = os.Stat(dbDir); os.IsNotExist(err) {
-		err = os.MkdirAll(dbDir, 0755)
-		if err != nil {
-			return
-		}
+// OpenDB opens the database with the configured driver, without migrating it
+func OpenDB(cfg *config.Config) (db *Database, err error) {
+	dialector, err := openDialector(cfg)
+	if err != nil {
+		return
 	}
-
-	dbPath := filepath.Join(dbDir, dbName)
-
-	// Set up logger for GORM
-	newLogger := logger.New(
-		log.New(os.Stdout, "\r\n", log.LstdFlags),
-		logger.Config{
-			SlowThreshold: time.Second,
-			LogLevel:      logger.Info,
-			Colorful:      true,
-		},
-	)
-
-	dialector := sqlite.Open(dbPath + "?_pragma=foreign_keys(1)")
-	config := &gorm.Config{Logger: newLogger}
+	catalogURL = cfg.CatalogURL
 
 	// Open connection to the database
-	conn, err := gorm.Open(dialector, config)
+	conn, err := gorm.Open(dialector, &gorm.Config{Logger: newLogger(cfg)})
 	if err != nil {
 		return
 	}
 
+	if err = conn.Use(metricsPlugin{}); err != nil {
+		return nil, err
+	}
+
 	// Get the underlying SQL database to set connection parameters
 	sqlDB, err := conn.DB()
 	if err != nil {
@@ -225,27 +332,21 @@ func InitializeDB() (db *Database, err error) {
 	sqlDB.SetMaxOpenConns(100)
 	sqlDB.SetConnMaxLifetime(time.Hour)
 
-	// Auto migrate the models in correct order
-	err = conn.AutoMigrate(
-		&Day{},
-		&User{},
-		&HeightMeasurement{},
-		&WeightMeasurement{},
-		&Exercise{},
-		&Routine{},
-		&RoutineItem{},
-		&ExerciseItem{},
-		&Set{},
-		&RecordRoutine{},
-		&RecordRoutineItem{},
-		&RecordExerciseItem{},
-		&RecordSet{},
-	)
+	return &Database{DB: conn, Config: cfg}, nil
+}
+
+// InitializeDB opens the database, applies the pending migrations and makes
+// sure the initial data is present
+func InitializeDB(cfg *config.Config) (db *Database, err error) {
+	db, err = OpenDB(cfg)
 	if err != nil {
 		return
 	}
 
-	db = &Database{conn}
+	err = db.MigrateUp(0)
+	if err != nil {
+		return nil, err
+	}
 
 	// Ensure initial data is present
 	err = db.CheckInitialData()
@@ -255,3 +356,13 @@ func InitializeDB() (db *Database, err error) {
 
 	return db, nil
 }
+
+// Close closes the connections to the database
+func (db *Database) Close() error {
+	sqlDB, err := db.DB.DB()
+	if err != nil {
+		return err
+	}
+
+	return sqlDB.Close()
+}
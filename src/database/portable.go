@@ -0,0 +1,192 @@
+package database
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	g "github.com/birabittoh/go-lift/src/globals"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	RoutineDocumentFormat  = "go-lift/routine"
+	RoutineDocumentVersion = 1
+)
+
+// RoutineDocument is a routine in a portable format that can be shared
+// between instances. Weights are in kg and exercises are referenced by
+// catalog ID, with their name as a fallback.
+type RoutineDocument struct {
+	Format          string `json:"format" yaml:"format"`
+	Version         int    `json:"version" yaml:"version"`
+	IsTemplate      bool   `json:"isTemplate,omitempty" yaml:"isTemplate,omitempty"`
+	RoutineSnapshot `yaml:",inline"`
+}
+
+type RoutineImportResult struct {
+	Routine          *Routine `json:"routine"`
+	UnknownExercises []string `json:"unknownExercises"`
+}
+
+// NewRoutineDocument exports a routine; items must be sorted
+func NewRoutineDocument(r *Routine) *RoutineDocument {
+	return &RoutineDocument{
+		Format:          RoutineDocumentFormat,
+		Version:         RoutineDocumentVersion,
+		IsTemplate:      r.IsTemplate,
+		RoutineSnapshot: r.Snapshot(),
+	}
+}
+
+// Encode writes the document as "json" or "yaml"
+func (d *RoutineDocument) Encode(w io.Writer, format string) error {
+	switch format {
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(d)
+	case "yaml":
+		encoder := yaml.NewEncoder(w)
+		encoder.SetIndent(2)
+		defer encoder.Close()
+		return encoder.Encode(d)
+	default:
+		return fmt.Errorf("unsupported format: %s", format)
+	}
+}
+
+// ParseRoutineDocument reads a routine document, either in JSON or YAML
+func ParseRoutineDocument(data []byte) (*RoutineDocument, error) {
+	var doc RoutineDocument
+	var err error
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		err = json.Unmarshal(data, &doc)
+	} else {
+		err = yaml.Unmarshal(data, &doc)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse routine: %w", err)
+	}
+
+	if doc.Format != RoutineDocumentFormat {
+		return nil, fmt.Errorf("not a routine document: format is %q", doc.Format)
+	}
+	if doc.Version < 1 || doc.Version > RoutineDocumentVersion {
+		return nil, fmt.Errorf("unsupported routine document version: %d", doc.Version)
+	}
+	if strings.TrimSpace(doc.Name) == "" {
+		return nil, fmt.Errorf("routine name is required")
+	}
+
+	return &doc, nil
+}
+
+func validateSetSnapshot(set SetSnapshot, bodyweight bool) error {
+	if set.Reps != nil && (*set.Reps == 0 || *set.Reps > 99) {
+		return fmt.Errorf("invalid reps value: %v", *set.Reps)
+	}
+	if set.Weight != nil && !validLoad(*set.Weight, bodyweight) {
+		return fmt.Errorf("invalid weight value: %v", *set.Weight)
+	}
+	if set.Duration != nil && (*set.Duration == 0 || *set.Duration > 7200) {
+		return fmt.Errorf("invalid duration value: %v", *set.Duration)
+	}
+	return nil
+}
+
+// findExercise looks an exercise up by ID, then by name
+func findExercise(exercises []Exercise, id, name string) *Exercise {
+	for i := range exercises {
+		if exercises[i].ID == id {
+			return &exercises[i]
+		}
+	}
+	for i := range exercises {
+		if name != "" && strings.EqualFold(exercises[i].Name, name) {
+			return &exercises[i]
+		}
+	}
+	return nil
+}
+
+// ImportRoutine creates a new routine from a document. Exercises missing from
+// the catalog are skipped and reported in the result.
+func (db *Database) ImportRoutine(doc *RoutineDocument) (*RoutineImportResult, error) {
+	exercises, err := db.GetExercises()
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve exercises: %w", err)
+	}
+
+	routine := &Routine{
+		Name:        g.Truncate(strings.TrimSpace(doc.Name), 100),
+		Description: g.Truncate(doc.Description, 500),
+		IsTemplate:  doc.IsTemplate,
+	}
+	result := &RoutineImportResult{Routine: routine, UnknownExercises: []string{}}
+
+	// Templates are not scheduled
+	if !doc.IsTemplate {
+		for _, name := range doc.Days {
+			day := dayByName(name)
+			if day == nil {
+				return nil, fmt.Errorf("unknown day: %s", name)
+			}
+			routine.Days = append(routine.Days, *day)
+		}
+	}
+
+	for _, ri := range doc.Items {
+		item := RoutineItem{OrderIndex: len(routine.RoutineItems)}
+		for _, ei := range ri.Exercises {
+			exercise := findExercise(exercises, ei.ExerciseID, ei.ExerciseName)
+			if exercise == nil {
+				result.UnknownExercises = append(result.UnknownExercises, coalesceName(ei.ExerciseName, ei.ExerciseID))
+				continue
+			}
+
+			exerciseItem := ExerciseItem{
+				ExerciseID: exercise.ID,
+				RestTime:   ei.RestTime,
+				Notes:      g.Truncate(ei.Notes, 500),
+				OrderIndex: len(item.ExerciseItems),
+			}
+			for i, set := range ei.Sets {
+				if err := validateSetSnapshot(set, exercise.IsBodyweight()); err != nil {
+					return nil, fmt.Errorf("%s, set %d: %w", exercise.Name, i+1, err)
+				}
+				exerciseItem.Sets = append(exerciseItem.Sets, Set{Reps: set.Reps, Weight: set.Weight, Duration: set.Duration})
+			}
+			item.ExerciseItems = append(item.ExerciseItems, exerciseItem)
+		}
+
+		// Groups made only of unknown exercises are dropped
+		if len(item.ExerciseItems) > 0 {
+			routine.RoutineItems = append(routine.RoutineItems, item)
+		}
+	}
+
+	if err := db.NewRoutine(routine); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func dayByName(name string) *Day {
+	for i, day := range weekDays {
+		if strings.EqualFold(strings.TrimSpace(name), day) {
+			return &Day{ID: uint(i + 1), Name: g.Capitalize(day)}
+		}
+	}
+	return nil
+}
+
+func coalesceName(name, id string) string {
+	if name != "" {
+		return name
+	}
+	return id
+}
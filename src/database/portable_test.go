@@ -0,0 +1,147 @@
+package database
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestParseRoutineDocument(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		ok   bool
+	}{
+		{"json", `{"format": "go-lift/routine", "version": 1, "name": "Legs"}`, true},
+		{"yaml", "format: go-lift/routine\nversion: 1\nname: Legs\n", true},
+		{"other format", `{"format": "other", "version": 1, "name": "Legs"}`, false},
+		{"newer version", `{"format": "go-lift/routine", "version": 2, "name": "Legs"}`, false},
+		{"missing version", `{"format": "go-lift/routine", "name": "Legs"}`, false},
+		{"missing name", "format: go-lift/routine\nversion: 1\nname: ' '\n", false},
+		{"invalid", "{", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseRoutineDocument([]byte(tt.data))
+			if (err == nil) != tt.ok {
+				t.Errorf("ParseRoutineDocument() error = %v, want success %v", err, tt.ok)
+			}
+		})
+	}
+}
+
+func TestRoutineDocumentRoundTrip(t *testing.T) {
+	forEachMigratedDB(t, func(t *testing.T, db *Database) {
+		legs := &Routine{
+			Name:        "Legs",
+			Description: "Heavy day",
+			Days:        []Day{{ID: 1, Name: "Monday"}},
+			RoutineItems: []RoutineItem{{
+				ExerciseItems: []ExerciseItem{{ExerciseID: "Barbell_Squat", RestTime: 180, Notes: "Belt", Sets: []Set{
+					{Reps: ptr[uint](5), Weight: ptr(100.0)},
+				}}},
+			}, {
+				OrderIndex:    1,
+				ExerciseItems: []ExerciseItem{{ExerciseID: "Pullups", Sets: []Set{{Reps: ptr[uint](8)}}}},
+			}},
+		}
+		mustCreate(t, db.DB, legs)
+		routine, err := db.GetRoutineByID(legs.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := NewRoutineDocument(routine)
+
+		for _, format := range []string{"json", "yaml"} {
+			t.Run(format, func(t *testing.T) {
+				var buf bytes.Buffer
+				if err := want.Encode(&buf, format); err != nil {
+					t.Fatal(err)
+				}
+				doc, err := ParseRoutineDocument(buf.Bytes())
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !reflect.DeepEqual(doc, want) {
+					t.Errorf("parsed document = %+v, want %+v", doc, want)
+				}
+
+				result, err := db.ImportRoutine(doc)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(result.UnknownExercises) != 0 {
+					t.Errorf("unknown exercises = %v, want none", result.UnknownExercises)
+				}
+				imported, err := db.GetRoutineByID(result.Routine.ID)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got := NewRoutineDocument(imported); !reflect.DeepEqual(got, want) {
+					t.Errorf("imported routine = %+v, want %+v", got, want)
+				}
+			})
+		}
+
+		if err := want.Encode(&bytes.Buffer{}, "xml"); err == nil {
+			t.Error("encoded a document as xml")
+		}
+	})
+}
+
+func TestImportRoutineMatchesExercises(t *testing.T) {
+	forEachMigratedDB(t, func(t *testing.T, db *Database) {
+		doc, err := ParseRoutineDocument([]byte(`
+format: go-lift/routine
+version: 1
+name: Push
+days: [friday]
+items:
+  - exercises:
+      - exerciseId: Other_Bench_Press
+        exerciseName: barbell bench press
+        sets: [{reps: 5, weight: 60}]
+      - exerciseId: Cable_Fly
+        exerciseName: Cable Fly
+  - exercises:
+      - exerciseId: Dip_Machine
+`))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		result, err := db.ImportRoutine(doc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := []string{"Cable Fly", "Dip_Machine"}; !reflect.DeepEqual(result.UnknownExercises, want) {
+			t.Errorf("unknown exercises = %v, want %v", result.UnknownExercises, want)
+		}
+
+		routine, err := db.GetRoutineByID(result.Routine.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(routine.Days) != 1 || routine.Days[0].Name != "Friday" {
+			t.Errorf("days = %+v, want Friday", routine.Days)
+		}
+		if len(routine.RoutineItems) != 1 || len(routine.RoutineItems[0].ExerciseItems) != 1 {
+			b, _ := json.Marshal(routine.RoutineItems)
+			t.Fatalf("items = %s, want only the bench press", b)
+		}
+		if id := routine.RoutineItems[0].ExerciseItems[0].ExerciseID; id != "Barbell_Bench_Press" {
+			t.Errorf("exercise = %s, want it matched by name", id)
+		}
+
+		doc.Items[0].Exercises[0].Sets[0].Reps = ptr[uint](0)
+		if _, err := db.ImportRoutine(doc); err == nil {
+			t.Error("imported a set of 0 reps")
+		}
+		doc.Items[0].Exercises[0].Sets[0].Reps = ptr[uint](5)
+		doc.Days = []string{"someday"}
+		if _, err := db.ImportRoutine(doc); err == nil {
+			t.Error("imported a routine on an unknown day")
+		}
+	})
+}
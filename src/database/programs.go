@@ -0,0 +1,414 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	defaultProgramWeeks = 4
+	maxProgramWeeks     = 52
+
+	deloadIntensity        = 60
+	deloadVolumeMultiplier = 0.5
+)
+
+// defaultProgramWeek returns the modifiers of a new week; deload weeks lighten the load
+func defaultProgramWeek(number int, deload bool) ProgramWeek {
+	if deload {
+		return ProgramWeek{WeekNumber: number, Intensity: deloadIntensity, VolumeMultiplier: deloadVolumeMultiplier, Deload: true}
+	}
+	return ProgramWeek{WeekNumber: number, Intensity: 100, VolumeMultiplier: 1}
+}
+
+func sortProgram(p *Program) {
+	sort.SliceStable(p.Weeks, func(i, j int) bool { return p.Weeks[i].WeekNumber < p.Weeks[j].WeekNumber })
+	sort.SliceStable(p.Routines, func(i, j int) bool { return p.Routines[i].OrderIndex < p.Routines[j].OrderIndex })
+}
+
+// Week returns the modifiers of a week, or nil if the program is shorter
+func (p *Program) Week(number int) *ProgramWeek {
+	for i := range p.Weeks {
+		if p.Weeks[i].WeekNumber == number {
+			return &p.Weeks[i]
+		}
+	}
+	return nil
+}
+
+// CurrentProgramWeek returns the modifiers of the week the program is at
+func (p *Program) CurrentProgramWeek() *ProgramWeek {
+	return p.Week(p.CurrentWeek)
+}
+
+// NextRoutine returns the routine to perform next, or nil if the program is not running
+func (p *Program) NextRoutine() *ProgramRoutine {
+	if !p.Active || p.CurrentIndex >= len(p.Routines) || p.CurrentProgramWeek() == nil {
+		return nil
+	}
+	return &p.Routines[p.CurrentIndex]
+}
+
+func validateProgramWeeks(weeks []ProgramWeek) error {
+	if len(weeks) == 0 || len(weeks) > maxProgramWeeks {
+		return fmt.Errorf("a program must last between 1 and %d weeks", maxProgramWeeks)
+	}
+
+	for i, week := range weeks {
+		if week.WeekNumber != i+1 {
+			return fmt.Errorf("invalid week number: %d", week.WeekNumber)
+		}
+		if week.Intensity <= 0 || week.Intensity > 200 {
+			return fmt.Errorf("invalid intensity for week %d: %v", week.WeekNumber, week.Intensity)
+		}
+		if week.VolumeMultiplier <= 0 || week.VolumeMultiplier > 5 {
+			return fmt.Errorf("invalid volume multiplier for week %d: %v", week.WeekNumber, week.VolumeMultiplier)
+		}
+	}
+
+	return nil
+}
+
+func (db *Database) GetPrograms() ([]Program, error) {
+	var programs []Program
+	err := db.
+		Preload("Weeks").
+		Preload("Routines").
+		Preload("Routines.Routine").
+		Find(&programs).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range programs {
+		sortProgram(&programs[i])
+	}
+
+	return programs, nil
+}
+
+func (db *Database) GetProgramByID(id uint) (*Program, error) {
+	var program Program
+	err := db.
+		Preload("Weeks").
+		Preload("Routines").
+		Preload("Routines.Routine").
+		First(&program, id).Error
+	if err != nil {
+		return nil, err
+	}
+
+	sortProgram(&program)
+
+	return &program, nil
+}
+
+// GetActiveProgram returns the program being followed, or nil if there is none
+func (db *Database) GetActiveProgram() (*Program, error) {
+	var program Program
+	err := db.Where("active = ?", true).First(&program).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return db.GetProgramByID(program.ID)
+}
+
+// NewProgram creates a program; without weeks, it gets a default block ending with a deload week
+func (db *Database) NewProgram(program *Program) error {
+	if program.Name == "" || len(program.Name) > 100 {
+		return fmt.Errorf("invalid program name")
+	}
+
+	if len(program.Weeks) == 0 {
+		for i := 1; i <= defaultProgramWeeks; i++ {
+			program.Weeks = append(program.Weeks, defaultProgramWeek(i, i == defaultProgramWeeks))
+		}
+	}
+
+	if err := validateProgramWeeks(program.Weeks); err != nil {
+		return err
+	}
+
+	program.Active = false
+	program.CurrentWeek = 1
+	program.CurrentIndex = 0
+	for i := range program.Routines {
+		program.Routines[i].OrderIndex = i
+	}
+
+	if err := db.Omit("Routines.Routine").Create(program).Error; err != nil {
+		return fmt.Errorf("failed to create program: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateProgram saves the program name and description
+func (db *Database) UpdateProgram(program *Program) error {
+	if program.ID == 0 {
+		return fmt.Errorf("program ID is required for update")
+	}
+
+	if program.Name == "" || len(program.Name) > 100 {
+		return fmt.Errorf("invalid program name")
+	}
+
+	err := db.Model(program).Select("name", "description").Updates(program).Error
+	if err != nil {
+		return fmt.Errorf("failed to update program: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateProgramWeeks replaces the weeks of a program
+func (db *Database) UpdateProgramWeeks(program *Program, weeks []ProgramWeek) error {
+	if program.ID == 0 {
+		return fmt.Errorf("program ID is required for updating weeks")
+	}
+
+	for i := range weeks {
+		weeks[i].ID = 0
+		weeks[i].ProgramID = program.ID
+	}
+
+	if err := validateProgramWeeks(weeks); err != nil {
+		return err
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("program_id = ?", program.ID).Delete(&ProgramWeek{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&weeks).Error
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update program weeks: %w", err)
+	}
+	program.Weeks = weeks
+
+	return nil
+}
+
+// ResizeProgram adds default weeks to, or removes the last weeks from, a program
+func (db *Database) ResizeProgram(program *Program, length int) error {
+	if length == len(program.Weeks) {
+		return nil
+	}
+
+	weeks := make([]ProgramWeek, 0, length)
+	for i := 1; i <= length; i++ {
+		if week := program.Week(i); week != nil {
+			weeks = append(weeks, *week)
+		} else {
+			weeks = append(weeks, defaultProgramWeek(i, false))
+		}
+	}
+
+	return db.UpdateProgramWeeks(program, weeks)
+}
+
+// UpdateProgramRoutines replaces the weekly routines of a program, in order
+func (db *Database) UpdateProgramRoutines(program *Program, routineIDs []uint) error {
+	if program.ID == 0 {
+		return fmt.Errorf("program ID is required for updating routines")
+	}
+
+	routines := make([]ProgramRoutine, 0, len(routineIDs))
+	for i, id := range routineIDs {
+		routines = append(routines, ProgramRoutine{ProgramID: program.ID, RoutineID: id, OrderIndex: i})
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("program_id = ?", program.ID).Delete(&ProgramRoutine{}).Error; err != nil {
+			return err
+		}
+		if len(routines) == 0 {
+			return nil
+		}
+		return tx.Omit("Routine", "Program").Create(&routines).Error
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update program routines: %w", err)
+	}
+	program.Routines = routines
+
+	// Restart the week if its current position was removed
+	if program.CurrentIndex >= len(routines) {
+		if err := db.Model(program).Update("current_index", 0).Error; err != nil {
+			return fmt.Errorf("failed to update program position: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (db *Database) DeleteProgram(id uint) error {
+	if id == 0 {
+		return fmt.Errorf("program ID is required for deletion")
+	}
+
+	if err := db.Delete(&Program{}, id).Error; err != nil {
+		return fmt.Errorf("failed to delete program: %w", err)
+	}
+
+	return nil
+}
+
+// StartProgram makes a program the active one and restarts it from the first week
+func (db *Database) StartProgram(program *Program) error {
+	if program.ID == 0 {
+		return fmt.Errorf("program ID is required to start")
+	}
+
+	if len(program.Routines) == 0 {
+		return fmt.Errorf("program has no routines")
+	}
+
+	now := time.Now()
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&Program{}).Where("active = ?", true).Update("active", false).Error; err != nil {
+			return err
+		}
+		return tx.Model(program).Updates(map[string]any{
+			"active":        true,
+			"current_week":  1,
+			"current_index": 0,
+			"started_at":    now,
+			"completed_at":  nil,
+		}).Error
+	})
+	if err != nil {
+		return fmt.Errorf("failed to start program: %w", err)
+	}
+
+	return nil
+}
+
+// StopProgram stops following a program, keeping its position
+func (db *Database) StopProgram(program *Program) error {
+	if err := db.Model(program).Update("active", false).Error; err != nil {
+		return fmt.Errorf("failed to stop program: %w", err)
+	}
+
+	return nil
+}
+
+// applyProgramWeek scales the planned sets of a new record with the week modifiers
+func applyProgramWeek(record *RecordRoutine, routine *Routine, week *ProgramWeek, inventory *EquipmentInventory) {
+	exercises := map[string]Exercise{}
+	for _, ri := range routine.RoutineItems {
+		for _, ei := range ri.ExerciseItems {
+			exercises[ei.ExerciseID] = ei.Exercise
+		}
+	}
+
+	for i := range record.RecordRoutineItems {
+		items := record.RecordRoutineItems[i].RecordExerciseItems
+		for j := range items {
+			exercise := exercises[items[j].ExerciseID]
+			sets := items[j].RecordSets
+			if len(sets) == 0 {
+				continue
+			}
+
+			// Scale the number of sets, repeating the last one when adding
+			count := max(int(math.Round(float64(len(sets))*week.VolumeMultiplier)), 1)
+			for len(sets) < count {
+				extra := sets[len(sets)-1]
+				extra.OrderIndex = len(sets)
+				sets = append(sets, extra)
+			}
+			sets = sets[:count]
+
+			// Scale added loads only: assistance does not get lighter with intensity
+			for k := range sets {
+				if sets[k].Weight == nil || *sets[k].Weight <= 0 {
+					continue
+				}
+				weight := inventory.SnapLoad(*sets[k].Weight*week.Intensity/100, exercise.Equipment)
+				sets[k].Weight = &weight
+				sets[k].PlannedWeight = &weight
+			}
+
+			items[j].RecordSets = sets
+		}
+	}
+}
+
+// programSession links a new record to the active program when the routine is
+// the next one due, applying the modifiers of the current week.
+func (db *Database) programSession(record *RecordRoutine, routine *Routine) error {
+	program, err := db.GetActiveProgram()
+	if err != nil {
+		return fmt.Errorf("failed to retrieve active program: %w", err)
+	}
+	if program == nil {
+		return nil
+	}
+
+	next := program.NextRoutine()
+	if next == nil || next.RoutineID != routine.ID {
+		return nil
+	}
+
+	inventory, err := db.userInventory()
+	if err != nil {
+		return err
+	}
+
+	week := program.CurrentProgramWeek()
+	applyProgramWeek(record, routine, week, inventory)
+	record.ProgramID = &program.ID
+	record.ProgramWeek = &week.WeekNumber
+
+	return nil
+}
+
+// advanceProgram moves the program past a finished workout: to the next
+// routine, the next week, or the end of the program.
+func (db *Database) advanceProgram(record *RecordRoutine) error {
+	if record.ProgramID == nil || record.ProgramWeek == nil {
+		return nil
+	}
+
+	program, err := db.GetProgramByID(*record.ProgramID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	// Ignore workouts of a position the program has already moved past
+	next := program.NextRoutine()
+	if next == nil || record.RoutineID == nil || next.RoutineID != *record.RoutineID || program.CurrentWeek != *record.ProgramWeek {
+		return nil
+	}
+
+	week, index := nextProgramPosition(program, program.CurrentWeek, program.CurrentIndex)
+	updates := map[string]any{"current_week": week, "current_index": index}
+	if program.Week(week) == nil {
+		updates["active"] = false
+		updates["completed_at"] = time.Now()
+	}
+
+	return db.Model(program).Updates(updates).Error
+}
+
+// nextProgramPosition returns the position following a routine of a program
+func nextProgramPosition(program *Program, week, index int) (int, int) {
+	if index+1 >= len(program.Routines) {
+		return week + 1, 0
+	}
+	return week, index + 1
+}
@@ -0,0 +1,93 @@
+package database
+
+import "testing"
+
+// programRoutine creates a routine of three squat sets at 100 kg
+func programRoutine(t *testing.T, db *Database, name string) *Routine {
+	t.Helper()
+	routine := &Routine{Name: name, RoutineItems: []RoutineItem{{
+		ExerciseItems: []ExerciseItem{{ExerciseID: "Barbell_Squat", Sets: []Set{
+			{Reps: ptr[uint](5), Weight: ptr(100.0)},
+			{Reps: ptr[uint](5), Weight: ptr(100.0)},
+			{Reps: ptr[uint](5), Weight: ptr(100.0)},
+		}}},
+	}}}
+	mustCreate(t, db.DB, routine)
+
+	routine, err := db.GetRoutineByID(routine.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return routine
+}
+
+func TestFinishRecordRoutineAdvancesProgram(t *testing.T) {
+	forEachMigratedDB(t, func(t *testing.T, db *Database) {
+		upper := programRoutine(t, db, "Upper")
+		lower := programRoutine(t, db, "Lower")
+
+		program := &Program{
+			Name:     "Block",
+			Weeks:    []ProgramWeek{defaultProgramWeek(1, false), defaultProgramWeek(2, true)},
+			Routines: []ProgramRoutine{{RoutineID: upper.ID}, {RoutineID: lower.ID}},
+		}
+		if err := db.NewProgram(program); err != nil {
+			t.Fatal(err)
+		}
+		if err := db.StartProgram(program); err != nil {
+			t.Fatal(err)
+		}
+
+		// perform starts and finishes a routine, returning the program position after it
+		perform := func(routine *Routine) (*RecordRoutine, *Program) {
+			t.Helper()
+			record, err := db.NewRecordRoutine(routine)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := db.FinishRecordRoutine(record); err != nil {
+				t.Fatal(err)
+			}
+			program, err := db.GetProgramByID(program.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return record, program
+		}
+
+		// Routines out of order are not part of the program
+		record, p := perform(lower)
+		if record.ProgramID != nil || p.CurrentWeek != 1 || p.CurrentIndex != 0 {
+			t.Errorf("out of order workout moved the program to week %d, index %d", p.CurrentWeek, p.CurrentIndex)
+		}
+
+		record, p = perform(upper)
+		if record.ProgramWeek == nil || *record.ProgramWeek != 1 || p.CurrentWeek != 1 || p.CurrentIndex != 1 {
+			t.Errorf("after the first session the program is at week %d, index %d", p.CurrentWeek, p.CurrentIndex)
+		}
+
+		_, p = perform(lower)
+		if p.CurrentWeek != 2 || p.CurrentIndex != 0 || !p.Active {
+			t.Errorf("after the first week the program is at week %d, index %d", p.CurrentWeek, p.CurrentIndex)
+		}
+
+		// The deload week halves the sets and lightens the load
+		record, p = perform(upper)
+		sets := record.RecordRoutineItems[0].RecordExerciseItems[0].RecordSets
+		if len(sets) != 2 || *sets[0].PlannedWeight != 60 {
+			t.Errorf("deload sets = %d at %g kg, want 2 at 60 kg", len(sets), *sets[0].PlannedWeight)
+		}
+		if p.CurrentWeek != 2 || p.CurrentIndex != 1 {
+			t.Errorf("after the deload session the program is at week %d, index %d", p.CurrentWeek, p.CurrentIndex)
+		}
+
+		_, p = perform(lower)
+		if p.Active || p.CompletedAt == nil || p.CurrentWeek != 3 {
+			t.Errorf("program = active %v, completed at %v, week %d, want it completed", p.Active, p.CompletedAt, p.CurrentWeek)
+		}
+
+		if err := db.FinishRecordRoutine(record); err == nil {
+			t.Error("finished a workout twice")
+		}
+	})
+}
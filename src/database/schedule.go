@@ -0,0 +1,236 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	ScheduleStatusPlanned    = "planned"
+	ScheduleStatusInProgress = "in progress"
+	ScheduleStatusCompleted  = "completed"
+	ScheduleStatusMissed     = "missed"
+)
+
+// maxScheduleDays limits the range of a single schedule query
+const maxScheduleDays = 366
+
+// ScheduledWorkout is a routine planned (or performed) on a given date
+type ScheduledWorkout struct {
+	Date            time.Time `json:"date"`
+	RoutineID       uint      `json:"routineId"`
+	RoutineName     string    `json:"routineName"`
+	Status          string    `json:"status"`
+	Unplanned       bool      `json:"unplanned"` // Performed on a day it was not scheduled for
+	RecordRoutineID *uint     `json:"recordRoutineId,omitempty"`
+	ProgramName     string    `json:"programName,omitempty"`
+	ProgramWeek     *int      `json:"programWeek,omitempty"`
+}
+
+// ScheduleDay lists the workouts of a single date
+type ScheduleDay struct {
+	Date     time.Time          `json:"date"`
+	Workouts []ScheduledWorkout `json:"workouts"`
+}
+
+func (d ScheduleDay) IsToday() bool {
+	return sameDay(d.Date, time.Now())
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local)
+}
+
+func sameDay(a, b time.Time) bool {
+	a, b = a.In(time.Local), b.In(time.Local)
+	return a.Year() == b.Year() && a.YearDay() == b.YearDay()
+}
+
+// IsScheduledOn reports whether the routine is planned on the week day of t
+func (r Routine) IsScheduledOn(t time.Time) bool {
+	if r.IsTemplate {
+		return false
+	}
+
+	weekday := t.In(time.Local).Weekday().String()
+	for _, d := range r.Days {
+		if strings.EqualFold(d.Name, weekday) {
+			return true
+		}
+	}
+	return false
+}
+
+func (w *ScheduledWorkout) setRecord(record *RecordRoutine) {
+	w.Status = ScheduleStatusCompleted
+	if record.Duration == nil {
+		w.Status = ScheduleStatusInProgress
+	}
+	w.RecordRoutineID = &record.ID
+	if record.Program != nil && record.ProgramWeek != nil {
+		w.ProgramName = record.Program.Name
+		w.ProgramWeek = record.ProgramWeek
+	}
+}
+
+// CheckScheduleRange tells whether the schedule can be listed between two
+// dates
+func CheckScheduleRange(from, to time.Time) error {
+	from, to = startOfDay(from), startOfDay(to)
+	if to.Before(from) {
+		return fmt.Errorf("invalid date range")
+	}
+	if to.Sub(from) > maxScheduleDays*24*time.Hour {
+		return fmt.Errorf("date range too long")
+	}
+	return nil
+}
+
+// GetSchedule returns every date between from and to (inclusive) with the
+// routines scheduled through Routine.Days, marked as completed or missed
+// according to the recorded sessions of that date.
+func (db *Database) GetSchedule(from, to time.Time) ([]ScheduleDay, error) {
+	if err := CheckScheduleRange(from, to); err != nil {
+		return nil, err
+	}
+	from, to = startOfDay(from), startOfDay(to)
+
+	routines, err := db.GetRoutines()
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve routines: %w", err)
+	}
+
+	var records []RecordRoutine
+	err = db.
+		Preload("Program").
+		Where("created_at >= ? AND created_at < ?", from, to.AddDate(0, 0, 1)).
+		Order("created_at").
+		Find(&records).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve workouts: %w", err)
+	}
+
+	today := startOfDay(time.Now())
+	var days []ScheduleDay
+
+	for date := from; !date.After(to); date = date.AddDate(0, 0, 1) {
+		day := ScheduleDay{Date: date, Workouts: []ScheduledWorkout{}}
+
+		// Sessions recorded on this date
+		var dayRecords []RecordRoutine
+		for _, record := range records {
+			if sameDay(record.CreatedAt, date) {
+				dayRecords = append(dayRecords, record)
+			}
+		}
+		used := make([]bool, len(dayRecords))
+
+		for _, routine := range routines {
+			if !routine.IsScheduledOn(date) {
+				continue
+			}
+
+			workout := ScheduledWorkout{
+				Date:        date,
+				RoutineID:   routine.ID,
+				RoutineName: routine.Name,
+				Status:      ScheduleStatusPlanned,
+			}
+
+			for i, record := range dayRecords {
+				if !used[i] && record.RoutineID != nil && *record.RoutineID == routine.ID {
+					used[i] = true
+					workout.setRecord(&dayRecords[i])
+					break
+				}
+			}
+
+			if workout.Status == ScheduleStatusPlanned && date.Before(today) {
+				workout.Status = ScheduleStatusMissed
+			}
+
+			day.Workouts = append(day.Workouts, workout)
+		}
+
+		// Sessions of routines that were not scheduled for this date
+		for i, record := range dayRecords {
+			if used[i] {
+				continue
+			}
+			workout := ScheduledWorkout{
+				Date:        date,
+				RoutineName: record.RoutineName,
+				Unplanned:   true,
+			}
+			if record.RoutineID != nil {
+				workout.RoutineID = *record.RoutineID
+			}
+			workout.setRecord(&dayRecords[i])
+			day.Workouts = append(day.Workouts, workout)
+		}
+
+		days = append(days, day)
+	}
+
+	if err := db.projectProgram(days); err != nil {
+		return nil, err
+	}
+
+	return days, nil
+}
+
+// projectProgram assigns the upcoming planned workouts to the position they
+// will have in the active program, assuming every session is performed.
+func (db *Database) projectProgram(days []ScheduleDay) error {
+	program, err := db.GetActiveProgram()
+	if err != nil {
+		return fmt.Errorf("failed to retrieve active program: %w", err)
+	}
+	if program == nil {
+		return nil
+	}
+
+	week, index := program.CurrentWeek, program.CurrentIndex
+	for i := range days {
+		for j := range days[i].Workouts {
+			workout := &days[i].Workouts[j]
+			if workout.Status != ScheduleStatusPlanned {
+				continue
+			}
+			if program.Week(week) == nil || index >= len(program.Routines) {
+				return nil
+			}
+			if program.Routines[index].RoutineID != workout.RoutineID {
+				continue
+			}
+
+			workout.ProgramName = program.Name
+			workout.ProgramWeek = new(int)
+			*workout.ProgramWeek = week
+			week, index = nextProgramPosition(program, week, index)
+		}
+	}
+
+	return nil
+}
+
+// GetTodaysWorkout suggests the first routine scheduled for today that has not
+// been performed yet; if there is none, the next scheduled one within a week.
+func (db *Database) GetTodaysWorkout() (*ScheduledWorkout, error) {
+	now := time.Now()
+	days, err := db.GetSchedule(now, now.AddDate(0, 0, 6))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, day := range days {
+		for _, workout := range day.Workouts {
+			if workout.Status == ScheduleStatusPlanned {
+				return &workout, nil
+			}
+		}
+	}
+
+	return nil, nil
+}
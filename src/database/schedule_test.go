@@ -0,0 +1,112 @@
+package database
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// scheduleRoutine creates a routine planned on the given days, 0 being Monday
+func scheduleRoutine(t *testing.T, db *Database, name string, template bool, days ...int) *Routine {
+	t.Helper()
+	routine := &Routine{Name: name, IsTemplate: template}
+	mustCreate(t, db.DB, routine)
+
+	all := db.GetDays()
+	var planned []Day
+	for _, d := range days {
+		planned = append(planned, all[d])
+	}
+	if err := db.UpdateRoutineDays(routine, planned); err != nil {
+		t.Fatal(err)
+	}
+	return routine
+}
+
+// scheduleSummary describes the workouts of a day, to compare them
+func scheduleSummary(day ScheduleDay) string {
+	var parts []string
+	for _, w := range day.Workouts {
+		s := w.RoutineName + " " + w.Status
+		if w.Unplanned {
+			s += " unplanned"
+		}
+		parts = append(parts, s)
+	}
+	return strings.Join(parts, ", ")
+}
+
+func TestGetSchedule(t *testing.T) {
+	monday := time.Date(2024, 3, 4, 0, 0, 0, 0, time.Local)
+
+	forEachMigratedDB(t, func(t *testing.T, db *Database) {
+		upper := scheduleRoutine(t, db, "Upper", false, 0, 2)
+		lower := scheduleRoutine(t, db, "Lower", false, 0)
+		scheduleRoutine(t, db, "Template", true, 0)
+
+		mustCreate(t, db.DB, &RecordRoutine{
+			RoutineID:   &upper.ID,
+			RoutineName: upper.Name,
+			Duration:    ptr[uint](3600),
+			CreatedAt:   monday.Add(18 * time.Hour),
+		})
+		mustCreate(t, db.DB, &RecordRoutine{
+			RoutineID:   &lower.ID,
+			RoutineName: lower.Name,
+			CreatedAt:   monday.AddDate(0, 0, 1).Add(18 * time.Hour),
+		})
+
+		days, err := db.GetSchedule(monday, monday.AddDate(0, 0, 6))
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := []string{
+			"Upper completed, Lower missed",
+			"Lower in progress unplanned",
+			"Upper missed",
+			"", "", "", "",
+		}
+		if len(days) != len(want) {
+			t.Fatalf("got %d days, want %d", len(days), len(want))
+		}
+		for i, w := range want {
+			if !days[i].Date.Equal(monday.AddDate(0, 0, i)) {
+				t.Errorf("day %d is %s", i, days[i].Date)
+			}
+			if got := scheduleSummary(days[i]); got != w {
+				t.Errorf("%s = %q, want %q", days[i].Date.Weekday(), got, w)
+			}
+		}
+
+		// Upcoming workouts are only planned
+		today := startOfDay(time.Now())
+		days, err = db.GetSchedule(today, today.AddDate(0, 0, 6))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, day := range days {
+			if day.Date.Weekday() == time.Monday && scheduleSummary(day) != "Upper planned, Lower planned" {
+				t.Errorf("%s = %q, want both routines planned", day.Date.Format(time.DateOnly), scheduleSummary(day))
+			}
+		}
+	})
+}
+
+func TestGetScheduleRange(t *testing.T) {
+	forEachMigratedDB(t, func(t *testing.T, db *Database) {
+		now := time.Now()
+		if _, err := db.GetSchedule(now, now.AddDate(0, 0, -1)); err == nil {
+			t.Error("accepted a range ending before it starts")
+		}
+		if _, err := db.GetSchedule(now, now.AddDate(0, 0, maxScheduleDays+1)); err == nil {
+			t.Error("accepted a range that is too long")
+		}
+		days, err := db.GetSchedule(now, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(days) != 1 || !days[0].IsToday() {
+			t.Errorf("schedule of today = %+v, want a single day", days)
+		}
+	})
+}
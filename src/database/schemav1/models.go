@@ -0,0 +1,314 @@
+// Package schemav1 holds the models as they were when migration 1 was
+// released. Migration 1 creates its tables from them, so that changes to the
+// current models don't change it; those go in new migrations.
+package schemav1
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Day model represents a week day
+type Day struct {
+	ID   uint   `gorm:"primaryKey"`
+	Name string `gorm:"size:10;not null;uniqueIndex"`
+}
+
+// User holds the profile and preferences
+type User struct {
+	ID        uint   `gorm:"primaryKey"`
+	Name      string `gorm:"size:50"`
+	IsFemale  bool
+	Height    *float64
+	Weight    *float64
+	BirthDate *time.Time
+
+	WeightUnit string `gorm:"size:2;not null;default:kg"`
+	LengthUnit string `gorm:"size:2;not null;default:cm"`
+
+	FeedToken *string `gorm:"size:64;uniqueIndex"`
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+}
+
+type HeightMeasurement struct {
+	ID        uint `gorm:"primaryKey"`
+	Height    float64
+	CreatedAt time.Time
+}
+
+type WeightMeasurement struct {
+	ID        uint `gorm:"primaryKey"`
+	Weight    float64
+	CreatedAt time.Time
+}
+
+// BodyMetric represents a kind of body measurement (body fat, waist, chest...)
+type BodyMetric struct {
+	ID         uint   `gorm:"primaryKey"`
+	Name       string `gorm:"size:50;not null;uniqueIndex"`
+	Unit       string `gorm:"size:10;not null"`
+	OrderIndex int    `gorm:"not null;default:0"`
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+
+	BodyMeasurements []BodyMeasurement `gorm:"foreignKey:BodyMetricID;constraint:OnDelete:CASCADE"`
+}
+
+// BodyMeasurement records a dated value for a body metric
+type BodyMeasurement struct {
+	ID           uint      `gorm:"primaryKey"`
+	BodyMetricID uint      `gorm:"not null;index"`
+	Value        float64   `gorm:"not null"`
+	Date         time.Time `gorm:"not null;index"`
+	Notes        string    `gorm:"size:200"`
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+
+	BodyMetric BodyMetric `gorm:"constraint:OnDelete:CASCADE"`
+}
+
+// EquipmentInventory describes the equipment available to a user; all weights are in kg
+type EquipmentInventory struct {
+	ID                uint   `gorm:"primaryKey"`
+	UserID            uint   `gorm:"not null;uniqueIndex"`
+	BarWeights        string `gorm:"size:200"`
+	Plates            string `gorm:"size:500"`
+	DumbbellIncrement float64
+	DumbbellMax       float64
+	MachineIncrement  float64
+	MachineMax        float64
+	Available         string `gorm:"size:500"`
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+
+	User User `gorm:"constraint:OnDelete:CASCADE"`
+}
+
+// Exercise model
+type Exercise struct {
+	ID                 string  `gorm:"primaryKey"`
+	Name               string  `gorm:"not null;uniqueIndex"`
+	Level              string  `gorm:"size:50;not null"`
+	Category           string  `gorm:"size:50;not null"`
+	Force              *string `gorm:"size:50"`
+	Mechanic           *string `gorm:"size:50"`
+	Equipment          *string `gorm:"size:50"`
+	InstructionsString *string
+
+	PrimaryMuscles   *string
+	SecondaryMuscles *string
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// Routine represents a workout routine blueprint
+type Routine struct {
+	ID          uint   `gorm:"primaryKey"`
+	Name        string `gorm:"size:100;not null"`
+	Description string `gorm:"size:500"`
+	IsTemplate  bool   `gorm:"not null;default:false"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+
+	RoutineItems []RoutineItem    `gorm:"foreignKey:RoutineID;constraint:OnDelete:CASCADE"`
+	Days         []Day            `gorm:"many2many:routine_days;constraint:OnDelete:CASCADE;"`
+	Versions     []RoutineVersion `gorm:"foreignKey:RoutineID;constraint:OnDelete:SET NULL"`
+}
+
+// RoutineVersion is an immutable snapshot of a routine, taken when a workout starts
+type RoutineVersion struct {
+	ID        uint   `gorm:"primaryKey"`
+	RoutineID *uint  `gorm:"constraint:OnDelete:SET NULL;uniqueIndex:idx_routine_version"`
+	Version   int    `gorm:"not null;uniqueIndex:idx_routine_version"`
+	Snapshot  string `gorm:"not null"`
+	CreatedAt time.Time
+}
+
+// RoutineItem represents a group of exercises (can be a single exercise or superset)
+type RoutineItem struct {
+	ID         uint `gorm:"primaryKey"`
+	RoutineID  uint `gorm:"not null;constraint:OnDelete:CASCADE"`
+	OrderIndex int  `gorm:"not null;default:0"`
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+
+	Routine       Routine        `gorm:"constraint:OnDelete:CASCADE"`
+	ExerciseItems []ExerciseItem `gorm:"foreignKey:RoutineItemID;constraint:OnDelete:CASCADE;"`
+}
+
+// ExerciseItem represents an exercise within a routine item
+type ExerciseItem struct {
+	ID            uint   `gorm:"primaryKey"`
+	RoutineItemID uint   `gorm:"not null;constraint:OnDelete:CASCADE;uniqueIndex:idx_routineitem_exercise"`
+	ExerciseID    string `gorm:"not null;constraint:OnDelete:CASCADE;uniqueIndex:idx_routineitem_exercise"`
+	RestTime      uint   `gorm:"not null;default:0"`
+	Notes         string `gorm:"size:500"`
+	OrderIndex    int    `gorm:"not null;default:0"`
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+
+	RoutineItem RoutineItem `gorm:"constraint:OnDelete:CASCADE"`
+	Exercise    Exercise    `gorm:"constraint:OnDelete:CASCADE"`
+	Sets        []Set       `gorm:"foreignKey:ExerciseItemID;constraint:OnDelete:CASCADE"`
+}
+
+// Set represents a planned set within an exercise
+type Set struct {
+	ID             uint `gorm:"primaryKey"`
+	ExerciseItemID uint `gorm:"not null;constraint:OnDelete:CASCADE"`
+	Reps           *uint
+	Weight         *float64
+	Duration       *uint
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+
+	ExerciseItem ExerciseItem `gorm:"constraint:OnDelete:CASCADE"`
+}
+
+// Program sequences routines across weeks, e.g. a 12-week training block
+type Program struct {
+	ID           uint   `gorm:"primaryKey"`
+	Name         string `gorm:"size:100;not null"`
+	Description  string `gorm:"size:500"`
+	Active       bool   `gorm:"not null;default:false"`
+	CurrentWeek  int    `gorm:"not null;default:1"`
+	CurrentIndex int    `gorm:"not null;default:0"`
+	StartedAt    *time.Time
+	CompletedAt  *time.Time
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+
+	Weeks    []ProgramWeek    `gorm:"foreignKey:ProgramID;constraint:OnDelete:CASCADE"`
+	Routines []ProgramRoutine `gorm:"foreignKey:ProgramID;constraint:OnDelete:CASCADE"`
+}
+
+// ProgramWeek holds the modifiers applied to every session of a program week
+type ProgramWeek struct {
+	ID               uint    `gorm:"primaryKey"`
+	ProgramID        uint    `gorm:"not null;constraint:OnDelete:CASCADE;uniqueIndex:idx_program_week"`
+	WeekNumber       int     `gorm:"not null;uniqueIndex:idx_program_week"`
+	Intensity        float64 `gorm:"not null;default:100"`
+	VolumeMultiplier float64 `gorm:"not null;default:1"`
+	Deload           bool    `gorm:"not null;default:false"`
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+}
+
+// ProgramRoutine is a routine performed every week of a program, in order
+type ProgramRoutine struct {
+	ID         uint `gorm:"primaryKey"`
+	ProgramID  uint `gorm:"not null;constraint:OnDelete:CASCADE"`
+	RoutineID  uint `gorm:"not null;constraint:OnDelete:CASCADE"`
+	OrderIndex int  `gorm:"not null;default:0"`
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+
+	Program Program `gorm:"constraint:OnDelete:CASCADE"`
+	Routine Routine `gorm:"constraint:OnDelete:CASCADE"`
+}
+
+// ===== RECORD MODELS (for actual workout completion) =====
+
+// RecordRoutine records a completed workout session
+type RecordRoutine struct {
+	ID          uint   `gorm:"primaryKey"`
+	RoutineID   *uint  `gorm:"constraint:OnDelete:SET NULL"`
+	RoutineName string `gorm:"size:100;not null;default:''"`
+	ProgramID   *uint  `gorm:"constraint:OnDelete:SET NULL"`
+	ProgramWeek *int
+	VersionID   *uint `gorm:"constraint:OnDelete:SET NULL"`
+	Duration    *uint
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+
+	Routine            *Routine            `gorm:"constraint:OnDelete:SET NULL"`
+	Program            *Program            `gorm:"constraint:OnDelete:SET NULL"`
+	Version            *RoutineVersion     `gorm:"constraint:OnDelete:SET NULL"`
+	RecordRoutineItems []RecordRoutineItem `gorm:"foreignKey:RecordRoutineID;constraint:OnDelete:CASCADE"`
+}
+
+// RecordRoutineItem records completion of a routine item (group of exercises)
+type RecordRoutineItem struct {
+	ID              uint  `gorm:"primaryKey"`
+	RecordRoutineID uint  `gorm:"not null;constraint:OnDelete:CASCADE"`
+	RoutineItemID   *uint `gorm:"constraint:OnDelete:SET NULL"`
+	OrderIndex      int   `gorm:"not null;default:0"`
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+
+	RecordRoutine       RecordRoutine        `gorm:"constraint:OnDelete:CASCADE"`
+	RoutineItem         *RoutineItem         `gorm:"constraint:OnDelete:SET NULL"`
+	RecordExerciseItems []RecordExerciseItem `gorm:"foreignKey:RecordRoutineItemID;constraint:OnDelete:CASCADE"`
+}
+
+// RecordExerciseItem records completion of an exercise within a routine item
+type RecordExerciseItem struct {
+	ID                   uint   `gorm:"primaryKey"`
+	RecordRoutineItemID  uint   `gorm:"not null;constraint:OnDelete:CASCADE"`
+	ExerciseItemID       *uint  `gorm:"constraint:OnDelete:SET NULL"`
+	ExerciseID           string `gorm:"size:100;not null;default:'';index"`
+	ExerciseName         string `gorm:"size:100;not null;default:''"`
+	OriginalExerciseID   string `gorm:"size:100;not null;default:''"`
+	OriginalExerciseName string `gorm:"size:100;not null;default:''"`
+	Skipped              bool   `gorm:"not null;default:false"`
+	RestTime             uint   `gorm:"not null;default:0"`
+	Notes                string `gorm:"size:500"`
+	OrderIndex           int    `gorm:"not null;default:0"`
+	CreatedAt            time.Time
+	UpdatedAt            time.Time
+
+	RecordRoutineItem RecordRoutineItem `gorm:"constraint:OnDelete:CASCADE"`
+	ExerciseItem      *ExerciseItem     `gorm:"constraint:OnDelete:SET NULL"`
+	Exercise          Exercise          `gorm:"constraint:-"`
+	RecordSets        []RecordSet       `gorm:"foreignKey:RecordExerciseItemID;constraint:OnDelete:CASCADE"`
+}
+
+// RecordSet records completion of an actual set
+type RecordSet struct {
+	ID                   uint  `gorm:"primaryKey"`
+	RecordExerciseItemID uint  `gorm:"not null;constraint:OnDelete:CASCADE"`
+	SetID                *uint `gorm:"constraint:OnDelete:SET NULL"`
+	PlannedReps          *uint
+	PlannedWeight        *float64
+	PlannedDuration      *uint
+	Reps                 *uint
+	Weight               *float64
+	Duration             *uint
+	RPE                  *float64
+	CompletedAt          *time.Time
+	OrderIndex           int `gorm:"not null;default:0"`
+	CreatedAt            time.Time
+	UpdatedAt            time.Time
+
+	RecordExerciseItem RecordExerciseItem `gorm:"constraint:OnDelete:CASCADE"`
+	Set                *Set               `gorm:"constraint:OnDelete:SET NULL"`
+}
+
+// Models lists every model in the order they must be migrated
+var Models = []any{
+	&Day{},
+	&User{},
+	&HeightMeasurement{},
+	&WeightMeasurement{},
+	&BodyMetric{},
+	&BodyMeasurement{},
+	&EquipmentInventory{},
+	&Exercise{},
+	&Routine{},
+	&RoutineVersion{},
+	&RoutineItem{},
+	&ExerciseItem{},
+	&Set{},
+	&Program{},
+	&ProgramWeek{},
+	&ProgramRoutine{},
+	&RecordRoutine{},
+	&RecordRoutineItem{},
+	&RecordExerciseItem{},
+	&RecordSet{},
+}
@@ -0,0 +1,136 @@
+package database
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// sessionExerciseItem looks an exercise of a workout in progress up
+func sessionExerciseItem(record *RecordRoutine, recordExerciseItemID uint) (*RecordExerciseItem, error) {
+	if record.Duration != nil {
+		return nil, fmt.Errorf("workout is already finished")
+	}
+
+	rei := findRecordExerciseItem(record, recordExerciseItemID)
+	if rei == nil {
+		return nil, fmt.Errorf("exercise %d is not part of this workout", recordExerciseItemID)
+	}
+
+	return rei, nil
+}
+
+// SwapRecordExercise replaces an exercise of a workout in progress, keeping
+// track of the planned one. Loads of the sets still to do are cleared, as they
+// were meant for the other exercise.
+func (db *Database) SwapRecordExercise(record *RecordRoutine, recordExerciseItemID uint, exerciseID string) error {
+	rei, err := sessionExerciseItem(record, recordExerciseItemID)
+	if err != nil {
+		return err
+	}
+
+	exercise, err := db.GetExerciseByID(exerciseID)
+	if err != nil {
+		return fmt.Errorf("exercise not found: %s", exerciseID)
+	}
+
+	if rei.OriginalExerciseID == "" {
+		rei.OriginalExerciseID = rei.ExerciseID
+		rei.OriginalExerciseName = rei.ExerciseName
+	}
+	if rei.OriginalExerciseID == exercise.ID {
+		rei.OriginalExerciseID = ""
+		rei.OriginalExerciseName = ""
+	}
+	rei.ExerciseID = exercise.ID
+	rei.ExerciseName = exercise.Name
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(rei).Select("exercise_id", "exercise_name", "original_exercise_id", "original_exercise_name").Updates(rei).Error
+		if err != nil {
+			return fmt.Errorf("failed to swap exercise: %w", err)
+		}
+
+		err = tx.Model(&RecordSet{}).
+			Where("record_exercise_item_id = ? AND completed_at IS NULL", rei.ID).
+			Update("weight", nil).Error
+		if err != nil {
+			return fmt.Errorf("failed to reset set loads: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// SkipRecordExercise marks an exercise of a workout in progress as skipped, or not
+func (db *Database) SkipRecordExercise(record *RecordRoutine, recordExerciseItemID uint, skip bool) error {
+	rei, err := sessionExerciseItem(record, recordExerciseItemID)
+	if err != nil {
+		return err
+	}
+
+	if err := db.Model(rei).Update("skipped", skip).Error; err != nil {
+		return fmt.Errorf("failed to skip exercise: %w", err)
+	}
+
+	return nil
+}
+
+func recordItemStarted(item *RecordRoutineItem) bool {
+	for _, rei := range item.RecordExerciseItems {
+		for _, set := range rei.RecordSets {
+			if set.CompletedAt != nil {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// MoveRecordRoutineItem swaps an item of a workout in progress with the
+// previous or next one. Only items without completed sets can be reordered.
+// The record must be sorted.
+func (db *Database) MoveRecordRoutineItem(record *RecordRoutine, recordRoutineItemID uint, up bool) error {
+	if record.Duration != nil {
+		return fmt.Errorf("workout is already finished")
+	}
+
+	i := -1
+	for j := range record.RecordRoutineItems {
+		if record.RecordRoutineItems[j].ID == recordRoutineItemID {
+			i = j
+			break
+		}
+	}
+	if i < 0 {
+		return fmt.Errorf("item %d is not part of this workout", recordRoutineItemID)
+	}
+
+	j := i + 1
+	if up {
+		j = i - 1
+	}
+	if j < 0 || j >= len(record.RecordRoutineItems) {
+		return fmt.Errorf("cannot move item further")
+	}
+
+	item, other := &record.RecordRoutineItems[i], &record.RecordRoutineItems[j]
+	if recordItemStarted(item) || recordItemStarted(other) {
+		return fmt.Errorf("only the remaining items can be reordered")
+	}
+
+	// Indexes may have gaps once exercises are added, so all items are renumbered
+	items := record.RecordRoutineItems
+	items[i], items[j] = items[j], items[i]
+	return db.Transaction(func(tx *gorm.DB) error {
+		for k := range items {
+			if items[k].OrderIndex == k {
+				continue
+			}
+			if err := tx.Model(&items[k]).Update("order_index", k).Error; err != nil {
+				return fmt.Errorf("failed to move item: %w", err)
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,158 @@
+package database
+
+import (
+	"testing"
+	"time"
+)
+
+// startSession starts a workout of three items: squats, bench press and pullups
+func startSession(t *testing.T, db *Database) *RecordRoutine {
+	t.Helper()
+
+	routine := &Routine{Name: "Full body"}
+	for i, id := range []string{"Barbell_Squat", "Barbell_Bench_Press", "Pullups"} {
+		routine.RoutineItems = append(routine.RoutineItems, RoutineItem{OrderIndex: i, ExerciseItems: []ExerciseItem{{
+			ExerciseID: id,
+			Sets:       []Set{{Reps: ptr[uint](5), Weight: ptr(60.0)}, {Reps: ptr[uint](5), Weight: ptr(60.0)}},
+		}}})
+	}
+	mustCreate(t, db.DB, routine)
+
+	routine, err := db.GetRoutineByID(routine.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	record, err := db.NewRecordRoutine(routine)
+	if err != nil {
+		t.Fatal(err)
+	}
+	record, err = db.GetRecordRoutineByID(record.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return record
+}
+
+func TestSwapRecordExercise(t *testing.T) {
+	forEachMigratedDB(t, func(t *testing.T, db *Database) {
+		record := startSession(t, db)
+		rei := record.RecordRoutineItems[0].RecordExerciseItems[0]
+
+		completed := time.Now()
+		if err := db.Model(&rei.RecordSets[0]).Update("completed_at", &completed).Error; err != nil {
+			t.Fatal(err)
+		}
+
+		if err := db.SwapRecordExercise(record, rei.ID, "Missing"); err == nil {
+			t.Error("swapped to an exercise missing from the catalog")
+		}
+		if err := db.SwapRecordExercise(record, 0, "Pullups"); err == nil {
+			t.Error("swapped an exercise that is not part of the workout")
+		}
+		if err := db.SwapRecordExercise(record, rei.ID, "Barbell_Bench_Press"); err != nil {
+			t.Fatal(err)
+		}
+
+		record, err := db.GetRecordRoutineByID(record.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		swapped := record.RecordRoutineItems[0].RecordExerciseItems[0]
+		if swapped.ExerciseID != "Barbell_Bench_Press" || swapped.OriginalExerciseID != "Barbell_Squat" || swapped.OriginalExerciseName != "Barbell Squat" {
+			t.Errorf("swapped exercise = %s, original %s (%s), want Barbell_Bench_Press, original Barbell_Squat", swapped.ExerciseID, swapped.OriginalExerciseID, swapped.OriginalExerciseName)
+		}
+		if w := swapped.RecordSets[0].Weight; w == nil || *w != 60 {
+			t.Errorf("completed set weight = %v, want it kept", w)
+		}
+		if w := swapped.RecordSets[1].Weight; w != nil {
+			t.Errorf("remaining set weight = %v, want it cleared", *w)
+		}
+
+		// Swapping back forgets the original exercise
+		if err := db.SwapRecordExercise(record, swapped.ID, "Barbell_Squat"); err != nil {
+			t.Fatal(err)
+		}
+		record, err = db.GetRecordRoutineByID(record.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if back := record.RecordRoutineItems[0].RecordExerciseItems[0]; back.ExerciseID != "Barbell_Squat" || back.OriginalExerciseID != "" {
+			t.Errorf("exercise = %s, original %q, want Barbell_Squat without an original one", back.ExerciseID, back.OriginalExerciseID)
+		}
+	})
+}
+
+func TestSkipRecordExercise(t *testing.T) {
+	forEachMigratedDB(t, func(t *testing.T, db *Database) {
+		record := startSession(t, db)
+		id := record.RecordRoutineItems[1].RecordExerciseItems[0].ID
+
+		for _, skip := range []bool{true, false} {
+			if err := db.SkipRecordExercise(record, id, skip); err != nil {
+				t.Fatal(err)
+			}
+			stored, err := db.GetRecordRoutineByID(record.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := stored.RecordRoutineItems[1].RecordExerciseItems[0].Skipped; got != skip {
+				t.Errorf("skipped = %v, want %v", got, skip)
+			}
+		}
+
+		if err := db.FinishRecordRoutine(record); err != nil {
+			t.Fatal(err)
+		}
+		if err := db.SkipRecordExercise(record, id, true); err == nil {
+			t.Error("skipped an exercise of a finished workout")
+		}
+	})
+}
+
+func TestMoveRecordRoutineItem(t *testing.T) {
+	forEachMigratedDB(t, func(t *testing.T, db *Database) {
+		record := startSession(t, db)
+		exercises := func() string {
+			t.Helper()
+			stored, err := db.GetRecordRoutineByID(record.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			record = stored
+
+			var s string
+			for i, item := range record.RecordRoutineItems {
+				if item.OrderIndex != i {
+					t.Errorf("item %d has index %d", i, item.OrderIndex)
+				}
+				s += item.RecordExerciseItems[0].ExerciseID + " "
+			}
+			return s
+		}
+
+		if err := db.MoveRecordRoutineItem(record, record.RecordRoutineItems[2].ID, true); err != nil {
+			t.Fatal(err)
+		}
+		if got, want := exercises(), "Barbell_Squat Pullups Barbell_Bench_Press "; got != want {
+			t.Errorf("order = %q, want %q", got, want)
+		}
+
+		if err := db.MoveRecordRoutineItem(record, record.RecordRoutineItems[2].ID, false); err == nil {
+			t.Error("moved the last item further down")
+		}
+
+		// Items with completed sets stay where they are
+		completed := time.Now()
+		set := record.RecordRoutineItems[0].RecordExerciseItems[0].RecordSets[0]
+		if err := db.Model(&set).Update("completed_at", &completed).Error; err != nil {
+			t.Fatal(err)
+		}
+		exercises()
+		if err := db.MoveRecordRoutineItem(record, record.RecordRoutineItems[1].ID, true); err == nil {
+			t.Error("moved an item above a started one")
+		}
+		if got, want := exercises(), "Barbell_Squat Pullups Barbell_Bench_Press "; got != want {
+			t.Errorf("order = %q after a refused move, want %q", got, want)
+		}
+	})
+}
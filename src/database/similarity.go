@@ -0,0 +1,186 @@
+package database
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Weights of the exercise attributes when ranking substitutes
+const (
+	weightPrimaryMuscles   = 0.40
+	weightSecondaryMuscles = 0.10
+	weightForce            = 0.15
+	weightMechanic         = 0.10
+	weightCategory         = 0.10
+	weightEquipment        = 0.10
+	weightLevel            = 0.05
+)
+
+// MaxAlternatives is the default number of substitutes suggested for an exercise
+const MaxAlternatives = 10
+
+type ExerciseAlternative struct {
+	Exercise
+	Score float64 `json:"score"` // From 0 to 1
+}
+
+// Match is the score as a percentage
+func (a ExerciseAlternative) Match() int {
+	return int(math.Round(a.Score * 100))
+}
+
+// splitList parses comma-separated attributes like muscles and equipment
+func splitList(s *string) []string {
+	if s == nil {
+		return nil
+	}
+
+	var items []string
+	for _, item := range strings.Split(*s, ",") {
+		item = strings.ToLower(strings.TrimSpace(item))
+		if item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func jaccard(a, b []string) float64 {
+	if len(a) == 0 && len(b) == 0 {
+		return 1
+	}
+
+	set := map[string]bool{}
+	for _, item := range a {
+		set[item] = true
+	}
+
+	common := 0
+	union := len(set)
+	for _, item := range b {
+		if set[item] {
+			common++
+		} else {
+			union++
+		}
+	}
+	return float64(common) / float64(union)
+}
+
+func sameAttribute(a, b *string) float64 {
+	if a == nil || b == nil {
+		if a == nil && b == nil {
+			return 1
+		}
+		return 0
+	}
+	if strings.EqualFold(*a, *b) {
+		return 1
+	}
+	return 0
+}
+
+// exerciseSimilarity scores from 0 to 1 how well b can replace a. Exercises
+// not sharing any primary muscle are not substitutes and score 0.
+func exerciseSimilarity(a, b *Exercise) float64 {
+	musclesA, musclesB := splitList(a.PrimaryMuscles), splitList(b.PrimaryMuscles)
+	if len(musclesA) == 0 || len(musclesB) == 0 {
+		return 0
+	}
+
+	primary := jaccard(musclesA, musclesB)
+	if primary == 0 {
+		return 0
+	}
+
+	return weightPrimaryMuscles*primary +
+		weightSecondaryMuscles*jaccard(splitList(a.SecondaryMuscles), splitList(b.SecondaryMuscles)) +
+		weightForce*sameAttribute(a.Force, b.Force) +
+		weightMechanic*sameAttribute(a.Mechanic, b.Mechanic) +
+		weightCategory*sameAttribute(&a.Category, &b.Category) +
+		weightEquipment*sameAttribute(a.Equipment, b.Equipment) +
+		weightLevel*sameAttribute(&a.Level, &b.Level)
+}
+
+// AvailableEquipment lists the equipment types the user has, nil if not restricted
+func (inv *EquipmentInventory) AvailableEquipment() []string {
+	return splitList(&inv.Available)
+}
+
+// HasEquipment tells whether an exercise can be performed with the available
+// equipment. Exercises without equipment are always available.
+func (inv *EquipmentInventory) HasEquipment(equipment *string) bool {
+	return equipmentAllowed(inv.AvailableEquipment(), equipment)
+}
+
+// IsAvailable is HasEquipment for templates
+func (inv *EquipmentInventory) IsAvailable(equipment string) bool {
+	return inv.HasEquipment(&equipment)
+}
+
+// equipmentAllowed checks an exercise's equipment against a list, an empty list allows everything
+func equipmentAllowed(available []string, equipment *string) bool {
+	if len(available) == 0 || equipment == nil {
+		return true
+	}
+
+	e := strings.ToLower(*equipment)
+	return e == equipmentBodyOnly || slices.Contains(available, e)
+}
+
+// RankAlternatives returns the exercises that can best replace the given one,
+// most similar first, limited to the available equipment if not empty.
+func RankAlternatives(exercise *Exercise, exercises []Exercise, available []string, limit int) []ExerciseAlternative {
+	var alternatives []ExerciseAlternative
+	for i := range exercises {
+		candidate := &exercises[i]
+		if candidate.ID == exercise.ID || !equipmentAllowed(available, candidate.Equipment) {
+			continue
+		}
+
+		if score := exerciseSimilarity(exercise, candidate); score > 0 {
+			alternatives = append(alternatives, ExerciseAlternative{Exercise: *candidate, Score: score})
+		}
+	}
+
+	sort.SliceStable(alternatives, func(i, j int) bool {
+		if alternatives[i].Score != alternatives[j].Score {
+			return alternatives[i].Score > alternatives[j].Score
+		}
+		return alternatives[i].Name < alternatives[j].Name
+	})
+
+	if limit > 0 && len(alternatives) > limit {
+		alternatives = alternatives[:limit]
+	}
+	return alternatives
+}
+
+// GetExerciseAlternatives ranks the substitutes of an exercise from the whole
+// catalog, see RankAlternatives.
+func (db *Database) GetExerciseAlternatives(exercise *Exercise, available []string, limit int) ([]ExerciseAlternative, error) {
+	exercises, err := db.GetExercises()
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve exercises: %w", err)
+	}
+
+	return RankAlternatives(exercise, exercises, available, limit), nil
+}
+
+// GetEquipmentTypes lists the equipment used by the exercises of the catalog
+func (db *Database) GetEquipmentTypes() ([]string, error) {
+	var types []string
+	err := db.Model(&Exercise{}).
+		Where("equipment IS NOT NULL AND equipment <> ''").
+		Distinct().
+		Order("equipment").
+		Pluck("equipment", &types).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve equipment types: %w", err)
+	}
+
+	return types, nil
+}
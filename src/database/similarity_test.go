@@ -0,0 +1,113 @@
+package database
+
+import (
+	"math"
+	"slices"
+	"testing"
+)
+
+func TestJaccard(t *testing.T) {
+	tests := []struct {
+		a, b []string
+		want float64
+	}{
+		{nil, nil, 1},
+		{[]string{"chest"}, nil, 0},
+		{[]string{"chest"}, []string{"chest"}, 1},
+		{[]string{"chest", "triceps"}, []string{"chest", "shoulders"}, 1.0 / 3},
+		{[]string{"lats"}, []string{"chest"}, 0},
+	}
+	for _, tt := range tests {
+		if got := jaccard(tt.a, tt.b); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("jaccard(%v, %v) = %g, want %g", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestEquipmentAllowed(t *testing.T) {
+	tests := []struct {
+		available []string
+		equipment *string
+		want      bool
+	}{
+		{nil, ptr("barbell"), true},
+		{[]string{"dumbbell"}, nil, true},
+		{[]string{"dumbbell"}, ptr("Dumbbell"), true},
+		{[]string{"dumbbell"}, ptr("barbell"), false},
+		{[]string{"dumbbell"}, ptr(equipmentBodyOnly), true},
+	}
+	for _, tt := range tests {
+		if got := equipmentAllowed(tt.available, tt.equipment); got != tt.want {
+			t.Errorf("equipmentAllowed(%v, %v) = %v, want %v", tt.available, optionalString(tt.equipment), got, tt.want)
+		}
+	}
+}
+
+func optionalString(s *string) string {
+	if s == nil {
+		return "<nil>"
+	}
+	return *s
+}
+
+func TestRankAlternatives(t *testing.T) {
+	exercise := func(id, equipment, mechanic, primary, secondary string) Exercise {
+		return Exercise{
+			ID:               id,
+			Name:             id,
+			Level:            "beginner",
+			Category:         "strength",
+			Force:            ptr("push"),
+			Mechanic:         ptr(mechanic),
+			Equipment:        ptr(equipment),
+			PrimaryMuscles:   ptr(primary),
+			SecondaryMuscles: ptr(secondary),
+		}
+	}
+	bench := exercise("Bench", "barbell", "compound", "chest", "shoulders, triceps")
+	catalog := []Exercise{
+		bench,
+		exercise("Dumbbell_Bench", "dumbbell", "compound", "chest", "shoulders, triceps"),
+		exercise("Close_Grip_Bench", "barbell", "compound", "triceps", "chest, shoulders"),
+		exercise("Push_Up", equipmentBodyOnly, "compound", "chest", "shoulders, triceps"),
+		exercise("Fly", "dumbbell", "isolation", "chest", ""),
+		exercise("Floor_Press", "barbell", "compound", "chest", "shoulders, triceps"),
+		exercise("Squat", "barbell", "compound", "quadriceps", "glutes"),
+	}
+
+	names := func(alternatives []ExerciseAlternative) []string {
+		var ids []string
+		for _, a := range alternatives {
+			ids = append(ids, a.ID)
+		}
+		return ids
+	}
+
+	tests := []struct {
+		name      string
+		available []string
+		limit     int
+		want      []string
+	}{
+		{"any equipment", nil, 0, []string{"Floor_Press", "Dumbbell_Bench", "Push_Up", "Fly"}},
+		{"limited", nil, 2, []string{"Floor_Press", "Dumbbell_Bench"}},
+		{"dumbbells only", []string{"dumbbell"}, 0, []string{"Dumbbell_Bench", "Push_Up", "Fly"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := RankAlternatives(&bench, catalog, tt.available, tt.limit)
+			if !slices.Equal(names(got), tt.want) {
+				t.Errorf("alternatives = %v, want %v", names(got), tt.want)
+			}
+			for i := 1; i < len(got); i++ {
+				if got[i].Score > got[i-1].Score {
+					t.Errorf("%s scores more than %s", got[i].ID, got[i-1].ID)
+				}
+			}
+		})
+	}
+
+	if got := RankAlternatives(&bench, catalog, nil, 1)[0]; got.Match() != 100 {
+		t.Errorf("match of an identical exercise = %d%%, want 100%%", got.Match())
+	}
+}
@@ -0,0 +1,241 @@
+package database
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// maxAssistance is the heaviest assistance (negative load) accepted for bodyweight exercises, in kg
+const maxAssistance = 200
+
+// PersonalRecord is the best performance recorded for an exercise; loads include body weight
+type PersonalRecord struct {
+	ExerciseID         string    `json:"exerciseId"`
+	ExerciseName       string    `json:"exerciseName"`
+	Bodyweight         bool      `json:"bodyweight"`
+	HeaviestLoad       float64   `json:"heaviestLoad"`       // In kg
+	EstimatedOneRepMax float64   `json:"estimatedOneRepMax"` // In kg
+	Reps               uint      `json:"reps"`               // Reps of the set the 1RM was estimated from
+	Date               time.Time `json:"date"`
+}
+
+// TrainingStats aggregates every finished workout
+type TrainingStats struct {
+	TotalVolume     float64          `json:"totalVolume"` // In kg
+	PersonalRecords []PersonalRecord `json:"personalRecords"`
+}
+
+// bodyWeights answers body weight lookups at a given date from the weight history
+type bodyWeights struct {
+	measurements []WeightMeasurement // Sorted by CreatedAt
+	fallback     *float64
+}
+
+func (db *Database) getBodyWeights() (*bodyWeights, error) {
+	bw := &bodyWeights{}
+	if err := db.Order("created_at").Find(&bw.measurements).Error; err != nil {
+		return nil, err
+	}
+
+	user, err := db.GetUserByID(1)
+	if err == nil {
+		bw.fallback = user.Weight
+	}
+
+	return bw, nil
+}
+
+// At returns the latest body weight measured before t, the first one after it
+// if there is none, or the profile weight as a last resort.
+func (bw *bodyWeights) At(t time.Time) float64 {
+	i := sort.Search(len(bw.measurements), func(i int) bool {
+		return bw.measurements[i].CreatedAt.After(t)
+	})
+	if i > 0 {
+		return bw.measurements[i-1].Weight
+	}
+	if len(bw.measurements) > 0 {
+		return bw.measurements[0].Weight
+	}
+	if bw.fallback != nil {
+		return *bw.fallback
+	}
+	return 0
+}
+
+// GetBodyWeightAt returns the user's body weight at a given date in kg
+func (db *Database) GetBodyWeightAt(t time.Time) (float64, error) {
+	bw, err := db.getBodyWeights()
+	if err != nil {
+		return 0, err
+	}
+	return bw.At(t), nil
+}
+
+// IsBodyweight reports whether the exercise moves the user's own body,
+// in which case set weights are added (or assisted, if negative) load.
+func (e Exercise) IsBodyweight() bool {
+	return e.Equipment != nil && *e.Equipment == equipmentBodyOnly
+}
+
+// validLoad checks a set weight in kg; bodyweight exercises also accept assistance
+func validLoad(weight float64, bodyweight bool) bool {
+	if bodyweight {
+		return weight != 0 && weight >= -maxAssistance && weight <= 300
+	}
+	return weight > 0 && weight <= 300
+}
+
+// EffectiveLoad returns the actual load lifted in a set, including body weight
+func EffectiveLoad(exercise Exercise, weight *float64, bodyweight float64) float64 {
+	load := 0.0
+	if weight != nil {
+		load = *weight
+	}
+	if exercise.IsBodyweight() {
+		load += bodyweight
+	}
+	return math.Max(load, 0)
+}
+
+// EstimateOneRepMax uses the Epley formula
+func EstimateOneRepMax(load float64, reps uint) float64 {
+	if reps == 0 {
+		return 0
+	}
+	if reps == 1 {
+		return load
+	}
+	return load * (1 + float64(reps)/30)
+}
+
+func (db *Database) getExerciseForItem(exerciseItemID uint) (*Exercise, error) {
+	var exercise Exercise
+	err := db.
+		Joins("JOIN exercise_items ON exercise_items.exercise_id = exercises.id").
+		Where("exercise_items.id = ?", exerciseItemID).
+		First(&exercise).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &exercise, nil
+}
+
+// getRecordExercise returns the exercise of a record; exercises missing from
+// the catalog are treated as loaded exercises.
+func (db *Database) getRecordExercise(rei *RecordExerciseItem) (*Exercise, error) {
+	var exercise Exercise
+	err := db.Where("id = ?", rei.ExerciseID).Limit(1).Find(&exercise).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &exercise, nil
+}
+
+func (db *Database) getFinishedRecordRoutines() ([]RecordRoutine, error) {
+	var records []RecordRoutine
+	err := db.
+		Preload("RecordRoutineItems").
+		Preload("RecordRoutineItems.RecordExerciseItems").
+		Preload("RecordRoutineItems.RecordExerciseItems.Exercise").
+		Preload("RecordRoutineItems.RecordExerciseItems.RecordSets").
+		Where("duration IS NOT NULL").
+		Order("created_at").
+		Find(&records).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return records, nil
+}
+
+// recordVolume sums load × reps over every set of a record
+func recordVolume(record *RecordRoutine, bodyweight float64) (volume float64) {
+	for _, rri := range record.RecordRoutineItems {
+		for _, rei := range rri.RecordExerciseItems {
+			for _, set := range rei.RecordSets {
+				if set.Reps == nil {
+					continue
+				}
+				volume += EffectiveLoad(rei.Exercise, set.Weight, bodyweight) * float64(*set.Reps)
+			}
+		}
+	}
+	return
+}
+
+// GetRecordRoutineVolume returns the tonnage of a workout in kg
+func (db *Database) GetRecordRoutineVolume(record *RecordRoutine) (float64, error) {
+	bodyweight, err := db.GetBodyWeightAt(record.CreatedAt)
+	if err != nil {
+		return 0, fmt.Errorf("failed to retrieve body weight: %w", err)
+	}
+
+	return recordVolume(record, bodyweight), nil
+}
+
+func (db *Database) GetTrainingStats() (*TrainingStats, error) {
+	records, err := db.getFinishedRecordRoutines()
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve workouts: %w", err)
+	}
+
+	bw, err := db.getBodyWeights()
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve body weight: %w", err)
+	}
+
+	stats := &TrainingStats{PersonalRecords: []PersonalRecord{}}
+	prs := map[string]*PersonalRecord{}
+
+	for i := range records {
+		bodyweight := bw.At(records[i].CreatedAt)
+		stats.TotalVolume += recordVolume(&records[i], bodyweight)
+
+		for _, rri := range records[i].RecordRoutineItems {
+			for _, rei := range rri.RecordExerciseItems {
+				exercise := rei.Exercise
+				for _, set := range rei.RecordSets {
+					if set.Reps == nil || *set.Reps == 0 {
+						continue
+					}
+
+					load := EffectiveLoad(exercise, set.Weight, bodyweight)
+					if load == 0 {
+						continue
+					}
+
+					pr, ok := prs[rei.ExerciseID]
+					if !ok {
+						pr = &PersonalRecord{
+							ExerciseID:   rei.ExerciseID,
+							ExerciseName: rei.ExerciseName,
+							Bodyweight:   exercise.IsBodyweight(),
+						}
+						prs[rei.ExerciseID] = pr
+					}
+
+					pr.HeaviestLoad = math.Max(pr.HeaviestLoad, load)
+					if e1rm := EstimateOneRepMax(load, *set.Reps); e1rm > pr.EstimatedOneRepMax {
+						pr.EstimatedOneRepMax = e1rm
+						pr.Reps = *set.Reps
+						pr.Date = records[i].CreatedAt
+					}
+				}
+			}
+		}
+	}
+
+	for _, pr := range prs {
+		stats.PersonalRecords = append(stats.PersonalRecords, *pr)
+	}
+	sort.Slice(stats.PersonalRecords, func(i, j int) bool {
+		return stats.PersonalRecords[i].ExerciseName < stats.PersonalRecords[j].ExerciseName
+	})
+
+	return stats, nil
+}
@@ -0,0 +1,84 @@
+package database
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestEffectiveLoad(t *testing.T) {
+	barbell := Exercise{Equipment: ptr(equipmentBarbell)}
+	bodyweight := Exercise{Equipment: ptr(equipmentBodyOnly)}
+
+	tests := []struct {
+		name     string
+		exercise Exercise
+		weight   *float64
+		want     float64
+	}{
+		{"external load", barbell, ptr(100.0), 100},
+		{"body weight is ignored", barbell, nil, 0},
+		{"bodyweight only", bodyweight, nil, 80},
+		{"added load", bodyweight, ptr(20.0), 100},
+		{"assistance", bodyweight, ptr(-30.0), 50},
+		{"assistance heavier than the body", bodyweight, ptr(-100.0), 0},
+		{"no equipment", Exercise{}, ptr(10.0), 10},
+	}
+	for _, tt := range tests {
+		if got := EffectiveLoad(tt.exercise, tt.weight, 80); got != tt.want {
+			t.Errorf("%s: EffectiveLoad = %g, want %g", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestEstimateOneRepMax(t *testing.T) {
+	tests := []struct {
+		load float64
+		reps uint
+		want float64
+	}{
+		{100, 0, 0},
+		{100, 1, 100},
+		{100, 5, 116.66666666666667},
+		{100, 10, 133.33333333333334},
+		{60, 30, 120},
+	}
+	for _, tt := range tests {
+		if got := EstimateOneRepMax(tt.load, tt.reps); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("EstimateOneRepMax(%g, %d) = %g, want %g", tt.load, tt.reps, got, tt.want)
+		}
+	}
+}
+
+func TestBodyWeightsAt(t *testing.T) {
+	day := time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC)
+	bw := &bodyWeights{
+		measurements: []WeightMeasurement{
+			{Weight: 80, CreatedAt: day},
+			{Weight: 78, CreatedAt: day.AddDate(0, 0, 10)},
+		},
+		fallback: ptr(90.0),
+	}
+
+	tests := []struct {
+		at   time.Time
+		want float64
+	}{
+		{day.AddDate(0, 0, -5), 80}, // Before any measurement
+		{day.AddDate(0, 0, 5), 80},
+		{day.AddDate(0, 0, 10), 78},
+		{day.AddDate(0, 1, 0), 78},
+	}
+	for _, tt := range tests {
+		if got := bw.At(tt.at); got != tt.want {
+			t.Errorf("body weight at %s = %g, want %g", tt.at.Format(time.DateOnly), got, tt.want)
+		}
+	}
+
+	if got := (&bodyWeights{fallback: ptr(90.0)}).At(day); got != 90 {
+		t.Errorf("body weight without measurements = %g, want the profile weight", got)
+	}
+	if got := (&bodyWeights{}).At(day); got != 0 {
+		t.Errorf("body weight without any data = %g, want 0", got)
+	}
+}
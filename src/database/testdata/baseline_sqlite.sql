@@ -0,0 +1,31 @@
+-- Schema created by the app before versioned migrations, with a workout
+-- recorded from a routine that is still referenced by it
+CREATE TABLE `days` (`id` integer PRIMARY KEY AUTOINCREMENT,`name` text NOT NULL);
+CREATE UNIQUE INDEX `idx_days_name` ON `days`(`name`);
+CREATE TABLE `users` (`id` integer PRIMARY KEY AUTOINCREMENT,`name` text,`is_female` numeric,`height` real,`weight` real,`birth_date` datetime,`created_at` datetime,`updated_at` datetime,`deleted_at` datetime);
+CREATE INDEX `idx_users_deleted_at` ON `users`(`deleted_at`);
+CREATE TABLE `height_measurements` (`id` integer PRIMARY KEY AUTOINCREMENT,`height` real,`created_at` datetime);
+CREATE TABLE `weight_measurements` (`id` integer PRIMARY KEY AUTOINCREMENT,`weight` real,`created_at` datetime);
+CREATE TABLE `exercises` (`id` text,`name` text NOT NULL,`level` text NOT NULL,`category` text NOT NULL,`force` text,`mechanic` text,`equipment` text,`instructions_string` text,`primary_muscles` text,`secondary_muscles` text,`created_at` datetime,`updated_at` datetime,PRIMARY KEY (`id`));
+CREATE UNIQUE INDEX `idx_exercises_name` ON `exercises`(`name`);
+CREATE TABLE `routines` (`id` integer PRIMARY KEY AUTOINCREMENT,`name` text NOT NULL,`description` text,`created_at` datetime,`updated_at` datetime);
+CREATE TABLE `routine_days` (`routine_id` integer,`day_id` integer,PRIMARY KEY (`routine_id`,`day_id`),CONSTRAINT `fk_routine_days_day` FOREIGN KEY (`day_id`) REFERENCES `days`(`id`) ON DELETE CASCADE,CONSTRAINT `fk_routine_days_routine` FOREIGN KEY (`routine_id`) REFERENCES `routines`(`id`) ON DELETE CASCADE);
+CREATE TABLE `routine_items` (`id` integer PRIMARY KEY AUTOINCREMENT,`routine_id` integer NOT NULL,`order_index` integer NOT NULL DEFAULT 0,`created_at` datetime,`updated_at` datetime,CONSTRAINT `fk_routines_routine_items` FOREIGN KEY (`routine_id`) REFERENCES `routines`(`id`) ON DELETE CASCADE);
+CREATE TABLE `exercise_items` (`id` integer PRIMARY KEY AUTOINCREMENT,`routine_item_id` integer NOT NULL,`exercise_id` text NOT NULL,`rest_time` integer NOT NULL DEFAULT 0,`notes` text,`order_index` integer NOT NULL DEFAULT 0,`created_at` datetime,`updated_at` datetime,CONSTRAINT `fk_exercise_items_exercise` FOREIGN KEY (`exercise_id`) REFERENCES `exercises`(`id`) ON DELETE CASCADE,CONSTRAINT `fk_routine_items_exercise_items` FOREIGN KEY (`routine_item_id`) REFERENCES `routine_items`(`id`) ON DELETE CASCADE);
+CREATE UNIQUE INDEX `idx_routineitem_exercise` ON `exercise_items`(`routine_item_id`,`exercise_id`);
+CREATE TABLE `sets` (`id` integer PRIMARY KEY AUTOINCREMENT,`exercise_item_id` integer NOT NULL,`reps` integer,`weight` real,`duration` integer,`created_at` datetime,`updated_at` datetime,CONSTRAINT `fk_exercise_items_sets` FOREIGN KEY (`exercise_item_id`) REFERENCES `exercise_items`(`id`) ON DELETE CASCADE);
+CREATE TABLE `record_routines` (`id` integer PRIMARY KEY AUTOINCREMENT,`routine_id` integer NOT NULL,`duration` integer,`created_at` datetime,`updated_at` datetime,CONSTRAINT `fk_record_routines_routine` FOREIGN KEY (`routine_id`) REFERENCES `routines`(`id`) ON DELETE CASCADE);
+CREATE TABLE `record_routine_items` (`id` integer PRIMARY KEY AUTOINCREMENT,`record_routine_id` integer NOT NULL,`routine_item_id` integer NOT NULL,`order_index` integer NOT NULL DEFAULT 0,`created_at` datetime,`updated_at` datetime,CONSTRAINT `fk_record_routine_items_routine_item` FOREIGN KEY (`routine_item_id`) REFERENCES `routine_items`(`id`) ON DELETE CASCADE,CONSTRAINT `fk_record_routines_record_routine_items` FOREIGN KEY (`record_routine_id`) REFERENCES `record_routines`(`id`) ON DELETE CASCADE);
+CREATE TABLE `record_exercise_items` (`id` integer PRIMARY KEY AUTOINCREMENT,`record_routine_item_id` integer NOT NULL,`exercise_item_id` integer NOT NULL,`rest_time` integer NOT NULL DEFAULT 0,`notes` text,`order_index` integer NOT NULL DEFAULT 0,`created_at` datetime,`updated_at` datetime,CONSTRAINT `fk_record_routine_items_record_exercise_items` FOREIGN KEY (`record_routine_item_id`) REFERENCES `record_routine_items`(`id`) ON DELETE CASCADE,CONSTRAINT `fk_record_exercise_items_exercise_item` FOREIGN KEY (`exercise_item_id`) REFERENCES `exercise_items`(`id`) ON DELETE CASCADE);
+CREATE TABLE `record_sets` (`id` integer PRIMARY KEY AUTOINCREMENT,`record_exercise_item_id` integer NOT NULL,`set_id` integer NOT NULL,`reps` integer,`weight` real,`duration` integer,`completed_at` datetime,`order_index` integer NOT NULL DEFAULT 0,`created_at` datetime,`updated_at` datetime,CONSTRAINT `fk_record_sets_set` FOREIGN KEY (`set_id`) REFERENCES `sets`(`id`) ON DELETE CASCADE,CONSTRAINT `fk_record_exercise_items_record_sets` FOREIGN KEY (`record_exercise_item_id`) REFERENCES `record_exercise_items`(`id`) ON DELETE CASCADE);
+
+INSERT INTO users (id, name) VALUES (1, 'User');
+INSERT INTO exercises (id, name, level, category) VALUES ('Barbell_Squat', 'Barbell Squat', 'beginner', 'strength');
+INSERT INTO routines (id, name) VALUES (1, 'Legs');
+INSERT INTO routine_items (id, routine_id) VALUES (1, 1);
+INSERT INTO exercise_items (id, routine_item_id, exercise_id) VALUES (1, 1, 'Barbell_Squat');
+INSERT INTO sets (id, exercise_item_id, reps, weight) VALUES (1, 1, 5, 100);
+INSERT INTO record_routines (id, routine_id, duration) VALUES (1, 1, 3600);
+INSERT INTO record_routine_items (id, record_routine_id, routine_item_id) VALUES (1, 1, 1);
+INSERT INTO record_exercise_items (id, record_routine_item_id, exercise_item_id) VALUES (1, 1, 1);
+INSERT INTO record_sets (id, record_exercise_item_id, set_id, reps, weight) VALUES (1, 1, 1, 4, 100);
@@ -0,0 +1,243 @@
+package database
+
+import (
+	"fmt"
+	"math"
+)
+
+const (
+	dimensionPercent = "percent"
+	dimensionLength  = "length"
+	dimensionMass    = "mass"
+)
+
+type unit struct {
+	Dimension string
+	Factor    float64 // Multiplier to the canonical unit of the dimension
+}
+
+// units maps every supported unit to its dimension; canonical units are %, cm and kg
+var units = map[string]unit{
+	"%":  {Dimension: dimensionPercent, Factor: 1},
+	"cm": {Dimension: dimensionLength, Factor: 1},
+	"mm": {Dimension: dimensionLength, Factor: 0.1},
+	"in": {Dimension: dimensionLength, Factor: 2.54},
+	"kg": {Dimension: dimensionMass, Factor: 1},
+	"lb": {Dimension: dimensionMass, Factor: 0.45359237},
+}
+
+// unitOrder is the order in which units are presented to the user
+var unitOrder = []string{"%", "cm", "mm", "in", "kg", "lb"}
+
+func (db *Database) GetUnits() []string {
+	return unitOrder
+}
+
+func isValidUnit(u string) bool {
+	_, ok := units[u]
+	return ok
+}
+
+func sameDimension(a, b string) bool {
+	return units[a].Dimension == units[b].Dimension
+}
+
+// ToCanonical converts a value expressed in unit u to the canonical unit of its dimension
+func ToCanonical(value float64, u string) float64 {
+	def, ok := units[u]
+	if !ok {
+		return value
+	}
+	return value * def.Factor
+}
+
+// FromCanonical converts a value expressed in the canonical unit to unit u
+func FromCanonical(value float64, u string) float64 {
+	def, ok := units[u]
+	if !ok {
+		return value
+	}
+	return value / def.Factor
+}
+
+// FormatValue rounds a value to two decimals and appends its unit
+func FormatValue(value float64, u string) string {
+	rounded := math.Round(value*100) / 100
+	if u == "%" {
+		return fmt.Sprintf("%g%%", rounded)
+	}
+	return fmt.Sprintf("%g %s", rounded, u)
+}
+
+const lbPlateIncrement = 2.5
+
+// Units holds the units a user wants to see and enter values in
+type Units struct {
+	Weight string `json:"weight"`
+	Length string `json:"length"`
+}
+
+// MetricUnits is used when no user preference is available
+var MetricUnits = Units{Weight: "kg", Length: "cm"}
+
+func (u *User) Units() Units {
+	p := MetricUnits
+	if u == nil {
+		return p
+	}
+	if u.WeightUnit == "lb" {
+		p.Weight = "lb"
+	}
+	if u.LengthUnit == "in" {
+		p.Length = "in"
+	}
+	return p
+}
+
+// RoundLoad rounds a load to a value that can be built with common plates;
+// kg loads are kept as they are since plates come in 0.5 kg pairs or less.
+func RoundLoad(value float64, u string) float64 {
+	if u == "lb" {
+		return math.Round(value/lbPlateIncrement) * lbPlateIncrement
+	}
+	return math.Round(value*100) / 100
+}
+
+func roundTenth(value float64) float64 {
+	return math.Round(value*10) / 10
+}
+
+// LoadFromKg converts a lifted weight to the preferred unit as it is
+func (p Units) LoadFromKg(kg float64) float64 {
+	return FromCanonical(kg, p.Weight)
+}
+
+// LoadToKg converts a lifted weight in the preferred unit to kg as it is
+func (p Units) LoadToKg(value float64) float64 {
+	return ToCanonical(value, p.Weight)
+}
+
+// PlannedLoadFromKg converts a planned or suggested weight to the preferred unit, rounded to plates
+func (p Units) PlannedLoadFromKg(kg float64) float64 {
+	return RoundLoad(FromCanonical(kg, p.Weight), p.Weight)
+}
+
+// PlannedLoadToKg converts a planned weight in the preferred unit to kg, rounded to plates
+func (p Units) PlannedLoadToKg(value float64) float64 {
+	return ToCanonical(RoundLoad(value, p.Weight), p.Weight)
+}
+
+// WeightFromKg converts a body weight to the preferred unit
+func (p Units) WeightFromKg(kg float64) float64 {
+	return roundTenth(FromCanonical(kg, p.Weight))
+}
+
+// WeightToKg converts a body weight in the preferred unit to kg
+func (p Units) WeightToKg(value float64) float64 {
+	return ToCanonical(value, p.Weight)
+}
+
+// LengthFromCm converts a length to the preferred unit
+func (p Units) LengthFromCm(cm float64) float64 {
+	return roundTenth(FromCanonical(cm, p.Length))
+}
+
+// LengthToCm converts a length in the preferred unit to cm
+func (p Units) LengthToCm(value float64) float64 {
+	return ToCanonical(value, p.Length)
+}
+
+func convertPointer(v *float64, f func(float64) float64) *float64 {
+	if v == nil {
+		return nil
+	}
+	c := f(*v)
+	return &c
+}
+
+func convertUser(u *User, weight, length func(float64) float64) {
+	u.Weight = convertPointer(u.Weight, weight)
+	u.Height = convertPointer(u.Height, length)
+}
+
+func convertRoutine(r *Routine, load func(float64) float64) {
+	for i := range r.RoutineItems {
+		for j := range r.RoutineItems[i].ExerciseItems {
+			sets := r.RoutineItems[i].ExerciseItems[j].Sets
+			for k := range sets {
+				sets[k].Weight = convertPointer(sets[k].Weight, load)
+			}
+		}
+	}
+}
+
+// convertRecordRoutine converts the recorded weights with load and the planned ones with planned
+func convertRecordRoutine(r *RecordRoutine, load, planned func(float64) float64) {
+	if r.Routine != nil {
+		convertRoutine(r.Routine, planned)
+	}
+	for i := range r.RecordRoutineItems {
+		for j := range r.RecordRoutineItems[i].RecordExerciseItems {
+			sets := r.RecordRoutineItems[i].RecordExerciseItems[j].RecordSets
+			for k := range sets {
+				sets[k].Weight = convertPointer(sets[k].Weight, load)
+				sets[k].PlannedWeight = convertPointer(sets[k].PlannedWeight, planned)
+				if sets[k].Set != nil {
+					sets[k].Set.Weight = convertPointer(sets[k].Set.Weight, planned)
+				}
+			}
+		}
+	}
+}
+
+// BodyMetricFromCanonical converts the measurements of a metric to its unit in place
+func BodyMetricFromCanonical(m *BodyMetric) {
+	for i := range m.BodyMeasurements {
+		m.BodyMeasurements[i].Value = FromCanonical(m.BodyMeasurements[i].Value, m.Unit)
+	}
+	if m.Latest != nil {
+		m.Latest.Value = FromCanonical(m.Latest.Value, m.Unit)
+	}
+}
+
+// UserFromMetric converts the user's measurements to the preferred units in place
+func (p Units) UserFromMetric(u *User) {
+	convertUser(u, p.WeightFromKg, p.LengthFromCm)
+}
+
+// UserToMetric converts the user's measurements from the preferred units in place
+func (p Units) UserToMetric(u *User) {
+	convertUser(u, p.WeightToKg, p.LengthToCm)
+}
+
+// RoutineFromMetric converts every planned weight to the preferred unit in place
+func (p Units) RoutineFromMetric(r *Routine) {
+	convertRoutine(r, p.PlannedLoadFromKg)
+}
+
+// RoutineToMetric converts every planned weight from the preferred unit in place
+func (p Units) RoutineToMetric(r *Routine) {
+	convertRoutine(r, p.PlannedLoadToKg)
+}
+
+// SnapshotFromMetric converts every weight of a routine version to the preferred unit in place
+func (p Units) SnapshotFromMetric(s *RoutineSnapshot) {
+	for i := range s.Items {
+		for j := range s.Items[i].Exercises {
+			sets := s.Items[i].Exercises[j].Sets
+			for k := range sets {
+				sets[k].Weight = convertPointer(sets[k].Weight, p.PlannedLoadFromKg)
+			}
+		}
+	}
+}
+
+// RecordRoutineFromMetric converts every recorded weight to the preferred unit in place
+func (p Units) RecordRoutineFromMetric(r *RecordRoutine) {
+	convertRecordRoutine(r, p.LoadFromKg, p.PlannedLoadFromKg)
+}
+
+// RecordRoutineToMetric converts every recorded weight from the preferred unit in place
+func (p Units) RecordRoutineToMetric(r *RecordRoutine) {
+	convertRecordRoutine(r, p.LoadToKg, p.PlannedLoadToKg)
+}
@@ -0,0 +1,94 @@
+package database
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCanonicalConversion(t *testing.T) {
+	tests := []struct {
+		value     float64
+		unit      string
+		canonical float64
+	}{
+		{10, "cm", 10},
+		{10, "mm", 1},
+		{10, "in", 25.4},
+		{100, "lb", 45.359237},
+		{20, "%", 20},
+		{5, "furlong", 5}, // Unknown units are left as they are
+	}
+	for _, tt := range tests {
+		if got := ToCanonical(tt.value, tt.unit); math.Abs(got-tt.canonical) > 1e-9 {
+			t.Errorf("ToCanonical(%g, %s) = %g, want %g", tt.value, tt.unit, got, tt.canonical)
+		}
+		if got := FromCanonical(tt.canonical, tt.unit); math.Abs(got-tt.value) > 1e-9 {
+			t.Errorf("FromCanonical(%g, %s) = %g, want %g", tt.canonical, tt.unit, got, tt.value)
+		}
+	}
+}
+
+func TestRoundLoad(t *testing.T) {
+	tests := []struct {
+		value float64
+		unit  string
+		want  float64
+	}{
+		{101.234, "kg", 101.23},
+		{220.46, "lb", 220},
+		{221.3, "lb", 222.5},
+		{1.2, "lb", 0},
+	}
+	for _, tt := range tests {
+		if got := RoundLoad(tt.value, tt.unit); got != tt.want {
+			t.Errorf("RoundLoad(%g, %s) = %g, want %g", tt.value, tt.unit, got, tt.want)
+		}
+	}
+}
+
+func TestUnitsLoads(t *testing.T) {
+	lb := Units{Weight: "lb", Length: "in"}
+
+	if got := lb.LoadFromKg(100); math.Abs(got-220.46226218487757) > 1e-9 {
+		t.Errorf("recorded 100 kg = %g lb, want 220.462...", got)
+	}
+	if got := lb.PlannedLoadFromKg(100); got != 220 {
+		t.Errorf("planned 100 kg = %g lb, want 220", got)
+	}
+
+	// Recorded weights survive a round trip
+	if got := lb.LoadFromKg(lb.LoadToKg(221.3)); math.Abs(got-221.3) > 1e-9 {
+		t.Errorf("221.3 lb through kg = %g lb", got)
+	}
+	if got := lb.PlannedLoadToKg(221.3); math.Abs(got-222.5*0.45359237) > 1e-9 {
+		t.Errorf("planned 221.3 lb = %g kg, want 222.5 lb", got)
+	}
+
+	if got := MetricUnits.PlannedLoadFromKg(101.234); got != 101.23 {
+		t.Errorf("planned 101.234 kg = %g, want 101.23", got)
+	}
+	if got := lb.WeightFromKg(80); got != 176.4 {
+		t.Errorf("body weight of 80 kg = %g lb, want 176.4", got)
+	}
+	if got := lb.LengthFromCm(180); got != 70.9 {
+		t.Errorf("height of 180 cm = %g in, want 70.9", got)
+	}
+}
+
+func TestRecordRoutineFromMetric(t *testing.T) {
+	r := testRecord()
+	sets := r.RecordRoutineItems[0].RecordExerciseItems[0].RecordSets
+	sets[0].PlannedWeight = ptr(100.0)
+	sets[0].Set = &Set{Weight: ptr(100.0)}
+
+	Units{Weight: "lb", Length: "in"}.RecordRoutineFromMetric(&r)
+	if got := *sets[0].Weight; math.Abs(got-220.46226218487757) > 1e-9 {
+		t.Errorf("recorded weight = %g lb, want 220.462...", got)
+	}
+	if got := *sets[0].PlannedWeight; got != 220 {
+		t.Errorf("planned weight = %g lb, want 220", got)
+	}
+	if got := *sets[0].Set.Weight; got != 220 {
+		t.Errorf("weight of the routine set = %g lb, want 220", got)
+	}
+}
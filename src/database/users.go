@@ -0,0 +1,222 @@
+package database
+
+import (
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+// Password hashes are stored as pbkdf2-sha256$<iterations>$<salt>$<key>
+const (
+	passwordScheme     = "pbkdf2-sha256"
+	passwordIterations = 600000
+	passwordSaltSize   = 16
+	passwordKeySize    = 32
+	minPasswordLength  = 8
+)
+
+// HashPassword derives a salted hash to store instead of the password
+func HashPassword(password string) (string, error) {
+	salt := make([]byte, passwordSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	key, err := pbkdf2.Key(sha256.New, password, salt, passwordIterations, passwordKeySize)
+	if err != nil {
+		return "", err
+	}
+
+	enc := base64.RawStdEncoding
+	return fmt.Sprintf("%s$%d$%s$%s", passwordScheme, passwordIterations, enc.EncodeToString(salt), enc.EncodeToString(key)), nil
+}
+
+// checkPassword compares a password with a hash made by HashPassword
+func checkPassword(hash, password string) bool {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 4 || parts[0] != passwordScheme {
+		return false
+	}
+
+	iterations, err := strconv.Atoi(parts[1])
+	if err != nil || iterations < 1 {
+		return false
+	}
+
+	enc := base64.RawStdEncoding
+	salt, err := enc.DecodeString(parts[2])
+	if err != nil {
+		return false
+	}
+	want, err := enc.DecodeString(parts[3])
+	if err != nil {
+		return false
+	}
+
+	got, err := pbkdf2.Key(sha256.New, password, salt, iterations, len(want))
+	if err != nil {
+		return false
+	}
+	return subtle.ConstantTimeCompare(got, want) == 1
+}
+
+// maxVerifiedPasswords bounds verifiedPasswords, which is emptied when full
+const maxVerifiedPasswords = 100
+
+// verifiedPasswords caches successful checks, as hashing is slow by design
+// and basic authentication sends the password with every request. It maps
+// stored hashes to the digest of the password that matched them, so it holds
+// one entry per user and a changed password doesn't match the old entry.
+var verifiedPasswords = struct {
+	sync.Mutex
+	digests map[string][32]byte
+}{digests: map[string][32]byte{}}
+
+// passwordVerified tells whether a password was already checked against a hash
+func passwordVerified(hash, password string) bool {
+	verifiedPasswords.Lock()
+	defer verifiedPasswords.Unlock()
+
+	digest, ok := verifiedPasswords.digests[hash]
+	want := sha256.Sum256([]byte(password))
+	return ok && subtle.ConstantTimeCompare(digest[:], want[:]) == 1
+}
+
+// rememberPassword caches a successful check
+func rememberPassword(hash, password string) {
+	verifiedPasswords.Lock()
+	defer verifiedPasswords.Unlock()
+
+	if len(verifiedPasswords.digests) >= maxVerifiedPasswords {
+		clear(verifiedPasswords.digests)
+	}
+	verifiedPasswords.digests[hash] = sha256.Sum256([]byte(password))
+}
+
+// forgetPassword drops the cached check of a hash that is no longer used
+func forgetPassword(hash string) {
+	verifiedPasswords.Lock()
+	defer verifiedPasswords.Unlock()
+
+	delete(verifiedPasswords.digests, hash)
+}
+
+// SetUserCredentials sets the username and password a user signs in with. An
+// empty password keeps the current one.
+func (db *Database) SetUserCredentials(user *User, username, password string) error {
+	username = strings.TrimSpace(username)
+	if username == "" || len(username) > 50 {
+		return fmt.Errorf("invalid username")
+	}
+
+	var taken int64
+	if err := db.Model(&User{}).Where("username = ? AND id <> ?", username, user.ID).Count(&taken).Error; err != nil {
+		return fmt.Errorf("failed to check username: %w", err)
+	}
+	if taken > 0 {
+		return fmt.Errorf("username %s is already taken", username)
+	}
+
+	updates := map[string]any{"username": username}
+	if password != "" {
+		if len(password) < minPasswordLength {
+			return fmt.Errorf("password must be at least %d characters long", minPasswordLength)
+		}
+		hash, err := HashPassword(password)
+		if err != nil {
+			return fmt.Errorf("failed to hash password: %w", err)
+		}
+		updates["password_hash"] = hash
+	} else if user.PasswordHash == "" {
+		return fmt.Errorf("password is required")
+	}
+
+	oldHash := user.PasswordHash
+	if err := db.Model(user).Updates(updates).Error; err != nil {
+		return fmt.Errorf("failed to save credentials: %w", err)
+	}
+	if password != "" {
+		forgetPassword(oldHash)
+	}
+
+	return nil
+}
+
+// CreateUser adds a user who signs in with the given credentials
+func (db *Database) CreateUser(name, username, password string) (*User, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		name = strings.TrimSpace(username)
+	}
+	if name == "" || len(name) > 50 {
+		return nil, fmt.Errorf("invalid name")
+	}
+	if password == "" {
+		return nil, fmt.Errorf("password is required")
+	}
+
+	user := &User{Name: name}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(user).Error; err != nil {
+			return fmt.Errorf("failed to create user: %w", err)
+		}
+		return (&Database{DB: tx, Config: db.Config}).SetUserCredentials(user, username, password)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// GetUserByUsername returns the user signing in with a username
+func (db *Database) GetUserByUsername(username string) (*User, error) {
+	var user User
+	err := db.First(&user, "username = ?", strings.TrimSpace(username)).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+// AuthenticateUser returns the user with the given credentials, nil if they
+// are wrong
+func (db *Database) AuthenticateUser(username, password string) (*User, error) {
+	var user User
+	err := db.Where("username = ? AND password_hash <> ''", username).Limit(1).Find(&user).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve user: %w", err)
+	}
+	if user.ID == 0 {
+		return nil, nil
+	}
+
+	if passwordVerified(user.PasswordHash, password) {
+		return &user, nil
+	}
+	if !checkPassword(user.PasswordHash, password) {
+		return nil, nil
+	}
+
+	rememberPassword(user.PasswordHash, password)
+	return &user, nil
+}
+
+// HasCredentials tells whether any user can sign in with a password
+func (db *Database) HasCredentials() (bool, error) {
+	var count int64
+	err := db.Model(&User{}).Where("username IS NOT NULL AND password_hash <> ''").Count(&count).Error
+	if err != nil {
+		return false, fmt.Errorf("failed to check credentials: %w", err)
+	}
+	return count > 0, nil
+}
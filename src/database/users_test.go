@@ -0,0 +1,119 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestHashPassword(t *testing.T) {
+	hash, err := HashPassword("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(hash, passwordScheme+"$") {
+		t.Errorf("hash = %q, want the %s scheme", hash, passwordScheme)
+	}
+
+	again, err := HashPassword("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hash == again {
+		t.Error("hashing a password twice gave the same hash, want a new salt each time")
+	}
+
+	tests := []struct {
+		name     string
+		hash     string
+		password string
+		want     bool
+	}{
+		{"right password", hash, "correct horse", true},
+		{"other salt", again, "correct horse", true},
+		{"wrong password", hash, "correct horse!", false},
+		{"empty password", hash, "", false},
+		{"empty hash", "", "correct horse", false},
+		{"other scheme", strings.Replace(hash, passwordScheme, "bcrypt", 1), "correct horse", false},
+		{"bad iterations", strings.Replace(hash, "$600000$", "$0$", 1), "correct horse", false},
+		{"missing key", hash[:strings.LastIndex(hash, "$")], "correct horse", false},
+		{"bad salt", strings.Replace(hash, "$600000$", "$600000$!", 1), "correct horse", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := checkPassword(tt.hash, tt.password); got != tt.want {
+				t.Errorf("checkPassword() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuthenticateUser(t *testing.T) {
+	forEachMigratedDB(t, func(t *testing.T, db *Database) {
+		if _, err := db.CreateUser("", "alice", "short"); err == nil {
+			t.Error("created a user with a short password")
+		}
+
+		user, err := db.CreateUser("", "alice", "correct horse")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := db.CreateUser("", " alice ", "correct horse"); err == nil {
+			t.Error("created two users with the same username")
+		}
+
+		tests := []struct {
+			username, password string
+			want               bool
+		}{
+			{"alice", "correct horse", true},
+			{"alice", "correct horse", true}, // Cached
+			{"alice", "wrong horse", false},
+			{"bob", "correct horse", false},
+		}
+		for _, tt := range tests {
+			got, err := db.AuthenticateUser(tt.username, tt.password)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if (got != nil) != tt.want || (got != nil && got.ID != user.ID) {
+				t.Errorf("AuthenticateUser(%q, %q) = %v, want success %v", tt.username, tt.password, got, tt.want)
+			}
+		}
+
+		// Changing the password invalidates the cached check
+		stored, err := db.GetUserByUsername("alice")
+		if err != nil {
+			t.Fatal(err)
+		}
+		hash := stored.PasswordHash
+		if !passwordVerified(hash, "correct horse") {
+			t.Error("the successful check was not cached")
+		}
+		if err := db.SetUserCredentials(stored, "alice", "battery staple"); err != nil {
+			t.Fatal(err)
+		}
+		if passwordVerified(hash, "correct horse") {
+			t.Error("the check of the old password is still cached")
+		}
+		if got, _ := db.AuthenticateUser("alice", "correct horse"); got != nil {
+			t.Error("the old password still works after changing it")
+		}
+		if got, _ := db.AuthenticateUser("alice", "battery staple"); got == nil {
+			t.Error("the new password doesn't work")
+		}
+	})
+}
+
+func TestVerifiedPasswordsAreBounded(t *testing.T) {
+	for i := range maxVerifiedPasswords + 10 {
+		rememberPassword(fmt.Sprintf("hash%d", i), "password")
+	}
+	if n := len(verifiedPasswords.digests); n > maxVerifiedPasswords {
+		t.Errorf("cached checks = %d, want at most %d", n, maxVerifiedPasswords)
+	}
+	last := fmt.Sprintf("hash%d", maxVerifiedPasswords+9)
+	if !passwordVerified(last, "password") || passwordVerified(last, "other") {
+		t.Error("the last check is not cached for its password only")
+	}
+}
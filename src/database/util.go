@@ -2,9 +2,11 @@ package database
 
 import (
 	"fmt"
+	"sort"
 	"time"
 
 	g "github.com/birabittoh/go-lift/src/globals"
+	"gorm.io/gorm"
 )
 
 func (db *Database) GetDays() []Day {
@@ -60,6 +62,20 @@ func (db *Database) UpdateUser(user *User) error {
 		return fmt.Errorf("invalid name")
 	}
 
+	if user.WeightUnit == "" {
+		user.WeightUnit = MetricUnits.Weight
+	}
+	if user.WeightUnit != "kg" && user.WeightUnit != "lb" {
+		return fmt.Errorf("invalid weight unit: %s", user.WeightUnit)
+	}
+
+	if user.LengthUnit == "" {
+		user.LengthUnit = MetricUnits.Length
+	}
+	if user.LengthUnit != "cm" && user.LengthUnit != "in" {
+		return fmt.Errorf("invalid length unit: %s", user.LengthUnit)
+	}
+
 	var nw *WeightMeasurement
 	var nh *HeightMeasurement
 
@@ -97,7 +113,9 @@ func (db *Database) UpdateUser(user *User) error {
 		}
 	}
 
-	return db.Save(user).Error
+	// The feed token and credentials are managed separately, see ResetFeedToken
+	// and SetUserCredentials
+	return db.Omit("FeedToken", "Username", "PasswordHash").Save(user).Error
 }
 
 func (db *Database) GetRoutines() ([]Routine, error) {
@@ -160,6 +178,10 @@ func (db *Database) NewRoutine(routine *Routine) error {
 		return fmt.Errorf("invalid routine name")
 	}
 
+	if err := db.snapRoutineLoads(routine); err != nil {
+		return err
+	}
+
 	if err := db.Create(routine).Error; err != nil {
 		return fmt.Errorf("failed to create routine: %w", err)
 	}
@@ -176,6 +198,10 @@ func (db *Database) UpdateRoutine(routine *Routine) error {
 		return fmt.Errorf("invalid routine name")
 	}
 
+	if err := db.snapRoutineLoads(routine); err != nil {
+		return err
+	}
+
 	if err := db.Save(routine).Error; err != nil {
 		return fmt.Errorf("failed to update routine: %w", err)
 	}
@@ -506,9 +532,22 @@ func (db *Database) UpdateSet(set *Set) error {
 		return fmt.Errorf("invalid reps value: %v", *set.Reps)
 	}
 
-	// Check if weight is valid
-	if set.Weight != nil && (*set.Weight <= 0 || *set.Weight > 300) {
-		return fmt.Errorf("invalid weight value: %v", *set.Weight)
+	// Check if weight is valid, bodyweight exercises accept assistance
+	if set.Weight != nil {
+		exercise, err := db.getExerciseForItem(set.ExerciseItemID)
+		if err != nil {
+			return fmt.Errorf("failed to retrieve exercise: %w", err)
+		}
+
+		inventory, err := db.userInventory()
+		if err != nil {
+			return err
+		}
+		*set.Weight = inventory.SnapLoad(*set.Weight, exercise.Equipment)
+
+		if !validLoad(*set.Weight, exercise.IsBodyweight()) {
+			return fmt.Errorf("invalid weight value: %v", *set.Weight)
+		}
 	}
 
 	// Check if duration is valid
@@ -551,32 +590,44 @@ func (db *Database) GetCurrentWorkout() *RecordRoutine {
 }
 
 func (db *Database) NewRecordRoutine(routine *Routine) (*RecordRoutine, error) {
+	if routine.IsTemplate {
+		return nil, fmt.Errorf("templates cannot be started, clone them first")
+	}
+
+	// Planned data is copied so that the record survives routine edits and deletion
 	record := &RecordRoutine{
-		RoutineID: routine.ID,
+		RoutineID:   &routine.ID,
+		RoutineName: routine.Name,
 	}
 
 	for _, ri := range routine.RoutineItems {
 		recordRoutineItem := RecordRoutineItem{
-			RoutineItemID: ri.ID,
+			RoutineItemID: &ri.ID,
 			OrderIndex:    ri.OrderIndex,
 		}
 
 		for _, ei := range ri.ExerciseItems {
 			recordExerciseItem := RecordExerciseItem{
-				ExerciseItemID:      ei.ID,
+				ExerciseItemID:      &ei.ID,
+				ExerciseID:          ei.ExerciseID,
+				ExerciseName:        ei.Exercise.Name,
 				RecordRoutineItemID: recordRoutineItem.ID,
 				RestTime:            ei.RestTime,
 				Notes:               ei.Notes,
 				OrderIndex:          ei.OrderIndex,
 			}
 
-			for _, set := range ei.Sets {
+			for i, set := range ei.Sets {
 				recordSet := RecordSet{
-					SetID:                set.ID,
+					SetID:                &set.ID,
+					PlannedReps:          set.Reps,
+					PlannedWeight:        set.Weight,
+					PlannedDuration:      set.Duration,
 					Reps:                 set.Reps,
 					Weight:               set.Weight,
 					Duration:             set.Duration,
 					RecordExerciseItemID: recordExerciseItem.ID,
+					OrderIndex:           i,
 				}
 
 				recordExerciseItem.RecordSets = append(recordExerciseItem.RecordSets, recordSet)
@@ -587,27 +638,126 @@ func (db *Database) NewRecordRoutine(routine *Routine) (*RecordRoutine, error) {
 		record.RecordRoutineItems = append(record.RecordRoutineItems, recordRoutineItem)
 	}
 
-	if err := db.Create(record).Error; err != nil {
-		return nil, fmt.Errorf("failed to create new record routine: %w", err)
+	// The version is only kept along with the workout that uses it
+	err := db.Transaction(func(tx *gorm.DB) error {
+		conn := &Database{DB: tx, Config: db.Config}
+
+		version, err := conn.snapshotRoutine(routine)
+		if err != nil {
+			return err
+		}
+		record.VersionID = &version.ID
+
+		if err := conn.programSession(record, routine); err != nil {
+			return err
+		}
+
+		if err := tx.Create(record).Error; err != nil {
+			return fmt.Errorf("failed to create new record routine: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return record, nil
 }
 
+func sortRecordRoutine(r *RecordRoutine) {
+	// sort every level of the record by OrderIndex
+	sort.SliceStable(r.RecordRoutineItems, func(i, j int) bool {
+		return r.RecordRoutineItems[i].OrderIndex < r.RecordRoutineItems[j].OrderIndex
+	})
+	for i := range r.RecordRoutineItems {
+		items := r.RecordRoutineItems[i].RecordExerciseItems
+		sort.SliceStable(items, func(i, j int) bool { return items[i].OrderIndex < items[j].OrderIndex })
+		for j := range items {
+			sets := items[j].RecordSets
+			sort.SliceStable(sets, func(i, j int) bool { return sets[i].OrderIndex < sets[j].OrderIndex })
+		}
+	}
+}
+
 func (db *Database) GetRecordRoutineByID(id uint) (*RecordRoutine, error) {
 	var record RecordRoutine
 	err := db.
+		Preload("Program").
 		Preload("RecordRoutineItems").
 		Preload("RecordRoutineItems.RecordExerciseItems").
+		Preload("RecordRoutineItems.RecordExerciseItems.Exercise").
 		Preload("RecordRoutineItems.RecordExerciseItems.RecordSets").
 		First(&record, id).Error
 	if err != nil {
 		return nil, err
 	}
 
+	sortRecordRoutine(&record)
+
 	return &record, nil
 }
 
+// FinishRecordRoutine stores the total workout time, which marks the workout as completed
+func (db *Database) FinishRecordRoutine(record *RecordRoutine) error {
+	if record.ID == 0 {
+		return fmt.Errorf("record routine ID is required to finish")
+	}
+
+	if record.Duration != nil {
+		return fmt.Errorf("workout is already finished")
+	}
+
+	duration := uint(time.Since(record.CreatedAt).Seconds())
+	if err := db.Model(record).Update("duration", duration).Error; err != nil {
+		return fmt.Errorf("failed to finish record routine: %w", err)
+	}
+	record.Duration = &duration
+
+	if err := db.advanceProgram(record); err != nil {
+		return fmt.Errorf("failed to advance program: %w", err)
+	}
+
+	return nil
+}
+
+func (db *Database) UpdateRecordSet(set *RecordSet) error {
+	if set.ID == 0 {
+		return fmt.Errorf("record set ID is required for update")
+	}
+
+	if set.Reps != nil && *set.Reps > 999 {
+		return fmt.Errorf("invalid reps value: %v", *set.Reps)
+	}
+
+	if set.Weight != nil {
+		var rei RecordExerciseItem
+		if err := db.First(&rei, set.RecordExerciseItemID).Error; err != nil {
+			return fmt.Errorf("failed to retrieve record exercise item: %w", err)
+		}
+		exercise, err := db.getRecordExercise(&rei)
+		if err != nil {
+			return fmt.Errorf("failed to retrieve exercise: %w", err)
+		}
+		if !validLoad(*set.Weight, exercise.IsBodyweight()) {
+			return fmt.Errorf("invalid weight value: %v", *set.Weight)
+		}
+	}
+
+	if set.Duration != nil && *set.Duration > 7200 {
+		return fmt.Errorf("invalid duration value: %v", *set.Duration)
+	}
+
+	if set.RPE != nil && (*set.RPE < 1 || *set.RPE > 10) {
+		return fmt.Errorf("invalid RPE value: %v", *set.RPE)
+	}
+
+	if err := db.Omit("Set", "RecordExerciseItem").Save(set).Error; err != nil {
+		return fmt.Errorf("failed to update record set: %w", err)
+	}
+
+	return nil
+}
+
 func (db *Database) DeleteRecordRoutine(recordRoutine *RecordRoutine) error {
 	if recordRoutine.ID == 0 {
 		return fmt.Errorf("record routine ID is required for deletion")
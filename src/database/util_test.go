@@ -0,0 +1,52 @@
+package database
+
+import "testing"
+
+func TestNewRecordRoutineSnapshotsPlan(t *testing.T) {
+	forEachMigratedDB(t, func(t *testing.T, db *Database) {
+		legs := &Routine{Name: "Legs", RoutineItems: []RoutineItem{{
+			ExerciseItems: []ExerciseItem{{ExerciseID: "Barbell_Squat", RestTime: 120, Notes: "Belt", Sets: []Set{
+				{Reps: ptr[uint](5), Weight: ptr(100.0)},
+			}}},
+		}}}
+		mustCreate(t, db.DB, legs)
+		routine, err := db.GetRoutineByID(legs.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		record, err := db.NewRecordRoutine(routine)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// The plan changes after the workout, then goes away
+		set := routine.RoutineItems[0].ExerciseItems[0].Sets[0]
+		set.Reps, set.Weight = ptr[uint](3), ptr(120.0)
+		if err := db.UpdateSet(&set); err != nil {
+			t.Fatal(err)
+		}
+		routine.Name = "Lower body"
+		if err := db.UpdateRoutine(routine); err != nil {
+			t.Fatal(err)
+		}
+		if err := db.DeleteRoutine(routine.ID); err != nil {
+			t.Fatal(err)
+		}
+
+		stored, err := db.GetRecordRoutineByID(record.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stored.RoutineID != nil || stored.RoutineName != "Legs" {
+			t.Errorf("workout routine = %v %q, want none and the name it was started with", stored.RoutineID, stored.RoutineName)
+		}
+		rei := stored.RecordRoutineItems[0].RecordExerciseItems[0]
+		if rei.ExerciseItemID != nil || rei.ExerciseName != "Barbell Squat" || rei.RestTime != 120 || rei.Notes != "Belt" {
+			t.Errorf("exercise = item %v %q, rest %d, notes %q, want the planned ones without the item", rei.ExerciseItemID, rei.ExerciseName, rei.RestTime, rei.Notes)
+		}
+		rs := rei.RecordSets[0]
+		if rs.SetID != nil || rs.PlannedReps == nil || *rs.PlannedReps != 5 || rs.PlannedWeight == nil || *rs.PlannedWeight != 100 {
+			t.Errorf("set = %v, planned %v reps at %v, want 5 reps at 100 without the set", rs.SetID, rs.PlannedReps, rs.PlannedWeight)
+		}
+	})
+}
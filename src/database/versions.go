@@ -0,0 +1,247 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"gorm.io/gorm"
+)
+
+// RoutineSnapshot is the content of a routine at a given time; weights are in kg
+type RoutineSnapshot struct {
+	Name        string                `json:"name" yaml:"name"`
+	Description string                `json:"description" yaml:"description"`
+	Days        []string              `json:"days" yaml:"days"`
+	Items       []RoutineItemSnapshot `json:"items" yaml:"items"`
+}
+
+// RoutineItemSnapshot is a group of exercises (a single exercise or a superset)
+type RoutineItemSnapshot struct {
+	Exercises []ExerciseItemSnapshot `json:"exercises" yaml:"exercises"`
+}
+
+type ExerciseItemSnapshot struct {
+	ExerciseID   string        `json:"exerciseId" yaml:"exerciseId"`
+	ExerciseName string        `json:"exerciseName" yaml:"exerciseName"`
+	RestTime     uint          `json:"restTime" yaml:"restTime"` // In seconds
+	Notes        string        `json:"notes" yaml:"notes"`
+	Sets         []SetSnapshot `json:"sets" yaml:"sets"`
+}
+
+type SetSnapshot struct {
+	Reps     *uint    `json:"reps" yaml:"reps,omitempty"`
+	Weight   *float64 `json:"weight" yaml:"weight,omitempty"`
+	Duration *uint    `json:"duration" yaml:"duration,omitempty"` // In seconds
+}
+
+// Snapshot captures the current content of a routine; items must be sorted
+func (r *Routine) Snapshot() RoutineSnapshot {
+	snapshot := RoutineSnapshot{
+		Name:        r.Name,
+		Description: r.Description,
+		Days:        []string{},
+		Items:       []RoutineItemSnapshot{},
+	}
+
+	days := append([]Day(nil), r.Days...)
+	sort.Slice(days, func(i, j int) bool { return days[i].ID < days[j].ID })
+	for _, day := range days {
+		snapshot.Days = append(snapshot.Days, day.Name)
+	}
+
+	for _, ri := range r.RoutineItems {
+		item := RoutineItemSnapshot{Exercises: []ExerciseItemSnapshot{}}
+		for _, ei := range ri.ExerciseItems {
+			exercise := ExerciseItemSnapshot{
+				ExerciseID:   ei.ExerciseID,
+				ExerciseName: ei.Exercise.Name,
+				RestTime:     ei.RestTime,
+				Notes:        ei.Notes,
+				Sets:         []SetSnapshot{},
+			}
+			for _, set := range ei.Sets {
+				exercise.Sets = append(exercise.Sets, SetSnapshot{Reps: set.Reps, Weight: set.Weight, Duration: set.Duration})
+			}
+			item.Exercises = append(item.Exercises, exercise)
+		}
+		snapshot.Items = append(snapshot.Items, item)
+	}
+
+	return snapshot
+}
+
+// exercises lists every exercise of a snapshot, in order
+func (s *RoutineSnapshot) exercises() []ExerciseItemSnapshot {
+	var exercises []ExerciseItemSnapshot
+	for _, item := range s.Items {
+		exercises = append(exercises, item.Exercises...)
+	}
+	return exercises
+}
+
+// Changes describes what changed from a previous version of the routine
+func (s *RoutineSnapshot) Changes(prev *RoutineSnapshot) []string {
+	if prev == nil {
+		return []string{"Created"}
+	}
+
+	var changes []string
+	if s.Name != prev.Name {
+		changes = append(changes, fmt.Sprintf("Renamed from %q", prev.Name))
+	}
+	if s.Description != prev.Description {
+		changes = append(changes, "Description changed")
+	}
+	if !reflect.DeepEqual(s.Days, prev.Days) {
+		changes = append(changes, "Schedule changed")
+	}
+
+	before := map[string]ExerciseItemSnapshot{}
+	for _, e := range prev.exercises() {
+		before[e.ExerciseID] = e
+	}
+
+	var order []string
+	after := map[string]bool{}
+	for _, e := range s.exercises() {
+		after[e.ExerciseID] = true
+		order = append(order, e.ExerciseID)
+
+		old, ok := before[e.ExerciseID]
+		switch {
+		case !ok:
+			changes = append(changes, "Added "+e.ExerciseName)
+		case len(old.Sets) != len(e.Sets):
+			changes = append(changes, fmt.Sprintf("%s: %d → %d sets", e.ExerciseName, len(old.Sets), len(e.Sets)))
+		case !reflect.DeepEqual(old.Sets, e.Sets):
+			changes = append(changes, e.ExerciseName+": sets changed")
+		case old.RestTime != e.RestTime || old.Notes != e.Notes:
+			changes = append(changes, e.ExerciseName+": rest time or notes changed")
+		}
+	}
+
+	var prevOrder []string
+	for _, e := range prev.exercises() {
+		if !after[e.ExerciseID] {
+			changes = append(changes, "Removed "+e.ExerciseName)
+		} else {
+			prevOrder = append(prevOrder, e.ExerciseID)
+		}
+	}
+
+	// Compare the order of the exercises present in both versions
+	var kept []string
+	for _, id := range order {
+		if _, ok := before[id]; ok {
+			kept = append(kept, id)
+		}
+	}
+	if !reflect.DeepEqual(kept, prevOrder) {
+		changes = append(changes, "Exercises reordered")
+	}
+	if len(changes) == 0 {
+		changes = append(changes, "Supersets regrouped")
+	}
+
+	return changes
+}
+
+func (db *Database) GetRoutineVersions(routineID uint) ([]RoutineVersion, error) {
+	var versions []RoutineVersion
+	err := db.Where("routine_id = ?", routineID).Order("version").Find(&versions).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return versions, nil
+}
+
+// CountRoutineVersionRecords returns how many workouts were performed with each version
+func (db *Database) CountRoutineVersionRecords(routineID uint) (map[uint]int, error) {
+	var rows []struct {
+		VersionID uint
+		Count     int
+	}
+	err := db.Model(&RecordRoutine{}).
+		Select("version_id, COUNT(*) AS count").
+		Where("routine_id = ? AND version_id IS NOT NULL", routineID).
+		Group("version_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[uint]int, len(rows))
+	for _, row := range rows {
+		counts[row.VersionID] = row.Count
+	}
+
+	return counts, nil
+}
+
+// snapshotRoutine returns the latest version of a routine, creating a new one
+// if the routine was edited since.
+func (db *Database) snapshotRoutine(routine *Routine) (*RoutineVersion, error) {
+	snapshot := routine.Snapshot()
+
+	var latest RoutineVersion
+	err := db.Where("routine_id = ?", routine.ID).Order("version DESC").First(&latest).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	if latest.ID != 0 && reflect.DeepEqual(latest.Snapshot, snapshot) {
+		return &latest, nil
+	}
+
+	version := &RoutineVersion{
+		RoutineID: &routine.ID,
+		Version:   latest.Version + 1,
+		Snapshot:  snapshot,
+	}
+	if err := db.Create(version).Error; err != nil {
+		return nil, fmt.Errorf("failed to create routine version: %w", err)
+	}
+
+	return version, nil
+}
+
+// CloneRoutine copies a routine with all of its items, sets and days
+func (db *Database) CloneRoutine(routine *Routine, name string, isTemplate bool) (*Routine, error) {
+	clone := &Routine{
+		Name:        name,
+		Description: routine.Description,
+		IsTemplate:  isTemplate,
+		Days:        routine.Days,
+	}
+
+	for _, ri := range routine.RoutineItems {
+		item := RoutineItem{OrderIndex: ri.OrderIndex}
+		for _, ei := range ri.ExerciseItems {
+			exerciseItem := ExerciseItem{
+				ExerciseID: ei.ExerciseID,
+				RestTime:   ei.RestTime,
+				Notes:      ei.Notes,
+				OrderIndex: ei.OrderIndex,
+			}
+			for _, set := range ei.Sets {
+				exerciseItem.Sets = append(exerciseItem.Sets, Set{Reps: set.Reps, Weight: set.Weight, Duration: set.Duration})
+			}
+			item.ExerciseItems = append(item.ExerciseItems, exerciseItem)
+		}
+		clone.RoutineItems = append(clone.RoutineItems, item)
+	}
+
+	// Templates are not scheduled
+	if isTemplate {
+		clone.Days = nil
+	}
+
+	if err := db.NewRoutine(clone); err != nil {
+		return nil, err
+	}
+
+	return clone, nil
+}
@@ -0,0 +1,57 @@
+package database
+
+import "testing"
+
+func TestNewRecordRoutineKeepsVersion(t *testing.T) {
+	forEachMigratedDB(t, func(t *testing.T, db *Database) {
+		template := &Routine{Name: "Template", IsTemplate: true}
+		mustCreate(t, db.DB, template)
+		if _, err := db.NewRecordRoutine(template); err == nil {
+			t.Error("started a workout from a template")
+		}
+		var versions int64
+		db.Model(&RoutineVersion{}).Count(&versions)
+		if versions != 0 {
+			t.Errorf("versions after starting a template = %d, want 0", versions)
+		}
+
+		legs := &Routine{Name: "Legs", RoutineItems: []RoutineItem{{
+			ExerciseItems: []ExerciseItem{{ExerciseID: "Barbell_Squat", Sets: []Set{{Reps: ptr[uint](5)}}}},
+		}}}
+		mustCreate(t, db.DB, legs)
+		routine, err := db.GetRoutineByID(legs.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		record, err := db.NewRecordRoutine(routine)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if record.VersionID == nil {
+			t.Fatal("the workout has no routine version")
+		}
+
+		// Deleting the routine keeps the version the workout was performed with
+		if err := db.DeleteRoutine(routine.ID); err != nil {
+			t.Fatal(err)
+		}
+		var version RoutineVersion
+		if err := db.First(&version, *record.VersionID).Error; err != nil {
+			t.Fatalf("version of the deleted routine: %v", err)
+		}
+		if version.RoutineID != nil {
+			t.Errorf("version routine ID = %d, want none", *version.RoutineID)
+		}
+		if len(version.Snapshot.Items) != 1 {
+			t.Errorf("version snapshot has %d items, want 1", len(version.Snapshot.Items))
+		}
+
+		got, err := db.GetRecordRoutineByID(record.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.RoutineID != nil || got.VersionID == nil || *got.VersionID != version.ID {
+			t.Errorf("workout routine and version = %v, %v, want none and %d", got.RoutineID, got.VersionID, version.ID)
+		}
+	})
+}
@@ -0,0 +1,165 @@
+package database
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// Column layouts of the workout logs exported by common tracking apps
+const (
+	CSVFormatStrong = "strong"
+	CSVFormatHevy   = "hevy"
+)
+
+var strongColumns = []string{"Date", "Workout Name", "Duration", "Exercise Name", "Set Order", "Weight", "Reps", "Distance", "Seconds", "Notes", "Workout Notes", "RPE"}
+
+const (
+	strongDateLayout = "2006-01-02 15:04:05"
+	hevyDateLayout   = "2 Jan 2006, 15:04"
+)
+
+func hevyColumns(weightUnit string) []string {
+	weight := "weight_kg"
+	if weightUnit == "lb" {
+		weight = "weight_lbs"
+	}
+	return []string{"title", "start_time", "end_time", "description", "exercise_title", "superset_id", "exercise_notes", "set_index", "set_type", weight, "reps", "distance_km", "duration_seconds", "rpe"}
+}
+
+// GetWorkoutLog returns the finished workouts started within [from, to), oldest first
+func (db *Database) GetWorkoutLog(from, to time.Time) ([]RecordRoutine, error) {
+	var records []RecordRoutine
+	err := db.
+		Preload("RecordRoutineItems").
+		Preload("RecordRoutineItems.RecordExerciseItems").
+		Preload("RecordRoutineItems.RecordExerciseItems.RecordSets").
+		Where("duration IS NOT NULL AND created_at >= ? AND created_at < ?", from, to).
+		Order("created_at").
+		Find(&records).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve workouts: %w", err)
+	}
+
+	for i := range records {
+		sortRecordRoutine(&records[i])
+	}
+
+	return records, nil
+}
+
+func csvFloat(v *float64) string {
+	if v == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*v, 'f', -1, 64)
+}
+
+func csvUint(v *uint) string {
+	if v == nil {
+		return ""
+	}
+	return strconv.FormatUint(uint64(*v), 10)
+}
+
+// strongDuration formats a workout duration like "1h 5m"
+func strongDuration(seconds uint) string {
+	d := time.Duration(seconds) * time.Second
+	if d < time.Hour {
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	}
+	return fmt.Sprintf("%dh %dm", int(d.Hours()), int(d.Minutes())%60)
+}
+
+// WriteWorkoutsCSV writes a row for every set of the workouts, using the
+// column layout of the given app. Neither layout has a time per set, so sets
+// are exported whether they were marked as done or not. Weights are converted
+// to the user's unit.
+func WriteWorkoutsCSV(w io.Writer, records []RecordRoutine, format string, units Units) error {
+	var header []string
+	switch format {
+	case CSVFormatStrong:
+		header = strongColumns
+	case CSVFormatHevy:
+		header = hevyColumns(units.Weight)
+	default:
+		return fmt.Errorf("unsupported CSV format: %s", format)
+	}
+
+	writer := csv.NewWriter(w)
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	for _, record := range records {
+		start := record.CreatedAt.Local()
+		var duration uint
+		if record.Duration != nil {
+			duration = *record.Duration
+		}
+		end := start.Add(time.Duration(duration) * time.Second)
+
+		for i, rri := range record.RecordRoutineItems {
+			// Hevy numbers the supersets of a workout
+			superset := ""
+			if len(rri.RecordExerciseItems) > 1 {
+				superset = strconv.Itoa(i)
+			}
+
+			for _, rei := range rri.RecordExerciseItems {
+				for i, set := range rei.RecordSets {
+					order := i + 1
+					weight := convertPointer(set.Weight, units.LoadFromKg)
+
+					var row []string
+					if format == CSVFormatStrong {
+						seconds := "0"
+						if set.Duration != nil {
+							seconds = csvUint(set.Duration)
+						}
+						row = []string{
+							start.Format(strongDateLayout),
+							record.RoutineName,
+							strongDuration(duration),
+							rei.ExerciseName,
+							strconv.Itoa(order),
+							csvFloat(weight),
+							csvUint(set.Reps),
+							"0",
+							seconds,
+							rei.Notes,
+							"",
+							csvFloat(set.RPE),
+						}
+					} else {
+						row = []string{
+							record.RoutineName,
+							start.Format(hevyDateLayout),
+							end.Format(hevyDateLayout),
+							"",
+							rei.ExerciseName,
+							superset,
+							rei.Notes,
+							strconv.Itoa(order - 1),
+							"normal",
+							csvFloat(weight),
+							csvUint(set.Reps),
+							"",
+							csvUint(set.Duration),
+							csvFloat(set.RPE),
+						}
+					}
+
+					if err := writer.Write(row); err != nil {
+						return err
+					}
+				}
+			}
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,98 @@
+package database
+
+import (
+	"bytes"
+	"encoding/csv"
+	"testing"
+	"time"
+)
+
+func testRecord() RecordRoutine {
+	start := time.Date(2024, 3, 4, 18, 0, 0, 0, time.Local)
+	done := start.Add(10 * time.Minute)
+	return RecordRoutine{
+		RoutineName: "Legs",
+		Duration:    ptr[uint](3900),
+		CreatedAt:   start,
+		RecordRoutineItems: []RecordRoutineItem{{
+			RecordExerciseItems: []RecordExerciseItem{{
+				ExerciseName: "Barbell Squat",
+				Notes:        "Low bar",
+				RecordSets: []RecordSet{
+					{Reps: ptr[uint](5), Weight: ptr(100.0), RPE: ptr(8.0), CompletedAt: &done},
+					{Reps: ptr[uint](5), Weight: ptr(102.5)}, // Not marked as done
+					{Reps: ptr[uint](3), Weight: ptr(105.0)},
+				},
+			}},
+		}},
+	}
+}
+
+func TestWriteWorkoutsCSV(t *testing.T) {
+	for _, format := range []string{CSVFormatStrong, CSVFormatHevy} {
+		t.Run(format, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := WriteWorkoutsCSV(&buf, []RecordRoutine{testRecord()}, format, MetricUnits); err != nil {
+				t.Fatal(err)
+			}
+
+			rows, err := csv.NewReader(bytes.NewReader(buf.Bytes())).ReadAll()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(rows) != 4 {
+				t.Fatalf("got %d rows, want a header and a row per set:\n%s", len(rows), buf.String())
+			}
+
+			// The export can be imported back
+			imp, err := ParseWorkoutCSV(buf.Bytes(), "kg")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if imp.Format != format {
+				t.Errorf("detected format = %s, want %s", imp.Format, format)
+			}
+			if len(imp.Workouts) != 1 || len(imp.Workouts[0].Exercises) != 1 {
+				t.Fatalf("imported workouts = %+v, want one with one exercise", imp.Workouts)
+			}
+			workout := imp.Workouts[0]
+			if workout.Name != "Legs" || !workout.Start.Equal(testRecord().CreatedAt) || workout.Duration/60 != 65 {
+				t.Errorf("imported workout = %s at %s for %ds, want Legs at %s for 65m", workout.Name, workout.Start, workout.Duration, testRecord().CreatedAt)
+			}
+
+			sets := workout.Exercises[0].Sets
+			want := testRecord().RecordRoutineItems[0].RecordExerciseItems[0].RecordSets
+			if len(sets) != len(want) {
+				t.Fatalf("imported %d sets, want %d", len(sets), len(want))
+			}
+			for i := range want {
+				if *sets[i].Reps != *want[i].Reps || *sets[i].Weight != *want[i].Weight {
+					t.Errorf("set %d = %d x %g, want %d x %g", i, *sets[i].Reps, *sets[i].Weight, *want[i].Reps, *want[i].Weight)
+				}
+			}
+			if sets[0].RPE == nil || *sets[0].RPE != 8 {
+				t.Errorf("set 0 RPE = %v, want 8", sets[0].RPE)
+			}
+		})
+	}
+}
+
+func TestWriteWorkoutsCSVPounds(t *testing.T) {
+	var buf bytes.Buffer
+	units := Units{Weight: "lb", Length: "in"}
+	if err := WriteWorkoutsCSV(&buf, []RecordRoutine{testRecord()}, CSVFormatHevy, units); err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rows[0][9] != "weight_lbs" {
+		t.Errorf("weight column = %s, want weight_lbs", rows[0][9])
+	}
+	// Recorded weights are converted as they are, not rounded to plates
+	if rows[1][9] != "220.46226218487757" {
+		t.Errorf("weight of 100 kg = %s lb, want 220.46226218487757", rows[1][9])
+	}
+}
@@ -0,0 +1,681 @@
+package database
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	g "github.com/birabittoh/go-lift/src/globals"
+	"gorm.io/gorm"
+)
+
+// Workout logs that can be imported, CSVFormatStrong and CSVFormatHevy are
+// the layouts written by WriteWorkoutsCSV.
+const CSVFormatFitNotes = "fitnotes"
+
+const (
+	exerciseMatchThreshold = 0.75 // Minimum similarity to map an exercise automatically
+	exerciseCandidates     = 5
+)
+
+var importDateLayouts = map[string][]string{
+	CSVFormatStrong:   {strongDateLayout, "2006-01-02 15:04", time.RFC3339},
+	CSVFormatHevy:     {hevyDateLayout, "2 Jan 2006 15:04", "Jan 2, 2006, 15:04", strongDateLayout},
+	CSVFormatFitNotes: {"2006-01-02"},
+}
+
+type ImportedSet struct {
+	Reps     *uint    `json:"reps"`
+	Weight   *float64 `json:"weight"`   // In kg
+	Duration *uint    `json:"duration"` // In seconds
+	RPE      *float64 `json:"rpe"`
+}
+
+type ImportedExercise struct {
+	Name     string        `json:"name"`
+	Notes    string        `json:"notes"`
+	Superset string        `json:"superset"` // Exercises with the same superset are grouped together
+	Sets     []ImportedSet `json:"sets"`
+}
+
+// ImportedWorkout is a workout read from another app's export
+type ImportedWorkout struct {
+	Name      string             `json:"name"`
+	Start     time.Time          `json:"start"`
+	Duration  uint               `json:"duration"` // In seconds
+	Exercises []ImportedExercise `json:"exercises"`
+}
+
+type ExerciseCandidate struct {
+	ID    string  `json:"id"`
+	Name  string  `json:"name"`
+	Score float64 `json:"score"`
+}
+
+// ExerciseMatch maps an exercise name of the imported file to the catalog
+type ExerciseMatch struct {
+	Name       string              `json:"name"`
+	Sets       int                 `json:"sets"`
+	ExerciseID string              `json:"exerciseId"` // Empty if no exercise is similar enough
+	Candidates []ExerciseCandidate `json:"candidates"`
+}
+
+type WorkoutImport struct {
+	Format    string            `json:"format"`
+	Workouts  []ImportedWorkout `json:"workouts"`
+	Exercises []ExerciseMatch   `json:"exercises"`
+	Errors    []string          `json:"errors"`
+}
+
+type WorkoutImportResult struct {
+	Imported         int      `json:"imported"`
+	Duplicates       int      `json:"duplicates"` // Workouts that were already imported
+	Sets             int      `json:"sets"`
+	SkippedExercises []string `json:"skippedExercises"`
+	Errors           []string `json:"errors"` // Rows that could not be read
+}
+
+// csvRow gives access to the fields of a row by lowercase column name
+type csvRow struct {
+	columns map[string]int
+	values  []string
+}
+
+func (r csvRow) get(name string) string {
+	i, ok := r.columns[name]
+	if !ok || i >= len(r.values) {
+		return ""
+	}
+	return strings.TrimSpace(r.values[i])
+}
+
+func (r csvRow) has(name string) bool {
+	_, ok := r.columns[name]
+	return ok
+}
+
+func detectWorkoutFormat(columns map[string]int) (string, error) {
+	row := csvRow{columns: columns}
+	switch {
+	case row.has("exercise_title") && row.has("start_time"):
+		return CSVFormatHevy, nil
+	case row.has("workout name") && row.has("exercise name"):
+		return CSVFormatStrong, nil
+	case row.has("exercise") && row.has("category") && row.has("date"):
+		return CSVFormatFitNotes, nil
+	default:
+		return "", fmt.Errorf("unrecognized CSV layout: expected a Strong, Hevy or FitNotes export")
+	}
+}
+
+func parseImportNumber(s string) (*float64, error) {
+	if s == "" {
+		return nil, nil
+	}
+	// Exports using semicolons as separator use commas as decimal separator
+	v, err := strconv.ParseFloat(strings.ReplaceAll(s, ",", "."), 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid number: %s", s)
+	}
+	return &v, nil
+}
+
+// parseImportUint reads a positive integer, zero is treated as missing
+func parseImportUint(s string) (*uint, error) {
+	v, err := parseImportNumber(s)
+	if err != nil || v == nil || *v <= 0 {
+		return nil, err
+	}
+	u := uint(*v + 0.5)
+	return &u, nil
+}
+
+// parseImportDuration reads durations like "1h 5m", "45m 30s", "0:01:30" or "90"
+func parseImportDuration(s string) (uint, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+
+	if strings.Contains(s, ":") {
+		var total uint
+		for _, part := range strings.Split(s, ":") {
+			v, err := strconv.ParseUint(part, 10, 32)
+			if err != nil {
+				return 0, fmt.Errorf("invalid duration: %s", s)
+			}
+			total = total*60 + uint(v)
+		}
+		return total, nil
+	}
+
+	if v, err := strconv.ParseUint(s, 10, 32); err == nil {
+		return uint(v), nil
+	}
+
+	var total uint
+	for _, part := range strings.Fields(s) {
+		if len(part) < 2 {
+			return 0, fmt.Errorf("invalid duration: %s", s)
+		}
+		v, err := strconv.ParseUint(part[:len(part)-1], 10, 32)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration: %s", s)
+		}
+		switch part[len(part)-1] {
+		case 'h':
+			total += uint(v) * 3600
+		case 'm':
+			total += uint(v) * 60
+		case 's':
+			total += uint(v)
+		default:
+			return 0, fmt.Errorf("invalid duration: %s", s)
+		}
+	}
+	return total, nil
+}
+
+func parseImportDate(s, format string) (time.Time, error) {
+	for _, layout := range importDateLayouts[format] {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognized date: %s", s)
+}
+
+// weightToKg converts a weight in "kg", "lb" or "lbs" to kg
+func weightToKg(v *float64, unit string) *float64 {
+	if v == nil || *v == 0 {
+		return nil
+	}
+	kg := *v
+	if strings.HasPrefix(strings.ToLower(unit), "lb") {
+		kg = ToCanonical(kg, "lb")
+	}
+	return &kg
+}
+
+// importRow is a single set of the imported file
+type importRow struct {
+	workoutKey string
+	workout    ImportedWorkout
+	exercise   ImportedExercise
+	set        ImportedSet
+}
+
+func parseStrongRow(row csvRow, weightUnit string) (*importRow, error) {
+	// Strong also lists rest timers and notes as sets
+	if _, err := strconv.Atoi(row.get("set order")); err != nil {
+		return nil, nil
+	}
+
+	start, err := parseImportDate(row.get("date"), CSVFormatStrong)
+	if err != nil {
+		return nil, err
+	}
+	duration, err := parseImportDuration(row.get("duration"))
+	if err != nil {
+		return nil, err
+	}
+
+	if unit := row.get("weight unit"); unit != "" {
+		weightUnit = unit
+	}
+	weight, err := parseImportNumber(row.get("weight"))
+	if err != nil {
+		return nil, err
+	}
+	reps, err := parseImportUint(row.get("reps"))
+	if err != nil {
+		return nil, err
+	}
+	seconds, err := parseImportUint(row.get("seconds"))
+	if err != nil {
+		return nil, err
+	}
+	rpe, err := parseImportNumber(row.get("rpe"))
+	if err != nil {
+		return nil, err
+	}
+
+	name := row.get("workout name")
+	return &importRow{
+		workoutKey: row.get("date") + "|" + name,
+		workout:    ImportedWorkout{Name: name, Start: start, Duration: duration},
+		exercise:   ImportedExercise{Name: row.get("exercise name"), Notes: row.get("notes")},
+		set:        ImportedSet{Reps: reps, Weight: weightToKg(weight, weightUnit), Duration: seconds, RPE: rpe},
+	}, nil
+}
+
+func parseHevyRow(row csvRow) (*importRow, error) {
+	start, err := parseImportDate(row.get("start_time"), CSVFormatHevy)
+	if err != nil {
+		return nil, err
+	}
+
+	var duration uint
+	if end, err := parseImportDate(row.get("end_time"), CSVFormatHevy); err == nil && end.After(start) {
+		duration = uint(end.Sub(start).Seconds())
+	}
+
+	weightColumn, weightUnit := "weight_kg", "kg"
+	if row.has("weight_lbs") {
+		weightColumn, weightUnit = "weight_lbs", "lb"
+	}
+	weight, err := parseImportNumber(row.get(weightColumn))
+	if err != nil {
+		return nil, err
+	}
+	reps, err := parseImportUint(row.get("reps"))
+	if err != nil {
+		return nil, err
+	}
+	seconds, err := parseImportUint(row.get("duration_seconds"))
+	if err != nil {
+		return nil, err
+	}
+	rpe, err := parseImportNumber(row.get("rpe"))
+	if err != nil {
+		return nil, err
+	}
+
+	name := row.get("title")
+	return &importRow{
+		workoutKey: row.get("start_time") + "|" + name,
+		workout:    ImportedWorkout{Name: name, Start: start, Duration: duration},
+		exercise:   ImportedExercise{Name: row.get("exercise_title"), Notes: row.get("exercise_notes"), Superset: row.get("superset_id")},
+		set:        ImportedSet{Reps: reps, Weight: weightToKg(weight, weightUnit), Duration: seconds, RPE: rpe},
+	}, nil
+}
+
+func parseFitNotesRow(row csvRow) (*importRow, error) {
+	start, err := parseImportDate(row.get("date"), CSVFormatFitNotes)
+	if err != nil {
+		return nil, err
+	}
+
+	weightColumn, weightUnit := "weight (kgs)", "kg"
+	if row.has("weight (lbs)") {
+		weightColumn, weightUnit = "weight (lbs)", "lb"
+	} else if row.has("weight (kg)") {
+		weightColumn = "weight (kg)"
+	}
+	weight, err := parseImportNumber(row.get(weightColumn))
+	if err != nil {
+		return nil, err
+	}
+	reps, err := parseImportUint(row.get("reps"))
+	if err != nil {
+		return nil, err
+	}
+
+	var seconds *uint
+	if value := row.get("time"); value != "" {
+		d, err := parseImportDuration(value)
+		if err != nil {
+			return nil, err
+		}
+		if d > 0 {
+			seconds = &d
+		}
+	}
+
+	// FitNotes logs sets by day, without workout names
+	return &importRow{
+		workoutKey: row.get("date"),
+		workout:    ImportedWorkout{Name: "FitNotes workout", Start: start},
+		exercise:   ImportedExercise{Name: row.get("exercise"), Notes: row.get("comment")},
+		set:        ImportedSet{Reps: reps, Weight: weightToKg(weight, weightUnit), Duration: seconds},
+	}, nil
+}
+
+// ParseWorkoutCSV reads a Strong, Hevy or FitNotes export. Strong does not
+// always state the weight unit, weightUnit is used in that case.
+func ParseWorkoutCSV(data []byte, weightUnit string) (*WorkoutImport, error) {
+	reader := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	// Some exports use semicolons as separator
+	firstLine, _, _ := bytes.Cut(data, []byte("\n"))
+	if bytes.Count(firstLine, []byte(";")) > bytes.Count(firstLine, []byte(",")) {
+		reader.Comma = ';'
+	}
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+
+	format, err := detectWorkoutFormat(columns)
+	if err != nil {
+		return nil, err
+	}
+
+	imp := &WorkoutImport{Format: format, Errors: []string{}}
+	workouts := map[string]int{}
+	line := 1
+	for {
+		values, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		line++
+		if err != nil {
+			imp.Errors = append(imp.Errors, fmt.Sprintf("line %d: %v", line, err))
+			continue
+		}
+
+		row := csvRow{columns: columns, values: values}
+		var parsed *importRow
+		switch format {
+		case CSVFormatStrong:
+			parsed, err = parseStrongRow(row, weightUnit)
+		case CSVFormatHevy:
+			parsed, err = parseHevyRow(row)
+		case CSVFormatFitNotes:
+			parsed, err = parseFitNotesRow(row)
+		}
+		if err != nil {
+			imp.Errors = append(imp.Errors, fmt.Sprintf("line %d: %v", line, err))
+			continue
+		}
+		if parsed == nil || parsed.exercise.Name == "" {
+			continue
+		}
+
+		i, ok := workouts[parsed.workoutKey]
+		if !ok {
+			i = len(imp.Workouts)
+			workouts[parsed.workoutKey] = i
+			imp.Workouts = append(imp.Workouts, parsed.workout)
+		}
+		workout := &imp.Workouts[i]
+
+		// Sets of the same exercise are usually consecutive
+		n := len(workout.Exercises)
+		if n == 0 || workout.Exercises[n-1].Name != parsed.exercise.Name || workout.Exercises[n-1].Superset != parsed.exercise.Superset {
+			workout.Exercises = append(workout.Exercises, parsed.exercise)
+			n++
+		}
+		workout.Exercises[n-1].Sets = append(workout.Exercises[n-1].Sets, parsed.set)
+	}
+
+	sort.SliceStable(imp.Workouts, func(i, j int) bool { return imp.Workouts[i].Start.Before(imp.Workouts[j].Start) })
+
+	return imp, nil
+}
+
+var nameSynonyms = map[string]string{
+	"db": "dumbbell",
+	"bb": "barbell",
+	"kb": "kettlebell",
+}
+
+// nameTokens splits an exercise name in sorted, singular, lowercase words
+func nameTokens(name string) []string {
+	words := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	tokens := make([]string, 0, len(words))
+	for _, w := range words {
+		if synonym, ok := nameSynonyms[w]; ok {
+			w = synonym
+		}
+		if len(w) > 3 && strings.HasSuffix(w, "s") && !strings.HasSuffix(w, "ss") {
+			w = strings.TrimSuffix(w, "s")
+		}
+		tokens = append(tokens, w)
+	}
+	sort.Strings(tokens)
+	return tokens
+}
+
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
+
+// nameSimilarity scores from 0 to 1 how likely two names are the same exercise,
+// combining shared words ("Bench Press (Barbell)" and "Barbell Bench Press")
+// and spelling ("Pull Up" and "Pullups").
+func nameSimilarity(a, b []string) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+
+	words := map[string]bool{}
+	for _, t := range b {
+		words[t] = true
+	}
+	common := 0
+	for _, t := range a {
+		if words[t] {
+			common++
+		}
+	}
+	containment := float64(common) / float64(min(len(a), len(b)))
+	dice := 2 * float64(common) / float64(len(a)+len(b))
+	wordScore := (containment + dice) / 2
+
+	ra, rb := []rune(strings.Join(a, "")), []rune(strings.Join(b, ""))
+	spellingScore := 1 - float64(levenshtein(ra, rb))/float64(max(len(ra), len(rb)))
+
+	return max(wordScore, spellingScore)
+}
+
+// matchExercises suggests catalog exercises for every exercise name of the import
+func (db *Database) matchExercises(imp *WorkoutImport) error {
+	var exercises []Exercise
+	if err := db.Select("id", "name").Find(&exercises).Error; err != nil {
+		return fmt.Errorf("failed to retrieve exercises: %w", err)
+	}
+
+	catalog := make([][]string, len(exercises))
+	for i, e := range exercises {
+		catalog[i] = nameTokens(e.Name)
+	}
+
+	matches := map[string]int{}
+	for _, workout := range imp.Workouts {
+		for _, e := range workout.Exercises {
+			i, ok := matches[e.Name]
+			if !ok {
+				i = len(imp.Exercises)
+				matches[e.Name] = i
+				imp.Exercises = append(imp.Exercises, ExerciseMatch{Name: e.Name})
+			}
+			imp.Exercises[i].Sets += len(e.Sets)
+		}
+	}
+
+	for i := range imp.Exercises {
+		match := &imp.Exercises[i]
+		tokens := nameTokens(match.Name)
+
+		candidates := make([]ExerciseCandidate, 0, len(exercises))
+		for j, e := range exercises {
+			if strings.EqualFold(e.Name, match.Name) || e.ID == match.Name {
+				candidates = append(candidates, ExerciseCandidate{ID: e.ID, Name: e.Name, Score: 1})
+				continue
+			}
+			if score := nameSimilarity(tokens, catalog[j]); score > 0 {
+				candidates = append(candidates, ExerciseCandidate{ID: e.ID, Name: e.Name, Score: score})
+			}
+		}
+		sort.SliceStable(candidates, func(a, b int) bool { return candidates[a].Score > candidates[b].Score })
+
+		match.Candidates = candidates[:min(len(candidates), exerciseCandidates)]
+		if len(match.Candidates) > 0 && match.Candidates[0].Score >= exerciseMatchThreshold {
+			match.ExerciseID = match.Candidates[0].ID
+		}
+	}
+
+	sort.SliceStable(imp.Exercises, func(i, j int) bool { return imp.Exercises[i].Name < imp.Exercises[j].Name })
+	return nil
+}
+
+// PrepareWorkoutImport parses an export and matches its exercises to the catalog
+func (db *Database) PrepareWorkoutImport(data []byte, weightUnit string) (*WorkoutImport, error) {
+	imp, err := ParseWorkoutCSV(data, weightUnit)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := db.matchExercises(imp); err != nil {
+		return nil, err
+	}
+
+	return imp, nil
+}
+
+// ImportWorkouts creates a finished workout record for every imported workout.
+// mapping overrides the suggested exercise of a name, an empty ID skips it.
+// Workouts already imported, with the same name and start, are skipped.
+func (db *Database) ImportWorkouts(imp *WorkoutImport, mapping map[string]string) (*WorkoutImportResult, error) {
+	exerciseIDs := map[string]string{}
+	for _, match := range imp.Exercises {
+		exerciseIDs[match.Name] = match.ExerciseID
+	}
+	for name, id := range mapping {
+		exerciseIDs[name] = id
+	}
+
+	exercises := map[string]*Exercise{}
+	result := &WorkoutImportResult{SkippedExercises: []string{}, Errors: imp.Errors}
+	for _, match := range imp.Exercises {
+		id := exerciseIDs[match.Name]
+		if id == "" {
+			result.SkippedExercises = append(result.SkippedExercises, match.Name)
+			continue
+		}
+		exercise, err := db.GetExerciseByID(id)
+		if err != nil {
+			return nil, fmt.Errorf("unknown exercise %q for %q", id, match.Name)
+		}
+		exercises[match.Name] = exercise
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		for _, workout := range imp.Workouts {
+			name := g.Truncate(workout.Name, 100)
+
+			var count int64
+			err := tx.Model(&RecordRoutine{}).Where("routine_name = ? AND created_at = ?", name, workout.Start).Count(&count).Error
+			if err != nil {
+				return err
+			}
+			if count > 0 {
+				result.Duplicates++
+				continue
+			}
+
+			record, sets := newImportedRecord(workout, name, exercises)
+			if len(record.RecordRoutineItems) == 0 {
+				continue
+			}
+
+			if err := tx.Create(record).Error; err != nil {
+				return fmt.Errorf("failed to import workout of %s: %w", workout.Start.Format(strongDateLayout), err)
+			}
+			result.Imported++
+			result.Sets += sets
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// newImportedRecord builds the record tree of an imported workout
+func newImportedRecord(workout ImportedWorkout, name string, exercises map[string]*Exercise) (*RecordRoutine, int) {
+	duration := workout.Duration
+	record := &RecordRoutine{
+		RoutineName: name,
+		Duration:    &duration,
+		CreatedAt:   workout.Start,
+		UpdatedAt:   workout.Start,
+	}
+
+	completedAt := workout.Start.Add(time.Duration(duration) * time.Second)
+	supersets := map[string]int{}
+	sets := 0
+	for _, e := range workout.Exercises {
+		exercise, ok := exercises[e.Name]
+		if !ok {
+			continue
+		}
+
+		item := &RecordExerciseItem{
+			ExerciseID:   exercise.ID,
+			ExerciseName: exercise.Name,
+			Notes:        g.Truncate(e.Notes, 500),
+			CreatedAt:    workout.Start,
+			UpdatedAt:    workout.Start,
+		}
+		for _, set := range e.Sets {
+			item.RecordSets = append(item.RecordSets, RecordSet{
+				Reps:        set.Reps,
+				Weight:      set.Weight,
+				Duration:    set.Duration,
+				RPE:         set.RPE,
+				CompletedAt: &completedAt,
+				OrderIndex:  len(item.RecordSets),
+				CreatedAt:   workout.Start,
+				UpdatedAt:   workout.Start,
+			})
+			sets++
+		}
+
+		// Exercises of the same superset share a routine item
+		i, ok := supersets[e.Superset]
+		if e.Superset == "" || !ok {
+			i = len(record.RecordRoutineItems)
+			record.RecordRoutineItems = append(record.RecordRoutineItems, RecordRoutineItem{
+				OrderIndex: i,
+				CreatedAt:  workout.Start,
+				UpdatedAt:  workout.Start,
+			})
+			if e.Superset != "" {
+				supersets[e.Superset] = i
+			}
+		}
+		rri := &record.RecordRoutineItems[i]
+		item.OrderIndex = len(rri.RecordExerciseItems)
+		rri.RecordExerciseItems = append(rri.RecordExerciseItems, *item)
+	}
+
+	return record, sets
+}
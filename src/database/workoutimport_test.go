@@ -0,0 +1,228 @@
+package database
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseImportDuration(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    uint
+		wantErr bool
+	}{
+		{"", 0, false},
+		{"90", 90, false},
+		{"45m", 2700, false},
+		{"1h 5m", 3900, false},
+		{"45m 30s", 2730, false},
+		{"0:01:30", 90, false},
+		{"1:00:00", 3600, false},
+		{"1x", 0, true},
+		{"a:b", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := parseImportDuration(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("parseImportDuration(%q) = %d, %v, want %d, error %v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+// importedSet describes a set as reps x weight, to compare them
+func importedSet(s ImportedSet) string {
+	var b strings.Builder
+	if s.Reps != nil {
+		b.WriteString(csvUint(s.Reps))
+	}
+	if s.Weight != nil {
+		b.WriteString("x" + csvFloat(ptr(math.Round(*s.Weight*100)/100)))
+	}
+	if s.Duration != nil {
+		b.WriteString(csvUint(s.Duration) + "s")
+	}
+	return b.String()
+}
+
+func TestParseWorkoutCSV(t *testing.T) {
+	type exercise struct {
+		name string
+		sets []string
+	}
+	type workout struct {
+		name      string
+		start     time.Time
+		duration  uint
+		exercises []exercise
+	}
+
+	tests := []struct {
+		name       string
+		csv        string
+		weightUnit string
+		format     string
+		workouts   []workout
+		errors     int
+	}{
+		{
+			name: "strong",
+			csv: "Date;Workout Name;Duration;Exercise Name;Set Order;Weight;Reps;Distance;Seconds;Notes;Workout Notes;RPE\n" +
+				"2022-03-03 18:00:00;Legs;45m;Squat (Barbell);1;140;3;0;0;;;\n" +
+				"2022-03-01 18:00:00;Push Day;1h 5m;Bench Press (Barbell);1;100;5;0;0;;;8\n" +
+				"2022-03-01 18:00:00;Push Day;1h 5m;Bench Press (Barbell);2;102,5;5;0;0;;;\n" +
+				"2022-03-01 18:00:00;Push Day;1h 5m;Bench Press (Barbell);Rest Timer;0;0;0;90;;;\n" +
+				"2022-03-01 18:00:00;Push Day;1h 5m;Plank;1;0;0;0;60;;;\n" +
+				"bad-date;Legs;45m;Squat (Barbell);1;140;3;0;0;;;\n",
+			weightUnit: "kg",
+			format:     CSVFormatStrong,
+			workouts: []workout{
+				{"Push Day", time.Date(2022, 3, 1, 18, 0, 0, 0, time.Local), 3900, []exercise{
+					{"Bench Press (Barbell)", []string{"5x100", "5x102.5"}},
+					{"Plank", []string{"60s"}},
+				}},
+				{"Legs", time.Date(2022, 3, 3, 18, 0, 0, 0, time.Local), 2700, []exercise{
+					{"Squat (Barbell)", []string{"3x140"}},
+				}},
+			},
+			errors: 1,
+		},
+		{
+			name: "strong in pounds",
+			csv: "\xef\xbb\xbfDate,Workout Name,Duration,Exercise Name,Set Order,Weight,Weight Unit,Reps\n" +
+				"2022-03-01 18:00,Push,1h,Bench Press (Barbell),1,225,lbs,5\n" +
+				"2022-03-01 18:00,Push,1h,Bench Press (Barbell),2,100,kg,5\n",
+			weightUnit: "kg",
+			format:     CSVFormatStrong,
+			workouts: []workout{
+				{"Push", time.Date(2022, 3, 1, 18, 0, 0, 0, time.Local), 3600, []exercise{
+					{"Bench Press (Barbell)", []string{"5x102.06", "5x100"}},
+				}},
+			},
+		},
+		{
+			name: "hevy",
+			csv: `"title","start_time","end_time","description","exercise_title","superset_id","exercise_notes","set_index","set_type","weight_lbs","reps","distance_km","duration_seconds","rpe"` + "\n" +
+				`"Upper","5 Apr 2023, 07:30","5 Apr 2023, 08:30","","Pull Up","0","","0","normal","","8","","",""` + "\n" +
+				`"Upper","5 Apr 2023, 07:30","5 Apr 2023, 08:30","","Bench Press (Barbell)","0","","0","normal","225","5","","","9"` + "\n" +
+				`"Upper","5 Apr 2023, 07:30","5 Apr 2023, 08:30","","Pull Up","0","","1","normal","","6","","",""` + "\n" +
+				`"Upper","5 Apr 2023, 07:30","5 Apr 2023, 08:30","","Pull Up","","","0","normal","","5","","",""` + "\n",
+			weightUnit: "kg",
+			format:     CSVFormatHevy,
+			workouts: []workout{
+				{"Upper", time.Date(2023, 4, 5, 7, 30, 0, 0, time.Local), 3600, []exercise{
+					{"Pull Up", []string{"8"}},
+					{"Bench Press (Barbell)", []string{"5x102.06"}},
+					{"Pull Up", []string{"6"}},
+					{"Pull Up", []string{"5"}}, // Out of the superset
+				}},
+			},
+		},
+		{
+			name: "fitnotes",
+			csv: "Date,Exercise,Category,Weight (kgs),Reps,Distance,Distance Unit,Time,Comment\n" +
+				"2021-01-10,Barbell Squat,Legs,100.0,5,,,,\n" +
+				"2021-01-10,Barbell Squat,Legs,100.0,5,,,,\n" +
+				"2021-01-10,Plank,Core,,,,,0:01:30,\n" +
+				"2021-01-12,Deadlift,Back,140.0,x,,,,\n" +
+				"2021-01-12,Deadlift,Back,140.0,3,,,,\n",
+			weightUnit: "lb",
+			format:     CSVFormatFitNotes,
+			workouts: []workout{
+				{"FitNotes workout", time.Date(2021, 1, 10, 0, 0, 0, 0, time.Local), 0, []exercise{
+					{"Barbell Squat", []string{"5x100", "5x100"}},
+					{"Plank", []string{"90s"}},
+				}},
+				{"FitNotes workout", time.Date(2021, 1, 12, 0, 0, 0, 0, time.Local), 0, []exercise{
+					{"Deadlift", []string{"3x140"}},
+				}},
+			},
+			errors: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			imp, err := ParseWorkoutCSV([]byte(tt.csv), tt.weightUnit)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if imp.Format != tt.format {
+				t.Errorf("format = %s, want %s", imp.Format, tt.format)
+			}
+			if len(imp.Errors) != tt.errors {
+				t.Errorf("errors = %q, want %d", imp.Errors, tt.errors)
+			}
+
+			if len(imp.Workouts) != len(tt.workouts) {
+				t.Fatalf("got %d workouts, want %d", len(imp.Workouts), len(tt.workouts))
+			}
+			for i, want := range tt.workouts {
+				got := imp.Workouts[i]
+				if got.Name != want.name || !got.Start.Equal(want.start) || got.Duration != want.duration {
+					t.Errorf("workout %d = %s at %s for %ds, want %s at %s for %ds", i, got.Name, got.Start, got.Duration, want.name, want.start, want.duration)
+				}
+				if len(got.Exercises) != len(want.exercises) {
+					t.Errorf("workout %d has %d exercises, want %d", i, len(got.Exercises), len(want.exercises))
+					continue
+				}
+				for j, we := range want.exercises {
+					ge := got.Exercises[j]
+					var sets []string
+					for _, s := range ge.Sets {
+						sets = append(sets, importedSet(s))
+					}
+					if ge.Name != we.name || strings.Join(sets, " ") != strings.Join(we.sets, " ") {
+						t.Errorf("workout %d exercise %d = %s %v, want %s %v", i, j, ge.Name, sets, we.name, we.sets)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestParseWorkoutCSVUnknownLayout(t *testing.T) {
+	if _, err := ParseWorkoutCSV([]byte("name,value\nsquat,100\n"), "kg"); err == nil {
+		t.Error("parsed a CSV file that is not a workout export")
+	}
+}
+
+func TestImportWorkouts(t *testing.T) {
+	data := []byte("Date,Workout Name,Duration,Exercise Name,Set Order,Weight,Reps\n" +
+		"2022-03-01 18:00:00,Legs,1h,Barbell Squat,1,100,5\n" +
+		"2022-03-01 18:00:00,Legs,1h,Barbell Squat,2,100,5\n" +
+		"2022-03-01 18:00:00,Legs,1h,Leg Wiggle,1,10,12\n")
+
+	forEachMigratedDB(t, func(t *testing.T, db *Database) {
+		imp, err := db.PrepareWorkoutImport(data, "kg")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		result, err := db.ImportWorkouts(imp, map[string]string{"Leg Wiggle": ""})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Imported != 1 || result.Sets != 2 || len(result.SkippedExercises) != 1 {
+			t.Errorf("result = %+v, want 1 workout with 2 sets and 1 skipped exercise", result)
+		}
+
+		records, err := db.GetWorkoutLog(time.Time{}, time.Now())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(records) != 1 || records[0].RecordRoutineItems[0].RecordExerciseItems[0].ExerciseID != "Barbell_Squat" {
+			t.Fatalf("imported workouts = %+v, want one with Barbell_Squat", records)
+		}
+
+		// Importing the same file again adds nothing
+		result, err = db.ImportWorkouts(imp, map[string]string{"Leg Wiggle": ""})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Imported != 0 || result.Duplicates != 1 {
+			t.Errorf("result of the second import = %+v, want 1 duplicate", result)
+		}
+	})
+}
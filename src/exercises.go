@@ -0,0 +1,25 @@
+package src
+
+import (
+	"flag"
+
+	"github.com/birabittoh/go-lift/src/config"
+	"github.com/birabittoh/go-lift/src/database"
+)
+
+// runSyncExercises updates the exercises from the catalog
+func runSyncExercises(cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("sync-exercises", flag.ExitOnError)
+	fs.Parse(args)
+
+	db, err := database.OpenDB(cfg)
+	if err != nil {
+		return err
+	}
+
+	if err := db.MigrateUp(0); err != nil {
+		return err
+	}
+
+	return db.UpdateExercises()
+}
@@ -1,9 +1,11 @@
 package functions
 
 import (
+	"context"
 	"net/http"
 	"strconv"
 	"strings"
+	"unicode/utf8"
 )
 
 func Capitalize(s string) string {
@@ -17,3 +19,28 @@ func GetIDFromPath(r *http.Request) (uint, error) {
 	id, err := strconv.Atoi(r.PathValue("id"))
 	return uint(id), err
 }
+
+// Truncate shortens s to at most n bytes without splitting characters
+func Truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+type userKey struct{}
+
+// WithUser returns a copy of the request carrying the name of the signed-in
+// user
+func WithUser(r *http.Request, username string) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), userKey{}, username))
+}
+
+// User returns the name of the signed-in user, empty without authentication
+func User(r *http.Request) string {
+	username, _ := r.Context().Value(userKey{}).(string)
+	return username
+}
@@ -0,0 +1,36 @@
+package logging
+
+import (
+	"io"
+	"log/slog"
+	"os"
+
+	"github.com/birabittoh/go-lift/src/config"
+)
+
+// Output is where logs are written. Standard output is left to commands
+// that print data, like export.
+var Output io.Writer = os.Stderr
+
+// levels maps the configured log levels to slog's
+var levels = map[string]slog.Level{
+	config.LogDebug: slog.LevelDebug,
+	config.LogInfo:  slog.LevelInfo,
+	config.LogWarn:  slog.LevelWarn,
+	config.LogError: slog.LevelError,
+}
+
+// New returns a logger writing in the configured format, from the given level
+func New(cfg *config.Config, level string) *slog.Logger {
+	opts := &slog.HandlerOptions{Level: levels[level]}
+	if cfg.LogFormat == config.LogFormatJSON {
+		return slog.New(slog.NewJSONHandler(Output, opts))
+	}
+	return slog.New(slog.NewTextHandler(Output, opts))
+}
+
+// Setup makes the configured logger the default one, which the log package
+// also writes through
+func Setup(cfg *config.Config) {
+	slog.SetDefault(New(cfg, cfg.LogLevel))
+}
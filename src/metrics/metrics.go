@@ -0,0 +1,92 @@
+package metrics
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Namespace prefixes the names of the metrics
+const Namespace = "golift"
+
+// Results of a catalog sync
+const (
+	SyncSuccess = "success"
+	SyncFailure = "failure"
+)
+
+var (
+	HTTPRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests served, by route pattern and status code.",
+	}, []string{"method", "route", "status"})
+
+	HTTPRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: Namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Time taken to serve HTTP requests, by route pattern.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	DBQueryDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: Namespace,
+		Name:      "db_query_duration_seconds",
+		Help:      "Time taken by database statements, by operation and table.",
+		Buckets:   prometheus.ExponentialBuckets(0.0005, 4, 8), // From 0.5ms to about 8s
+	}, []string{"operation", "table"})
+
+	catalogSyncs = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "catalog_syncs_total",
+		Help:      "Exercise catalog syncs, by result.",
+	}, []string{"result"})
+
+	catalogSyncExercises = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Name:      "catalog_sync_exercises",
+		Help:      "Exercises created, updated or failed by the last successful catalog sync.",
+	}, []string{"outcome"})
+
+	catalogSyncTime = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Name:      "catalog_last_sync_timestamp_seconds",
+		Help:      "Time of the last successful catalog sync.",
+	})
+)
+
+// CatalogSynced records the result of a catalog sync
+func CatalogSynced(created, updated, failed int, err error) {
+	if err != nil {
+		catalogSyncs.WithLabelValues(SyncFailure).Inc()
+		return
+	}
+
+	catalogSyncs.WithLabelValues(SyncSuccess).Inc()
+	catalogSyncExercises.WithLabelValues("created").Set(float64(created))
+	catalogSyncExercises.WithLabelValues("updated").Set(float64(updated))
+	catalogSyncExercises.WithLabelValues("failed").Set(float64(failed))
+	catalogSyncTime.Set(float64(time.Now().Unix()))
+}
+
+// Register adds a collector to the default registry. A collector of the same
+// metrics that is already registered, like the one of a previous server, is
+// replaced.
+func Register(c prometheus.Collector) error {
+	err := prometheus.Register(c)
+	var registered prometheus.AlreadyRegisteredError
+	if errors.As(err, &registered) {
+		prometheus.Unregister(registered.ExistingCollector)
+		err = prometheus.Register(c)
+	}
+	return err
+}
+
+// Handler serves the metrics in the Prometheus format
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
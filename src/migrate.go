@@ -0,0 +1,98 @@
+package src
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/birabittoh/go-lift/src/config"
+	"github.com/birabittoh/go-lift/src/database"
+)
+
+// runMigrate shows the migration status or applies and reverts migrations
+func runMigrate(cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("migrate", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: go-lift migrate [status | up [version] | down [version]]")
+		fmt.Fprintln(fs.Output(), "  status          list migrations and whether they are applied (default)")
+		fmt.Fprintln(fs.Output(), "  up [version]    apply pending migrations up to version (default: latest)")
+		fmt.Fprintln(fs.Output(), "  down [version]  revert migrations above version (default: the latest applied one)")
+	}
+	fs.Parse(args)
+	if fs.NArg() > 2 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	command := "status"
+	if fs.NArg() > 0 {
+		command = fs.Arg(0)
+	}
+
+	target := -1
+	if fs.NArg() == 2 {
+		v, err := strconv.Atoi(fs.Arg(1))
+		if err != nil {
+			return fmt.Errorf("invalid version: %s", fs.Arg(1))
+		}
+		target = v
+	}
+
+	db, err := database.OpenDB(cfg)
+	if err != nil {
+		return err
+	}
+
+	status, err := db.GetMigrationStatus()
+	if err != nil {
+		return err
+	}
+
+	switch command {
+	case "status":
+	case "up":
+		err = db.MigrateUp(max(target, 0))
+	case "down":
+		if target < 0 {
+			target = previousVersion(status)
+		}
+		err = db.MigrateDown(target)
+	default:
+		fs.Usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		return err
+	}
+
+	if command != "status" {
+		if status, err = db.GetMigrationStatus(); err != nil {
+			return err
+		}
+	}
+
+	for _, m := range status {
+		state := "pending"
+		if m.AppliedAt != nil {
+			state = "applied " + m.AppliedAt.Format("2006-01-02 15:04:05")
+		}
+		if !m.Reversible {
+			state += ", irreversible"
+		}
+		fmt.Printf("%4d  %-30s %s\n", m.Version, m.Name, state)
+	}
+	return nil
+}
+
+// previousVersion is the version before the latest applied migration
+func previousVersion(status []database.MigrationStatus) int {
+	previous, latest := 0, 0
+	for _, m := range status {
+		if m.AppliedAt == nil {
+			continue
+		}
+		previous, latest = latest, m.Version
+	}
+	return previous
+}
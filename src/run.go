@@ -1,35 +1,130 @@
 package src
 
 import (
-	"log"
-	"net/http"
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/birabittoh/go-lift/src/api"
+	"github.com/birabittoh/go-lift/src/config"
 	"github.com/birabittoh/go-lift/src/database"
-	"github.com/joho/godotenv"
+	"github.com/birabittoh/go-lift/src/logging"
 )
 
-func getEnv(key, def string) string {
-	if value, exists := os.LookupEnv(key); exists {
-		return value
+// shutdownTimeout is how long requests in progress can take to finish on shutdown
+const shutdownTimeout = 30 * time.Second
+
+// commands are the subcommands, serve runs when none is given
+var commands = []struct {
+	Name        string
+	Description string
+	Run         func(cfg *config.Config, args []string) error
+}{
+	{"serve", "run the web server (default)", runServe},
+	{"migrate", "show, apply or revert database migrations", runMigrate},
+	{"sync-exercises", "update the exercises from the catalog", runSyncExercises},
+	{"backup", "write a data archive or a copy of the database", runBackup},
+	{"restore", "restore a data archive", runRestore},
+	{"export", "export the finished workouts as CSV", runExport},
+	{"import", "import workouts from a Strong, Hevy or FitNotes CSV export", runImport},
+	{"create-user", "add a user who signs in with a password", runCreateUser},
+	{"reset-password", "set the password of a user", runResetPassword},
+}
+
+func usage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprintln(out, "Usage: go-lift [flags] [command] [arguments]")
+	fmt.Fprintln(out, "\nCommands:")
+	for _, c := range commands {
+		fmt.Fprintf(out, "  %-16s %s\n", c.Name, c.Description)
 	}
-	return def
+	fmt.Fprintln(out, "\nRun go-lift <command> -h for the arguments of a command.\n\nFlags:")
+	flag.PrintDefaults()
 }
 
 func Run() (err error) {
-	godotenv.Load()
+	configPath := flag.String("config", "", "configuration file (default: $APP_CONFIG, or config.yaml if it exists)")
+	printConfig := flag.Bool("print-config", false, "print the configuration and exit")
+	flag.Usage = usage
+	flag.Parse()
 
-	db, err := database.InitializeDB()
+	cfg, err := config.Load(*configPath)
 	if err != nil {
 		return
 	}
+	time.Local = cfg.Location
+	logging.Setup(cfg)
+
+	if *printConfig {
+		return cfg.Print(os.Stdout)
+	}
+
+	args := flag.Args()
+	if len(args) == 0 {
+		return runServe(cfg, nil)
+	}
+
+	for _, c := range commands {
+		if c.Name == args[0] {
+			return c.Run(cfg, args[1:])
+		}
+	}
+
+	usage()
+	return fmt.Errorf("unknown command: %s", args[0])
+}
 
-	listenAddress := getEnv("APP_LISTEN_ADDRESS", ":3000")
+// runServe runs the web server
+func runServe(cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	fs.Parse(args)
 
-	mux := api.GetServeMux(db)
+	db, err := database.InitializeDB(cfg)
+	if err != nil {
+		return err
+	}
+
+	if cfg.AuthMode == config.AuthBasic {
+		ok, err := db.HasCredentials()
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("auth mode %s needs a user with a password, add one with create-user or reset-password", cfg.AuthMode)
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	server := api.NewServer(db)
+	errs := make(chan error, 1)
+	go func() {
+		slog.Info("Listening", "address", cfg.ListenAddress)
+		errs <- server.ListenAndServe()
+	}()
+
+	select {
+	case err = <-errs:
+		db.Close()
+		return err
+	case <-ctx.Done():
+	}
+
+	// Let the requests in progress finish, so that no write is cut off. A
+	// second signal stops the server right away.
+	slog.Info("Shutting down")
+	stop()
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		slog.Warn("Failed to finish requests", "error", err)
+	}
 
-	log.Println("Listening at", listenAddress)
-	err = http.ListenAndServe(listenAddress, mux)
-	return
+	return db.Close()
 }
@@ -0,0 +1,167 @@
+package src
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/birabittoh/go-lift/src/config"
+	"github.com/birabittoh/go-lift/src/database"
+)
+
+// newTestConfig returns the configuration of a SQLite database in a temporary
+// directory, with a single exercise so that the catalog is not downloaded
+func newTestConfig(t *testing.T) *config.Config {
+	t.Helper()
+
+	cfg := config.Default()
+	cfg.DBPath = filepath.Join(t.TempDir(), "fitness.sqlite")
+	cfg.DBLogLevel = config.LogSilent
+
+	db := openTestDB(t, cfg)
+	if err := db.MigrateUp(0); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	squat := database.Exercise{ID: "Barbell_Squat", Name: "Barbell Squat", Level: "beginner", Category: "strength"}
+	if err := db.Create(&squat).Error; err != nil {
+		t.Fatalf("failed to add exercise: %v", err)
+	}
+	if err := db.CheckInitialData(); err != nil {
+		t.Fatalf("failed to add initial data: %v", err)
+	}
+
+	return cfg
+}
+
+// openTestDB opens the database of a configuration, closing it at the end of the test
+func openTestDB(t *testing.T, cfg *config.Config) *database.Database {
+	t.Helper()
+	db, err := database.OpenDB(cfg)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// setStdin replaces the standard input with the given text for the rest of the test
+func setStdin(t *testing.T, input string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "stdin")
+	if err := os.WriteFile(path, []byte(input), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stdin := os.Stdin
+	os.Stdin = f
+	t.Cleanup(func() {
+		os.Stdin = stdin
+		f.Close()
+	})
+}
+
+func TestUserCommands(t *testing.T) {
+	cfg := newTestConfig(t)
+
+	setStdin(t, "correct horse\n")
+	if err := runCreateUser(cfg, []string{"-name", "Alice", "alice"}); err != nil {
+		t.Fatal(err)
+	}
+	setStdin(t, "battery staple\r\n")
+	if err := runResetPassword(cfg, []string{"alice"}); err != nil {
+		t.Fatal(err)
+	}
+	setStdin(t, "battery staple\n")
+	if err := runResetPassword(cfg, []string{"bob"}); err == nil {
+		t.Error("reset the password of a missing user")
+	}
+	setStdin(t, "")
+	if err := runResetPassword(cfg, []string{"alice"}); err == nil {
+		t.Error("reset a password to an empty one")
+	}
+
+	// The user of single-user mode gets a username with -id
+	setStdin(t, "owner password")
+	if err := runResetPassword(cfg, []string{"-id", "1", "owner"}); err != nil {
+		t.Fatal(err)
+	}
+
+	db := openTestDB(t, cfg)
+	tests := []struct {
+		username, password string
+		want               bool
+	}{
+		{"alice", "battery staple", true},
+		{"alice", "correct horse", false},
+		{"owner", "owner password", true},
+	}
+	for _, tt := range tests {
+		user, err := db.AuthenticateUser(tt.username, tt.password)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if (user != nil) != tt.want {
+			t.Errorf("AuthenticateUser(%q, %q) = %v, want success %v", tt.username, tt.password, user, tt.want)
+		}
+	}
+}
+
+func TestExportImportCommands(t *testing.T) {
+	source := newTestConfig(t)
+	completed := time.Date(2024, 3, 4, 18, 0, 0, 0, time.UTC)
+	duration, reps, weight := uint(3600), uint(5), 100.0
+	workout := &database.RecordRoutine{
+		RoutineName: "Legs",
+		Duration:    &duration,
+		CreatedAt:   completed,
+		RecordRoutineItems: []database.RecordRoutineItem{{
+			RecordExerciseItems: []database.RecordExerciseItem{{
+				ExerciseID:   "Barbell_Squat",
+				ExerciseName: "Barbell Squat",
+				RecordSets:   []database.RecordSet{{Reps: &reps, Weight: &weight, CompletedAt: &completed}},
+			}},
+		}},
+	}
+	if err := openTestDB(t, source).Create(workout).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "workouts.csv")
+	if err := runExport(source, []string{"-from", "2024-03-01", "-to", "2024-03-31", "-o", path}); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "Barbell Squat") {
+		t.Fatalf("export = %q, want the squat set", data)
+	}
+	if err := runExport(source, []string{"-format", "fitnotes"}); err == nil {
+		t.Error("exported in an unsupported format")
+	}
+	if err := runExport(source, []string{"-from", "03/01/2024"}); err == nil {
+		t.Error("exported from an invalid date")
+	}
+
+	// Importing twice into another database adds the workout once
+	target := newTestConfig(t)
+	for range 2 {
+		if err := runImport(target, []string{path}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var count int64
+	if err := openTestDB(t, target).Model(&database.RecordSet{}).Count(&count).Error; err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Errorf("imported sets = %d, want 1", count)
+	}
+}
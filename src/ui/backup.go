@@ -0,0 +1,91 @@
+package ui
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+
+	"github.com/birabittoh/go-lift/src/database"
+	g "github.com/birabittoh/go-lift/src/globals"
+)
+
+// ServeArchive sends a data archive as a download. Errors are returned before
+// anything is written, for the caller to report them.
+func ServeArchive(w http.ResponseWriter, db *database.Database) error {
+	archive, err := db.ExportArchive()
+	if err != nil {
+		return fmt.Errorf("failed to export data: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := archive.Write(&buf); err != nil {
+		return fmt.Errorf("failed to write archive: %w", err)
+	}
+
+	w.Header().Set("Content-Type", "application/gzip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", database.BackupFilename(".json.gz")))
+	buf.WriteTo(w)
+	return nil
+}
+
+// ServeDatabase sends a copy of the SQLite database as a download. Errors are
+// returned before anything is written, for the caller to report them.
+func ServeDatabase(w http.ResponseWriter, r *http.Request, db *database.Database) error {
+	path, cleanup, err := db.TempDatabaseBackup()
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	w.Header().Set("Content-Type", "application/vnd.sqlite3")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", database.BackupFilename(".sqlite")))
+	http.ServeFile(w, r, path)
+	return nil
+}
+
+func getBackupArchive(db *database.Database) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := ServeArchive(w, db); err != nil {
+			showError(w, err.Error())
+		}
+	}
+}
+
+func getBackupDatabase(db *database.Database) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := ServeDatabase(w, r, db); err != nil {
+			showError(w, "Failed to back up database: "+err.Error())
+		}
+	}
+}
+
+func postRestoreBackup(db *database.Database) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		mode := r.FormValue("mode")
+		if mode == database.RestoreReplace && !db.Config.IsAdmin(g.User(r)) {
+			showError(w, "Only admin users can replace all the data")
+			return
+		}
+
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			showError(w, "Failed to read uploaded file: "+err.Error())
+			return
+		}
+		defer file.Close()
+
+		archive, err := database.ReadArchive(file)
+		if err != nil {
+			showError(w, "Failed to read archive: "+err.Error())
+			return
+		}
+
+		err = db.RestoreArchive(archive, mode)
+		if err != nil {
+			showError(w, err.Error())
+			return
+		}
+
+		redirect(w, r, "/profile")
+	}
+}
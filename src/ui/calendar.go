@@ -0,0 +1,64 @@
+package ui
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/birabittoh/go-lift/src/database"
+)
+
+// CalendarMonth is a month of schedule days arranged in Monday-first weeks
+type CalendarMonth struct {
+	Title string
+	Prev  string
+	Next  string
+	Weeks [][]*database.ScheduleDay
+}
+
+func newCalendarMonth(month time.Time, days []database.ScheduleDay) *CalendarMonth {
+	c := &CalendarMonth{
+		Title: month.Format("January 2006"),
+		Prev:  month.AddDate(0, -1, 0).Format("2006-01"),
+		Next:  month.AddDate(0, 1, 0).Format("2006-01"),
+	}
+
+	// Pad the first week so that it starts on Monday
+	week := make([]*database.ScheduleDay, (int(month.Weekday())+6)%7)
+	for i := range days {
+		week = append(week, &days[i])
+		if len(week) == 7 {
+			c.Weeks = append(c.Weeks, week)
+			week = nil
+		}
+	}
+	if len(week) > 0 {
+		c.Weeks = append(c.Weeks, append(week, make([]*database.ScheduleDay, 7-len(week))...))
+	}
+
+	return c
+}
+
+func getCalendar(db *database.Database) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		pageData, err := getPageData(db, "calendar")
+		if err != nil {
+			showError(w, "Failed to retrieve page data: "+err.Error())
+			return
+		}
+
+		month, err := time.ParseInLocation("2006-01", r.URL.Query().Get("month"), time.Local)
+		if err != nil {
+			now := time.Now()
+			month = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.Local)
+		}
+
+		days, err := db.GetSchedule(month, month.AddDate(0, 1, -1))
+		if err != nil {
+			showError(w, "Failed to retrieve schedule: "+err.Error())
+			return
+		}
+		pageData.Calendar = newCalendarMonth(month, days)
+
+		executeTemplateSafe(w, calendarPath, pageData)
+	}
+}
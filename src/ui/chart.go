@@ -0,0 +1,79 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	chartWidth   = 600
+	chartHeight  = 200
+	chartPadding = 10
+)
+
+type chartPoint struct {
+	Date  time.Time
+	Value float64
+}
+
+// Chart holds everything needed to render a simple SVG line chart
+type Chart struct {
+	Width  int
+	Height int
+	Points string
+	Min    float64
+	Max    float64
+	From   time.Time
+	To     time.Time
+}
+
+// newChart scales the points to the chart area, oldest on the left
+func newChart(points []chartPoint) *Chart {
+	if len(points) == 0 {
+		return nil
+	}
+
+	c := &Chart{
+		Width:  chartWidth,
+		Height: chartHeight,
+		Min:    points[0].Value,
+		Max:    points[0].Value,
+		From:   points[0].Date,
+		To:     points[0].Date,
+	}
+
+	for _, p := range points {
+		c.Min = min(c.Min, p.Value)
+		c.Max = max(c.Max, p.Value)
+		if p.Date.Before(c.From) {
+			c.From = p.Date
+		}
+		if p.Date.After(c.To) {
+			c.To = p.Date
+		}
+	}
+
+	span := c.To.Sub(c.From).Seconds()
+	valueRange := c.Max - c.Min
+	innerWidth := float64(chartWidth - 2*chartPadding)
+	innerHeight := float64(chartHeight - 2*chartPadding)
+
+	coords := make([]string, 0, len(points))
+	for _, p := range points {
+		x := innerWidth / 2
+		if span > 0 {
+			x = p.Date.Sub(c.From).Seconds() / span * innerWidth
+		}
+
+		y := innerHeight / 2
+		if valueRange > 0 {
+			y = (c.Max - p.Value) / valueRange * innerHeight
+		}
+
+		coords = append(coords, fmt.Sprintf("%.1f,%.1f", x+chartPadding, y+chartPadding))
+	}
+	c.Points = strings.Join(coords, " ")
+
+	return c
+}
@@ -0,0 +1,109 @@
+package ui
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/birabittoh/go-lift/src/database"
+)
+
+func getEquipment(db *database.Database) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		pageData, err := getPageData(db, "profile")
+		if err != nil {
+			showError(w, "Failed to retrieve page data: "+err.Error())
+			return
+		}
+
+		pageData.Inventory, err = db.GetEquipmentInventory(pageData.User)
+		if err != nil {
+			showError(w, "Failed to retrieve equipment: "+err.Error())
+			return
+		}
+
+		err = pageData.User.Units().InventoryFromMetric(pageData.Inventory)
+		if err != nil {
+			showError(w, "Failed to convert equipment: "+err.Error())
+			return
+		}
+
+		pageData.EquipmentTypes, err = db.GetEquipmentTypes()
+		if err != nil {
+			showError(w, "Failed to retrieve equipment types: "+err.Error())
+			return
+		}
+
+		executeTemplateSafe(w, equipmentPath, pageData)
+	}
+}
+
+func postEquipment(db *database.Database) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		user, err := db.GetUserByID(1)
+		if err != nil {
+			showError(w, "Failed to retrieve user: "+err.Error())
+			return
+		}
+
+		inventory, err := db.GetEquipmentInventory(user)
+		if err != nil {
+			showError(w, "Failed to retrieve equipment: "+err.Error())
+			return
+		}
+
+		inventory.BarWeights = r.FormValue("barWeights")
+		inventory.Plates = r.FormValue("plates")
+
+		// Having every type is the same as not restricting exercises
+		types, err := db.GetEquipmentTypes()
+		if err != nil {
+			showError(w, "Failed to retrieve equipment types: "+err.Error())
+			return
+		}
+		available := r.Form["available"]
+		if len(available) == len(types) {
+			available = nil
+		}
+		inventory.Available = strings.Join(available, ", ")
+
+		fields := map[string]*float64{
+			"dumbbellIncrement": &inventory.DumbbellIncrement,
+			"dumbbellMax":       &inventory.DumbbellMax,
+			"machineIncrement":  &inventory.MachineIncrement,
+			"machineMax":        &inventory.MachineMax,
+		}
+		for name, field := range fields {
+			value := r.FormValue(name)
+			if value == "" {
+				*field = 0
+				continue
+			}
+			f, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				showError(w, "Invalid "+name+" value: "+err.Error())
+				return
+			}
+			*field = f
+		}
+
+		err = user.Units().InventoryToMetric(inventory)
+		if err != nil {
+			showError(w, "Invalid equipment: "+err.Error())
+			return
+		}
+
+		err = db.UpdateEquipmentInventory(inventory)
+		if err != nil {
+			showError(w, "Failed to update equipment: "+err.Error())
+			return
+		}
+
+		redirect(w, r, "/profile/equipment")
+	}
+}
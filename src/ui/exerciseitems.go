@@ -183,6 +183,13 @@ func postExerciseItems(db *database.Database) http.HandlerFunc {
 			return
 		}
 
+		user, err := db.GetUserByID(1)
+		if err != nil {
+			showError(w, "Failed to retrieve user: "+err.Error())
+			return
+		}
+		units := user.Units()
+
 		item.RestTime = uint(restTime)
 		item.Notes = r.FormValue("notes")
 
@@ -215,6 +222,7 @@ func postExerciseItems(db *database.Database) http.HandlerFunc {
 					showError(w, fmt.Sprintf("Invalid weight for set %d: %v", i+1, err))
 					return
 				}
+				weight = units.PlannedLoadToKg(weight)
 				set.Weight = &weight
 			} else {
 				set.Weight = nil
@@ -70,6 +70,19 @@ func getExercise(db *database.Database) http.HandlerFunc {
 		}
 		pageData.Exercises = []database.Exercise{*exercise}
 
+		inventory, err := db.GetEquipmentInventory(pageData.User)
+		if err != nil {
+			showError(w, "Failed to retrieve equipment: "+err.Error())
+			return
+		}
+
+		alternatives, err := db.GetExerciseAlternatives(exercise, inventory.AvailableEquipment(), database.MaxAlternatives)
+		if err != nil {
+			showError(w, "Failed to retrieve alternatives: "+err.Error())
+			return
+		}
+		pageData.Alternatives = map[string][]database.ExerciseAlternative{exercise.ID: alternatives}
+
 		executeTemplateSafe(w, exercisePath, pageData)
 	}
 }
@@ -0,0 +1,189 @@
+package ui
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/birabittoh/go-lift/src/database"
+)
+
+const (
+	feedPastDays   = 365
+	feedFutureDays = 84
+
+	icsDate     = "20060102"
+	icsDateTime = "20060102T150405Z"
+)
+
+var icsEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`)
+
+// icsWriter builds an iCalendar document with CRLF line endings and folded lines
+type icsWriter struct {
+	sb strings.Builder
+}
+
+func (w *icsWriter) line(name, value string) {
+	line := name + ":" + value
+	// Fold lines longer than 75 octets without splitting multi-byte characters
+	for len(line) > 75 {
+		cut := 75
+		for cut > 0 && line[cut]&0xC0 == 0x80 {
+			cut--
+		}
+		w.sb.WriteString(line[:cut] + "\r\n")
+		line = " " + line[cut:]
+	}
+	w.sb.WriteString(line + "\r\n")
+}
+
+func (w *icsWriter) text(name, value string) {
+	w.line(name, icsEscaper.Replace(value))
+}
+
+// feedHost is the host name of the request, without the port. IPv6 addresses
+// lose their brackets either way.
+func feedHost(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.Host)
+	if err != nil {
+		return strings.Trim(r.Host, "[]")
+	}
+	return host
+}
+
+func programLabel(name string, week *int) string {
+	if name == "" || week == nil {
+		return ""
+	}
+	return fmt.Sprintf("%s, week %d", name, *week)
+}
+
+func formatDuration(seconds uint) string {
+	d := time.Duration(seconds) * time.Second
+	if d < time.Hour {
+		return fmt.Sprintf("%d min", int(d.Minutes()))
+	}
+	return fmt.Sprintf("%dh %02dmin", int(d.Hours()), int(d.Minutes())%60)
+}
+
+func getCalendarFeed(db *database.Database) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, err := db.GetUserByFeedToken(r.PathValue("token"))
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+
+		now := time.Now()
+		schedule, err := db.GetSchedule(now, now.AddDate(0, 0, feedFutureDays))
+		if err != nil {
+			http.Error(w, "Failed to retrieve schedule", http.StatusInternalServerError)
+			return
+		}
+
+		workouts, err := db.GetWorkoutSummaries(now.AddDate(0, 0, -feedPastDays))
+		if err != nil {
+			http.Error(w, "Failed to retrieve workouts", http.StatusInternalServerError)
+			return
+		}
+
+		host := feedHost(r)
+		units := user.Units()
+		stamp := now.UTC().Format(icsDateTime)
+
+		ics := &icsWriter{}
+		ics.line("BEGIN", "VCALENDAR")
+		ics.line("VERSION", "2.0")
+		ics.line("PRODID", "-//go-lift//Workouts//EN")
+		ics.line("CALSCALE", "GREGORIAN")
+		ics.line("METHOD", "PUBLISH")
+		ics.text("X-WR-CALNAME", "Go Lift")
+
+		// Upcoming sessions are all-day events, as routines are planned by week day
+		for _, day := range schedule {
+			for _, workout := range day.Workouts {
+				if workout.Status != database.ScheduleStatusPlanned {
+					continue
+				}
+
+				ics.line("BEGIN", "VEVENT")
+				ics.line("UID", fmt.Sprintf("routine-%d-%s@%s", workout.RoutineID, day.Date.Format(icsDate), host))
+				ics.line("DTSTAMP", stamp)
+				ics.line("DTSTART;VALUE=DATE", day.Date.Format(icsDate))
+				ics.line("DTEND;VALUE=DATE", day.Date.AddDate(0, 0, 1).Format(icsDate))
+				ics.text("SUMMARY", "🏋️ "+workout.RoutineName)
+				if label := programLabel(workout.ProgramName, workout.ProgramWeek); label != "" {
+					ics.text("DESCRIPTION", label)
+				}
+				ics.line("TRANSP", "TRANSPARENT")
+				ics.line("END", "VEVENT")
+			}
+		}
+
+		// Completed workouts span their actual duration
+		for _, workout := range workouts {
+			description := []string{
+				"Duration: " + formatDuration(workout.Duration),
+				"Volume: " + formatValue(workout.Volume, units.Weight),
+				fmt.Sprintf("Sets: %d", workout.Sets),
+			}
+			if label := programLabel(workout.ProgramName, workout.ProgramWeek); label != "" {
+				description = append(description, "Program: "+label)
+			}
+			if len(workout.Exercises) > 0 {
+				description = append(description, "Exercises: "+strings.Join(workout.Exercises, ", "))
+			}
+
+			end := workout.Start.Add(time.Duration(workout.Duration) * time.Second)
+
+			ics.line("BEGIN", "VEVENT")
+			ics.line("UID", fmt.Sprintf("record-%d@%s", workout.RecordRoutineID, host))
+			ics.line("DTSTAMP", stamp)
+			ics.line("DTSTART", workout.Start.UTC().Format(icsDateTime))
+			ics.line("DTEND", end.UTC().Format(icsDateTime))
+			ics.text("SUMMARY", "✅ "+workout.RoutineName)
+			ics.text("DESCRIPTION", strings.Join(description, "\n"))
+			ics.line("END", "VEVENT")
+		}
+
+		ics.line("END", "VCALENDAR")
+
+		w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+		w.Header().Set("Content-Disposition", `inline; filename="go-lift.ics"`)
+		w.Write([]byte(ics.sb.String()))
+	}
+}
+
+// feedURL returns the address calendar apps should subscribe to, on the
+// public base URL if one is configured
+func feedURL(r *http.Request, baseURL, token string) string {
+	if baseURL != "" {
+		return fmt.Sprintf("%s/calendar/%s/feed.ics", baseURL, token)
+	}
+
+	scheme := "http"
+	if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return fmt.Sprintf("%s://%s/calendar/%s/feed.ics", scheme, r.Host, token)
+}
+
+func postFeedTokenReset(db *database.Database) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, err := db.GetUserByID(1)
+		if err != nil {
+			showError(w, "Failed to retrieve profile: "+err.Error())
+			return
+		}
+
+		_, err = db.ResetFeedToken(user)
+		if err != nil {
+			showError(w, "Failed to reset calendar feed: "+err.Error())
+			return
+		}
+
+		redirect(w, r, "/profile")
+	}
+}
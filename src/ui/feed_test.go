@@ -0,0 +1,83 @@
+package ui
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestICSWriterLine(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+	}{
+		{"short", "Legs"},
+		{"ascii", strings.Repeat("a", 200)},
+		{"two bytes", strings.Repeat("è", 100)},
+		{"three bytes", strings.Repeat("€", 70)},
+		{"four bytes", strings.Repeat("💪", 50)},
+		{"mixed", "Squat " + strings.Repeat("ab€💪", 30)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := &icsWriter{}
+			w.line("SUMMARY", tt.value)
+			out := w.sb.String()
+
+			if !strings.HasSuffix(out, "\r\n") {
+				t.Fatalf("output %q does not end with CRLF", out)
+			}
+			lines := strings.Split(strings.TrimSuffix(out, "\r\n"), "\r\n")
+			var unfolded strings.Builder
+			for i, l := range lines {
+				if len(l) > 75 {
+					t.Errorf("line %d is %d octets long", i, len(l))
+				}
+				if !utf8.ValidString(l) {
+					t.Errorf("line %d splits a character: %q", i, l)
+				}
+				if i > 0 {
+					if !strings.HasPrefix(l, " ") {
+						t.Errorf("continuation line %d does not start with a space", i)
+					}
+					l = l[1:]
+				}
+				unfolded.WriteString(l)
+			}
+			if got := unfolded.String(); got != "SUMMARY:"+tt.value {
+				t.Errorf("unfolded line = %q, want %q", got, "SUMMARY:"+tt.value)
+			}
+		})
+	}
+}
+
+func TestICSWriterText(t *testing.T) {
+	w := &icsWriter{}
+	w.text("DESCRIPTION", "Sets; reps, load\nback\\off")
+	if got, want := w.sb.String(), `DESCRIPTION:Sets\; reps\, load\nback\\off`+"\r\n"; got != want {
+		t.Errorf("text = %q, want %q", got, want)
+	}
+}
+
+func TestFeedHost(t *testing.T) {
+	tests := []struct {
+		host string
+		want string
+	}{
+		{"lift.example.com", "lift.example.com"},
+		{"lift.example.com:3000", "lift.example.com"},
+		{"192.168.1.10:3000", "192.168.1.10"},
+		{"[::1]:3000", "::1"},
+		{"[::1]", "::1"},
+		{"[2001:db8::1]:443", "2001:db8::1"},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/calendar/token/feed.ics", nil)
+		r.Host = tt.host
+		if got := feedHost(r); got != tt.want {
+			t.Errorf("feedHost(%q) = %q, want %q", tt.host, got, tt.want)
+		}
+	}
+}
@@ -1,7 +1,9 @@
 package ui
 
 import (
+	"math"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/birabittoh/go-lift/src/database"
@@ -11,6 +13,33 @@ func redirect(w http.ResponseWriter, r *http.Request, path string) {
 	http.Redirect(w, r, path, http.StatusSeeOther)
 }
 
+func parseOptionalUint(s string) (*uint, error) {
+	if s == "" {
+		return nil, nil
+	}
+	v, err := strconv.ParseUint(s, 10, 32)
+	if err != nil {
+		return nil, err
+	}
+	u := uint(v)
+	return &u, nil
+}
+
+// optional prints a pointer's value, or nothing if it is nil
+func optional(v any) string {
+	switch p := v.(type) {
+	case *uint:
+		if p != nil {
+			return strconv.FormatUint(uint64(*p), 10)
+		}
+	case *float64:
+		if p != nil {
+			return formatFloat(*p)
+		}
+	}
+	return ""
+}
+
 func coalesce(s1 *string, s string) string {
 	if s1 != nil && *s1 != "" {
 		return *s1
@@ -25,6 +54,64 @@ func formatBirthDate(birthdate *time.Time) string {
 	return birthdate.Format("2006-01-02")
 }
 
+func formatDate(t time.Time) string {
+	return t.Format("2006-01-02")
+}
+
+// formatValue converts a canonical value to the given unit for display
+func formatValue(value float64, unit string) string {
+	return database.FormatValue(database.FromCanonical(value, unit), unit)
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// loadValue formats a lifted weight (stored in kg) in the user's unit, to two decimals
+func loadValue(kg *float64, user *database.User) string {
+	if kg == nil {
+		return ""
+	}
+	return formatFloat(math.Round(user.Units().LoadFromKg(*kg)*100) / 100)
+}
+
+// plannedLoadValue formats a planned weight (stored in kg) in the user's unit, rounded to plates
+func plannedLoadValue(kg *float64, user *database.User) string {
+	if kg == nil {
+		return ""
+	}
+	return formatFloat(user.Units().PlannedLoadFromKg(*kg))
+}
+
+// weightValue formats a body weight (stored in kg) in the user's unit
+func weightValue(kg *float64, user *database.User) string {
+	if kg == nil {
+		return ""
+	}
+	return formatFloat(user.Units().WeightFromKg(*kg))
+}
+
+// lengthValue formats a length (stored in cm) in the user's unit
+func lengthValue(cm *float64, user *database.User) string {
+	if cm == nil {
+		return ""
+	}
+	return formatFloat(user.Units().LengthFromCm(*cm))
+}
+
+// plateLoad describes how to load the bar for a weight in the user's unit
+func plateLoad(kg *float64, exercise database.Exercise, inventory *database.EquipmentInventory, user *database.User) string {
+	if kg == nil {
+		return ""
+	}
+	return database.FormatPlateLoad(inventory.LoadBreakdown(*kg, exercise.Equipment), user.Units().Weight)
+}
+
+func isToday(t time.Time) bool {
+	now := time.Now()
+	return t.Year() == now.Year() && t.YearDay() == now.YearDay()
+}
+
 func formatDay(day string) string {
 	// only return the first three letters of the day
 	if len(day) < 3 {
@@ -14,6 +14,18 @@ func getHome(db *database.Database) http.HandlerFunc {
 			return
 		}
 
+		pageData.Today, err = db.GetTodaysWorkout()
+		if err != nil {
+			showError(w, "Failed to retrieve schedule: "+err.Error())
+			return
+		}
+
+		pageData.ActiveProgram, err = db.GetActiveProgram()
+		if err != nil {
+			showError(w, "Failed to retrieve program: "+err.Error())
+			return
+		}
+
 		executeTemplateSafe(w, homePath, pageData)
 	}
 }
@@ -0,0 +1,245 @@
+package ui
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/birabittoh/go-lift/src/database"
+	g "github.com/birabittoh/go-lift/src/globals"
+)
+
+func getMeasurements(db *database.Database) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		pageData, err := getPageData(db, "measurements")
+		if err != nil {
+			showError(w, "Failed to retrieve page data: "+err.Error())
+			return
+		}
+
+		pageData.BodyMetrics, err = db.GetBodyMetrics()
+		if err != nil {
+			showError(w, "Failed to retrieve measurements: "+err.Error())
+			return
+		}
+
+		pageData.Units = db.GetUnits()
+
+		executeTemplateSafe(w, measurementsPath, pageData)
+	}
+}
+
+func getMeasurement(db *database.Database) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		pageData, err := getPageData(db, "measurements")
+		if err != nil {
+			showError(w, "Failed to retrieve page data: "+err.Error())
+			return
+		}
+
+		id, err := g.GetIDFromPath(r)
+		if err != nil {
+			showError(w, "Invalid metric ID: "+err.Error())
+			return
+		}
+
+		metric, err := db.GetBodyMetricByID(id)
+		if err != nil {
+			showError(w, "Failed to retrieve metric: "+err.Error())
+			return
+		}
+		pageData.BodyMetrics = []database.BodyMetric{*metric}
+
+		points := make([]chartPoint, 0, len(metric.BodyMeasurements))
+		for _, m := range metric.BodyMeasurements {
+			points = append(points, chartPoint{Date: m.Date, Value: database.FromCanonical(m.Value, metric.Unit)})
+		}
+		pageData.Chart = newChart(points)
+
+		pageData.Units = db.GetUnits()
+
+		executeTemplateSafe(w, measurementPath, pageData)
+	}
+}
+
+func postAddBodyMetric(db *database.Database) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		metric := &database.BodyMetric{
+			Name: r.FormValue("name"),
+			Unit: r.FormValue("unit"),
+		}
+
+		err := db.NewBodyMetric(metric)
+		if err != nil {
+			showError(w, "Failed to create metric: "+err.Error())
+			return
+		}
+
+		redirect(w, r, fmt.Sprintf("/measurements/%d", metric.ID))
+	}
+}
+
+func postBodyMetric(db *database.Database) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		id, err := g.GetIDFromPath(r)
+		if err != nil {
+			showError(w, "Invalid metric ID: "+err.Error())
+			return
+		}
+
+		metric, err := db.GetBodyMetricByID(id)
+		if err != nil {
+			showError(w, "Failed to retrieve metric: "+err.Error())
+			return
+		}
+
+		metric.Name = r.FormValue("name")
+		metric.Unit = r.FormValue("unit")
+
+		err = db.UpdateBodyMetric(metric)
+		if err != nil {
+			showError(w, "Failed to update metric: "+err.Error())
+			return
+		}
+
+		redirect(w, r, fmt.Sprintf("/measurements/%d", metric.ID))
+	}
+}
+
+func postBodyMetricDelete(db *database.Database) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		id, err := g.GetIDFromPath(r)
+		if err != nil {
+			showError(w, "Invalid metric ID: "+err.Error())
+			return
+		}
+
+		err = db.DeleteBodyMetric(id)
+		if err != nil {
+			showError(w, "Failed to delete metric: "+err.Error())
+			return
+		}
+
+		redirect(w, r, "/measurements")
+	}
+}
+
+func postAddBodyMeasurement(db *database.Database) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		id, err := g.GetIDFromPath(r)
+		if err != nil {
+			showError(w, "Invalid metric ID: "+err.Error())
+			return
+		}
+
+		metric, err := db.GetBodyMetricByID(id)
+		if err != nil {
+			showError(w, "Failed to retrieve metric: "+err.Error())
+			return
+		}
+
+		value, err := strconv.ParseFloat(r.FormValue("value"), 64)
+		if err != nil {
+			showError(w, "Invalid value: "+err.Error())
+			return
+		}
+
+		date, err := time.ParseInLocation("2006-01-02", r.FormValue("date"), time.Local)
+		if err != nil {
+			date = time.Now()
+		}
+
+		_, err = db.NewBodyMeasurement(metric, value, date, r.FormValue("notes"))
+		if err != nil {
+			showError(w, "Failed to save measurement: "+err.Error())
+			return
+		}
+
+		// get "page" query parameter
+		if r.URL.Query().Get("page") == "measurements" {
+			redirect(w, r, "/measurements")
+			return
+		}
+
+		redirect(w, r, fmt.Sprintf("/measurements/%d", metric.ID))
+	}
+}
+
+func postBodyMeasurementDelete(db *database.Database) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		id, err := g.GetIDFromPath(r)
+		if err != nil {
+			showError(w, "Invalid measurement ID: "+err.Error())
+			return
+		}
+
+		measurement, err := db.GetBodyMeasurementByID(id)
+		if err != nil {
+			showError(w, "Measurement not found")
+			return
+		}
+
+		metricID, err := db.DeleteBodyMeasurement(measurement)
+		if err != nil {
+			showError(w, "Failed to delete measurement: "+err.Error())
+			return
+		}
+
+		redirect(w, r, fmt.Sprintf("/measurements/%d", metricID))
+	}
+}
+
+func postImportBodyMeasurements(db *database.Database) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			showError(w, "Failed to read uploaded file: "+err.Error())
+			return
+		}
+		defer file.Close()
+
+		result, err := db.ImportBodyMeasurementsCSV(file)
+		if err != nil {
+			showError(w, "Failed to import measurements: "+err.Error())
+			return
+		}
+
+		if len(result.Errors) > 0 {
+			showError(w, fmt.Sprintf("Imported %d measurements with %d errors: %v", result.Imported, len(result.Errors), result.Errors))
+			return
+		}
+
+		redirect(w, r, "/measurements")
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/birabittoh/go-lift/src/database"
+	g "github.com/birabittoh/go-lift/src/globals"
 )
 
 func getProfile(db *database.Database) http.HandlerFunc {
@@ -22,6 +23,15 @@ func getProfile(db *database.Database) http.HandlerFunc {
 			return
 		}
 
+		token, err := db.GetFeedToken(pageData.User)
+		if err != nil {
+			showError(w, "Failed to retrieve calendar feed: "+err.Error())
+			return
+		}
+		pageData.FeedURL = feedURL(r, db.Config.BaseURL, token)
+		pageData.SQLite = db.IsSQLite()
+		pageData.Admin = db.Config.IsAdmin(g.User(r))
+
 		executeTemplateSafe(w, profilePath, pageData)
 	}
 }
@@ -65,9 +75,14 @@ func postProfileEdit(db *database.Database) http.HandlerFunc {
 			return
 		}
 
+		// Values are entered in the units the form was rendered with
+		units := user.Units()
+
 		// Update user fields from form
 		user.Name = r.FormValue("name")
 		user.IsFemale = r.FormValue("isFemale") == "true"
+		user.WeightUnit = r.FormValue("weightUnit")
+		user.LengthUnit = r.FormValue("lengthUnit")
 
 		heightValue := r.FormValue("height")
 		if heightValue == "" {
@@ -78,6 +93,7 @@ func postProfileEdit(db *database.Database) http.HandlerFunc {
 				showError(w, "Invalid height value: "+err.Error())
 				return
 			}
+			height = units.LengthToCm(height)
 			user.Height = &height
 		}
 
@@ -90,6 +106,7 @@ func postProfileEdit(db *database.Database) http.HandlerFunc {
 				showError(w, "Invalid weight value: "+err.Error())
 				return
 			}
+			weight = units.WeightToKg(weight)
 			user.Weight = &weight
 		}
 
@@ -111,3 +128,21 @@ func postProfileEdit(db *database.Database) http.HandlerFunc {
 		redirect(w, r, "/profile")
 	}
 }
+
+func postProfileCredentials(db *database.Database) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, err := db.GetUserByID(1)
+		if err != nil {
+			showError(w, "Failed to retrieve profile: "+err.Error())
+			return
+		}
+
+		err = db.SetUserCredentials(user, r.FormValue("username"), r.FormValue("password"))
+		if err != nil {
+			showError(w, "Failed to save credentials: "+err.Error())
+			return
+		}
+
+		redirect(w, r, "/profile")
+	}
+}
@@ -0,0 +1,298 @@
+package ui
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/birabittoh/go-lift/src/database"
+	g "github.com/birabittoh/go-lift/src/globals"
+)
+
+func getPrograms(db *database.Database) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		pageData, err := getPageData(db, "routines")
+		if err != nil {
+			showError(w, "Failed to retrieve page data: "+err.Error())
+			return
+		}
+
+		pageData.Programs, err = db.GetPrograms()
+		if err != nil {
+			showError(w, "Failed to retrieve programs: "+err.Error())
+			return
+		}
+
+		executeTemplateSafe(w, programsPath, pageData)
+	}
+}
+
+func getProgram(db *database.Database) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		pageData, err := getPageData(db, "routines")
+		if err != nil {
+			showError(w, "Failed to retrieve page data: "+err.Error())
+			return
+		}
+
+		id, err := g.GetIDFromPath(r)
+		if err != nil {
+			showError(w, "Invalid program ID: "+err.Error())
+			return
+		}
+
+		program, err := db.GetProgramByID(id)
+		if err != nil {
+			showError(w, "Failed to retrieve program: "+err.Error())
+			return
+		}
+		pageData.Programs = []database.Program{*program}
+
+		pageData.Routines, err = db.GetRoutines()
+		if err != nil {
+			showError(w, "Failed to retrieve routines: "+err.Error())
+			return
+		}
+
+		executeTemplateSafe(w, programPath, pageData)
+	}
+}
+
+func postAddProgram(db *database.Database) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		program := &database.Program{
+			Name: "New Program",
+		}
+
+		err := db.NewProgram(program)
+		if err != nil {
+			showError(w, "Failed to create new program: "+err.Error())
+			return
+		}
+
+		redirect(w, r, fmt.Sprintf("/programs/%d", program.ID))
+	}
+}
+
+func postProgramDelete(db *database.Database) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := g.GetIDFromPath(r)
+		if err != nil {
+			showError(w, "Invalid program ID: "+err.Error())
+			return
+		}
+
+		err = db.DeleteProgram(id)
+		if err != nil {
+			showError(w, "Failed to delete program: "+err.Error())
+			return
+		}
+
+		redirect(w, r, "/programs")
+	}
+}
+
+func postProgram(db *database.Database) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := g.GetIDFromPath(r)
+		if err != nil {
+			showError(w, "Invalid program ID: "+err.Error())
+			return
+		}
+
+		program, err := db.GetProgramByID(id)
+		if err != nil {
+			showError(w, "Failed to retrieve program: "+err.Error())
+			return
+		}
+
+		program.Name = r.FormValue("name")
+		program.Description = r.FormValue("description")
+
+		length, err := strconv.Atoi(r.FormValue("weeks"))
+		if err != nil {
+			showError(w, "Invalid number of weeks: "+err.Error())
+			return
+		}
+
+		err = db.UpdateProgram(program)
+		if err != nil {
+			showError(w, "Failed to update program: "+err.Error())
+			return
+		}
+
+		err = db.ResizeProgram(program, length)
+		if err != nil {
+			showError(w, "Failed to update program weeks: "+err.Error())
+			return
+		}
+
+		redirect(w, r, fmt.Sprintf("/programs/%d", program.ID))
+	}
+}
+
+func postProgramWeeks(db *database.Database) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := g.GetIDFromPath(r)
+		if err != nil {
+			showError(w, "Invalid program ID: "+err.Error())
+			return
+		}
+
+		program, err := db.GetProgramByID(id)
+		if err != nil {
+			showError(w, "Failed to retrieve program: "+err.Error())
+			return
+		}
+
+		weeks := make([]database.ProgramWeek, 0, len(program.Weeks))
+		for _, week := range program.Weeks {
+			prefix := fmt.Sprintf("weeks[%d]", week.WeekNumber)
+
+			intensity, err := strconv.ParseFloat(r.FormValue(prefix+"[intensity]"), 64)
+			if err != nil {
+				showError(w, "Invalid intensity: "+err.Error())
+				return
+			}
+
+			volume, err := strconv.ParseFloat(r.FormValue(prefix+"[volume]"), 64)
+			if err != nil {
+				showError(w, "Invalid volume multiplier: "+err.Error())
+				return
+			}
+
+			weeks = append(weeks, database.ProgramWeek{
+				WeekNumber:       week.WeekNumber,
+				Intensity:        intensity,
+				VolumeMultiplier: volume,
+				Deload:           r.FormValue(prefix+"[deload]") == "on",
+			})
+		}
+
+		err = db.UpdateProgramWeeks(program, weeks)
+		if err != nil {
+			showError(w, "Failed to update program weeks: "+err.Error())
+			return
+		}
+
+		redirect(w, r, fmt.Sprintf("/programs/%d", program.ID))
+	}
+}
+
+// programRoutineIDs returns the IDs of the weekly routines of a program, in order
+func programRoutineIDs(program *database.Program) []uint {
+	ids := make([]uint, 0, len(program.Routines))
+	for _, pr := range program.Routines {
+		ids = append(ids, pr.RoutineID)
+	}
+	return ids
+}
+
+func postAddProgramRoutine(db *database.Database) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := g.GetIDFromPath(r)
+		if err != nil {
+			showError(w, "Invalid program ID: "+err.Error())
+			return
+		}
+
+		program, err := db.GetProgramByID(id)
+		if err != nil {
+			showError(w, "Failed to retrieve program: "+err.Error())
+			return
+		}
+
+		routineID, err := strconv.ParseUint(r.FormValue("routine"), 10, 64)
+		if err != nil {
+			showError(w, "Invalid routine ID: "+err.Error())
+			return
+		}
+
+		err = db.UpdateProgramRoutines(program, append(programRoutineIDs(program), uint(routineID)))
+		if err != nil {
+			showError(w, "Failed to add routine: "+err.Error())
+			return
+		}
+
+		redirect(w, r, fmt.Sprintf("/programs/%d", program.ID))
+	}
+}
+
+func postProgramRoutineDelete(db *database.Database) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := g.GetIDFromPath(r)
+		if err != nil {
+			showError(w, "Invalid program ID: "+err.Error())
+			return
+		}
+
+		program, err := db.GetProgramByID(id)
+		if err != nil {
+			showError(w, "Failed to retrieve program: "+err.Error())
+			return
+		}
+
+		ids := programRoutineIDs(program)
+		index, err := strconv.Atoi(r.PathValue("index"))
+		if err != nil || index < 0 || index >= len(ids) {
+			showError(w, "Invalid routine index")
+			return
+		}
+
+		err = db.UpdateProgramRoutines(program, append(ids[:index], ids[index+1:]...))
+		if err != nil {
+			showError(w, "Failed to remove routine: "+err.Error())
+			return
+		}
+
+		redirect(w, r, fmt.Sprintf("/programs/%d", program.ID))
+	}
+}
+
+func postProgramStart(db *database.Database) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := g.GetIDFromPath(r)
+		if err != nil {
+			showError(w, "Invalid program ID: "+err.Error())
+			return
+		}
+
+		program, err := db.GetProgramByID(id)
+		if err != nil {
+			showError(w, "Failed to retrieve program: "+err.Error())
+			return
+		}
+
+		err = db.StartProgram(program)
+		if err != nil {
+			showError(w, "Failed to start program: "+err.Error())
+			return
+		}
+
+		redirect(w, r, fmt.Sprintf("/programs/%d", program.ID))
+	}
+}
+
+func postProgramStop(db *database.Database) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := g.GetIDFromPath(r)
+		if err != nil {
+			showError(w, "Invalid program ID: "+err.Error())
+			return
+		}
+
+		program, err := db.GetProgramByID(id)
+		if err != nil {
+			showError(w, "Failed to retrieve program: "+err.Error())
+			return
+		}
+
+		err = db.StopProgram(program)
+		if err != nil {
+			showError(w, "Failed to stop program: "+err.Error())
+			return
+		}
+
+		redirect(w, r, fmt.Sprintf("/programs/%d", program.ID))
+	}
+}
@@ -3,6 +3,8 @@ package ui
 import (
 	"fmt"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/birabittoh/go-lift/src/database"
 	g "github.com/birabittoh/go-lift/src/globals"
@@ -77,3 +79,363 @@ func postRecordRoutinesDelete(db *database.Database) http.HandlerFunc {
 		redirect(w, r, "/"+page)
 	}
 }
+
+func getRecordRoutine(db *database.Database) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		pageData, err := getPageData(db, "workout")
+		if err != nil {
+			showError(w, "Failed to retrieve page data: "+err.Error())
+			return
+		}
+
+		id, err := g.GetIDFromPath(r)
+		if err != nil {
+			showError(w, "Invalid record routine ID: "+err.Error())
+			return
+		}
+
+		record, err := db.GetRecordRoutineByID(id)
+		if err != nil {
+			showError(w, "Failed to retrieve workout: "+err.Error())
+			return
+		}
+		pageData.RecordRoutines = []database.RecordRoutine{*record}
+
+		volume, err := db.GetRecordRoutineVolume(record)
+		if err != nil {
+			showError(w, "Failed to compute volume: "+err.Error())
+			return
+		}
+		pageData.Volume = &volume
+
+		pageData.Inventory, err = db.GetEquipmentInventory(pageData.User)
+		if err != nil {
+			showError(w, "Failed to retrieve equipment: "+err.Error())
+			return
+		}
+
+		// Exercises can be added or swapped while the workout is in progress
+		if record.Duration == nil {
+			pageData.Exercises, err = db.GetExercises()
+			if err != nil {
+				showError(w, "Failed to retrieve exercises: "+err.Error())
+				return
+			}
+
+			available := pageData.Inventory.AvailableEquipment()
+			pageData.Alternatives = map[string][]database.ExerciseAlternative{}
+			for _, rri := range record.RecordRoutineItems {
+				for _, rei := range rri.RecordExerciseItems {
+					pageData.Alternatives[rei.ExerciseID] = database.RankAlternatives(&rei.Exercise, pageData.Exercises, available, database.MaxAlternatives)
+				}
+			}
+		}
+
+		executeTemplateSafe(w, workoutPath, pageData)
+	}
+}
+
+// saveRecordSets stores the sets posted with the workout form
+func saveRecordSets(db *database.Database, r *http.Request, record *database.RecordRoutine) error {
+	if err := r.ParseForm(); err != nil {
+		return fmt.Errorf("failed to parse form: %w", err)
+	}
+
+	user, err := db.GetUserByID(1)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve user: %w", err)
+	}
+	units := user.Units()
+
+	for _, rri := range record.RecordRoutineItems {
+		for _, rei := range rri.RecordExerciseItems {
+			// Sets are updated in place, so the record reflects the form
+			for i := range rei.RecordSets {
+				set := &rei.RecordSets[i]
+				prefix := fmt.Sprintf("sets[%d]", set.ID)
+
+				set.Reps, err = parseOptionalUint(r.FormValue(prefix + "[reps]"))
+				if err != nil {
+					return fmt.Errorf("invalid reps for set %d: %v", set.ID, err)
+				}
+
+				set.Duration, err = parseOptionalUint(r.FormValue(prefix + "[duration]"))
+				if err != nil {
+					return fmt.Errorf("invalid duration for set %d: %v", set.ID, err)
+				}
+
+				weightStr := r.FormValue(prefix + "[weight]")
+				if weightStr != "" {
+					weight, err := strconv.ParseFloat(weightStr, 64)
+					if err != nil {
+						return fmt.Errorf("invalid weight for set %d: %v", set.ID, err)
+					}
+					weight = units.LoadToKg(weight)
+					set.Weight = &weight
+				} else {
+					set.Weight = nil
+				}
+
+				rpeStr := r.FormValue(prefix + "[rpe]")
+				if rpeStr != "" {
+					rpe, err := strconv.ParseFloat(rpeStr, 64)
+					if err != nil {
+						return fmt.Errorf("invalid RPE for set %d: %v", set.ID, err)
+					}
+					set.RPE = &rpe
+				} else {
+					set.RPE = nil
+				}
+
+				if r.FormValue(prefix+"[done]") == "on" {
+					if set.CompletedAt == nil {
+						now := time.Now()
+						set.CompletedAt = &now
+					}
+				} else {
+					set.CompletedAt = nil
+				}
+
+				if err := db.UpdateRecordSet(set); err != nil {
+					return fmt.Errorf("failed to update set %d: %v", set.ID, err)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+func postRecordRoutine(db *database.Database) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		id, err := g.GetIDFromPath(r)
+		if err != nil {
+			showError(w, "Invalid record routine ID: "+err.Error())
+			return
+		}
+
+		record, err := db.GetRecordRoutineByID(id)
+		if err != nil {
+			showError(w, "Record routine not found")
+			return
+		}
+
+		if err := saveRecordSets(db, r, record); err != nil {
+			showError(w, g.Capitalize(err.Error()))
+			return
+		}
+
+		redirect(w, r, fmt.Sprintf("/record-routines/%d", record.ID))
+	}
+}
+
+func postAddFreestyleRecordRoutine(db *database.Database) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		rr, err := db.NewFreestyleRecordRoutine(r.FormValue("name"))
+		if err != nil {
+			showError(w, "Failed to start workout: "+err.Error())
+			return
+		}
+
+		redirect(w, r, fmt.Sprintf("/record-routines/%d", rr.ID))
+	}
+}
+
+// postRecordSession saves the workout form, then applies a change to the
+// session. The buttons of the workout page post the whole form, so that sets
+// entered so far are not lost.
+func postRecordSession(db *database.Database, action func(r *http.Request, record *database.RecordRoutine) error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		id, err := g.GetIDFromPath(r)
+		if err != nil {
+			showError(w, "Invalid record routine ID: "+err.Error())
+			return
+		}
+
+		record, err := db.GetRecordRoutineByID(id)
+		if err != nil {
+			showError(w, "Record routine not found")
+			return
+		}
+
+		if err := saveRecordSets(db, r, record); err != nil {
+			showError(w, g.Capitalize(err.Error()))
+			return
+		}
+
+		if err := action(r, record); err != nil {
+			showError(w, g.Capitalize(err.Error()))
+			return
+		}
+
+		redirect(w, r, fmt.Sprintf("/record-routines/%d", record.ID))
+	}
+}
+
+// formItemID reads the ID sent by the pressed button
+func formItemID(r *http.Request) (uint, error) {
+	id, err := strconv.ParseUint(r.FormValue("item"), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid item ID: %w", err)
+	}
+	return uint(id), nil
+}
+
+func postAddRecordExercise(db *database.Database) http.HandlerFunc {
+	return postRecordSession(db, func(r *http.Request, record *database.RecordRoutine) error {
+		if _, err := db.AddRecordExercise(record, r.FormValue("exerciseId")); err != nil {
+			return fmt.Errorf("failed to add exercise: %w", err)
+		}
+		return nil
+	})
+}
+
+func postAddRecordSet(db *database.Database) http.HandlerFunc {
+	return postRecordSession(db, func(r *http.Request, record *database.RecordRoutine) error {
+		itemID, err := formItemID(r)
+		if err != nil {
+			return err
+		}
+
+		if _, err := db.AddRecordSet(record, itemID); err != nil {
+			return fmt.Errorf("failed to add set: %w", err)
+		}
+		return nil
+	})
+}
+
+func postRecordExerciseSwap(db *database.Database) http.HandlerFunc {
+	return postRecordSession(db, func(r *http.Request, record *database.RecordRoutine) error {
+		itemID, err := formItemID(r)
+		if err != nil {
+			return err
+		}
+
+		exerciseID := r.FormValue(fmt.Sprintf("swap[%d]", itemID))
+		if err := db.SwapRecordExercise(record, itemID, exerciseID); err != nil {
+			return fmt.Errorf("failed to swap exercise: %w", err)
+		}
+		return nil
+	})
+}
+
+func postRecordExerciseSkip(db *database.Database) http.HandlerFunc {
+	return postRecordSession(db, func(r *http.Request, record *database.RecordRoutine) error {
+		itemID, err := formItemID(r)
+		if err != nil {
+			return err
+		}
+
+		if err := db.SkipRecordExercise(record, itemID, r.FormValue("skip") == "true"); err != nil {
+			return fmt.Errorf("failed to skip exercise: %w", err)
+		}
+		return nil
+	})
+}
+
+func postRecordRoutineItemUp(db *database.Database) http.HandlerFunc {
+	return postRecordSession(db, func(r *http.Request, record *database.RecordRoutine) error {
+		itemID, err := formItemID(r)
+		if err != nil {
+			return err
+		}
+
+		if err := db.MoveRecordRoutineItem(record, itemID, true); err != nil {
+			return fmt.Errorf("failed to move item up: %w", err)
+		}
+		return nil
+	})
+}
+
+func postRecordRoutineItemDown(db *database.Database) http.HandlerFunc {
+	return postRecordSession(db, func(r *http.Request, record *database.RecordRoutine) error {
+		itemID, err := formItemID(r)
+		if err != nil {
+			return err
+		}
+
+		if err := db.MoveRecordRoutineItem(record, itemID, false); err != nil {
+			return fmt.Errorf("failed to move item down: %w", err)
+		}
+		return nil
+	})
+}
+
+func postRecordRoutineSaveRoutine(db *database.Database) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		id, err := g.GetIDFromPath(r)
+		if err != nil {
+			showError(w, "Invalid record routine ID: "+err.Error())
+			return
+		}
+
+		record, err := db.GetRecordRoutineByID(id)
+		if err != nil {
+			showError(w, "Record routine not found")
+			return
+		}
+
+		routine, err := db.SaveRecordAsRoutine(record, r.FormValue("name"))
+		if err != nil {
+			showError(w, "Failed to save routine: "+err.Error())
+			return
+		}
+
+		redirect(w, r, fmt.Sprintf("/routines/%d", routine.ID))
+	}
+}
+
+func postRecordRoutineFinish(db *database.Database) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		id, err := g.GetIDFromPath(r)
+		if err != nil {
+			showError(w, "Invalid record routine ID: "+err.Error())
+			return
+		}
+
+		record, err := db.GetRecordRoutineByID(id)
+		if err != nil {
+			showError(w, "Record routine not found")
+			return
+		}
+
+		err = db.FinishRecordRoutine(record)
+		if err != nil {
+			showError(w, "Failed to finish workout: "+err.Error())
+			return
+		}
+
+		// Workouts without a routine can be saved as one
+		if record.RoutineID == nil {
+			redirect(w, r, fmt.Sprintf("/record-routines/%d", record.ID))
+			return
+		}
+
+		redirect(w, r, "/")
+	}
+}
@@ -1,8 +1,13 @@
 package ui
 
 import (
+	"bytes"
 	"fmt"
+	"io"
 	"net/http"
+	"strconv"
+	"strings"
+	"unicode"
 
 	"github.com/birabittoh/go-lift/src/database"
 	g "github.com/birabittoh/go-lift/src/globals"
@@ -22,6 +27,12 @@ func getRoutines(db *database.Database) http.HandlerFunc {
 			return
 		}
 
+		pageData.Estimates, err = db.GetRoutineEstimates()
+		if err != nil {
+			showError(w, "Failed to estimate routines: "+err.Error())
+			return
+		}
+
 		pageData.CurrentWorkout = db.GetCurrentWorkout()
 
 		executeTemplateSafe(w, routinesPath, pageData)
@@ -49,8 +60,21 @@ func getRoutine(db *database.Database) http.HandlerFunc {
 		}
 		pageData.Routines = []database.Routine{*routine}
 
+		estimate, err := db.GetRoutineEstimate(routine)
+		if err != nil {
+			showError(w, "Failed to estimate routine: "+err.Error())
+			return
+		}
+		pageData.Estimates = map[uint]*database.RoutineEstimate{routine.ID: estimate}
+
 		pageData.Days = db.GetDays()
 
+		pageData.Inventory, err = db.GetEquipmentInventory(pageData.User)
+		if err != nil {
+			showError(w, "Failed to retrieve equipment: "+err.Error())
+			return
+		}
+
 		executeTemplateSafe(w, routinePath, pageData)
 	}
 }
@@ -73,6 +97,94 @@ func postAddRoutines(db *database.Database) http.HandlerFunc {
 	}
 }
 
+func postRoutineClone(db *database.Database) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := g.GetIDFromPath(r)
+		if err != nil {
+			showError(w, "Invalid routine ID: "+err.Error())
+			return
+		}
+
+		routine, err := db.GetRoutineByID(id)
+		if err != nil {
+			showError(w, "Failed to retrieve routine: "+err.Error())
+			return
+		}
+
+		// Using a template keeps its name, any other copy is renamed
+		isTemplate := r.FormValue("template") == "on"
+		name := routine.Name
+		if !routine.IsTemplate || isTemplate {
+			name = g.Truncate(name+" (copy)", 100)
+		}
+
+		clone, err := db.CloneRoutine(routine, name, isTemplate)
+		if err != nil {
+			showError(w, "Failed to clone routine: "+err.Error())
+			return
+		}
+
+		redirect(w, r, fmt.Sprintf("/routines/%d", clone.ID))
+	}
+}
+
+// RoutineVersionView is a routine version along with what changed since the previous one
+type RoutineVersionView struct {
+	database.RoutineVersion
+	Changes []string
+	Records int
+}
+
+func getRoutineHistory(db *database.Database) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		pageData, err := getPageData(db, "routines")
+		if err != nil {
+			showError(w, "Failed to retrieve page data: "+err.Error())
+			return
+		}
+
+		id, err := g.GetIDFromPath(r)
+		if err != nil {
+			showError(w, "Invalid routine ID: "+err.Error())
+			return
+		}
+
+		routine, err := db.GetRoutineByID(id)
+		if err != nil {
+			showError(w, "Failed to retrieve routine: "+err.Error())
+			return
+		}
+		pageData.Routines = []database.Routine{*routine}
+
+		versions, err := db.GetRoutineVersions(id)
+		if err != nil {
+			showError(w, "Failed to retrieve routine versions: "+err.Error())
+			return
+		}
+
+		counts, err := db.CountRoutineVersionRecords(id)
+		if err != nil {
+			showError(w, "Failed to retrieve workouts: "+err.Error())
+			return
+		}
+
+		// Newest first
+		for i := len(versions) - 1; i >= 0; i-- {
+			var prev *database.RoutineSnapshot
+			if i > 0 {
+				prev = &versions[i-1].Snapshot
+			}
+			pageData.Versions = append(pageData.Versions, RoutineVersionView{
+				RoutineVersion: versions[i],
+				Changes:        versions[i].Snapshot.Changes(prev),
+				Records:        counts[versions[i].ID],
+			})
+		}
+
+		executeTemplateSafe(w, routineHistoryPath, pageData)
+	}
+}
+
 func postRoutinesDelete(db *database.Database) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		id, err := g.GetIDFromPath(r)
@@ -112,6 +224,7 @@ func postRoutines(db *database.Database) http.HandlerFunc {
 
 		routine.Name = r.FormValue("name")
 		routine.Description = r.FormValue("description")
+		routine.IsTemplate = r.FormValue("isTemplate") == "on"
 
 		weekDays := db.GetDays()
 		var days []string
@@ -141,3 +254,161 @@ func postRoutines(db *database.Database) http.HandlerFunc {
 		redirect(w, r, fmt.Sprintf("/routines/%d", routine.ID))
 	}
 }
+
+// exportFilename turns a routine name into a safe file name
+func exportFilename(name, format string) string {
+	slug := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return '-'
+	}, name)
+	slug = strings.Trim(slug, "-")
+	if slug == "" {
+		slug = "routine"
+	}
+	return slug + ".routine." + format
+}
+
+func getRoutineExport(db *database.Database) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := g.GetIDFromPath(r)
+		if err != nil {
+			showError(w, "Invalid routine ID: "+err.Error())
+			return
+		}
+
+		format := r.URL.Query().Get("format")
+		if format == "" {
+			format = "yaml"
+		}
+		if format != "json" && format != "yaml" {
+			showError(w, "Unsupported export format: "+format)
+			return
+		}
+
+		routine, err := db.GetRoutineByID(id)
+		if err != nil {
+			showError(w, "Failed to retrieve routine: "+err.Error())
+			return
+		}
+
+		var buf bytes.Buffer
+		if err := database.NewRoutineDocument(routine).Encode(&buf, format); err != nil {
+			showError(w, "Failed to export routine: "+err.Error())
+			return
+		}
+
+		contentType := "application/json"
+		if format == "yaml" {
+			contentType = "application/yaml"
+		}
+		w.Header().Set("Content-Type", contentType+"; charset=utf-8")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", exportFilename(routine.Name, format)))
+		buf.WriteTo(w)
+	}
+}
+
+func postImportRoutine(db *database.Database) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			showError(w, "Failed to read uploaded file: "+err.Error())
+			return
+		}
+		defer file.Close()
+
+		data, err := io.ReadAll(file)
+		if err != nil {
+			showError(w, "Failed to read uploaded file: "+err.Error())
+			return
+		}
+
+		doc, err := database.ParseRoutineDocument(data)
+		if err != nil {
+			showError(w, "Failed to import routine: "+err.Error())
+			return
+		}
+
+		result, err := db.ImportRoutine(doc)
+		if err != nil {
+			showError(w, "Failed to import routine: "+err.Error())
+			return
+		}
+
+		if len(result.UnknownExercises) > 0 {
+			showError(w, fmt.Sprintf("Imported %q, skipping unknown exercises: %s", result.Routine.Name, strings.Join(result.UnknownExercises, ", ")))
+			return
+		}
+
+		redirect(w, r, fmt.Sprintf("/routines/%d", result.Routine.ID))
+	}
+}
+
+func getRoutineGenerate(db *database.Database) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		pageData, err := getPageData(db, "routines")
+		if err != nil {
+			showError(w, "Failed to retrieve page data: "+err.Error())
+			return
+		}
+
+		pageData.Inventory, err = db.GetEquipmentInventory(pageData.User)
+		if err != nil {
+			showError(w, "Failed to retrieve equipment: "+err.Error())
+			return
+		}
+
+		pageData.EquipmentTypes, err = db.GetEquipmentTypes()
+		if err != nil {
+			showError(w, "Failed to retrieve equipment types: "+err.Error())
+			return
+		}
+
+		executeTemplateSafe(w, routineGeneratePath, pageData)
+	}
+}
+
+func postRoutineGenerate(db *database.Database) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		if err := r.ParseForm(); err != nil {
+			showError(w, "Failed to parse form: "+err.Error())
+			return
+		}
+
+		opts := database.GeneratorOptions{
+			Name:      r.FormValue("name"),
+			Equipment: r.Form["equipment"],
+			Level:     r.FormValue("level"),
+			Goal:      r.FormValue("goal"),
+		}
+
+		var err error
+		if opts.DaysPerWeek, err = strconv.Atoi(r.FormValue("daysPerWeek")); err != nil {
+			showError(w, "Invalid days per week: "+err.Error())
+			return
+		}
+		if opts.SessionMinutes, err = strconv.Atoi(r.FormValue("sessionMinutes")); err != nil {
+			showError(w, "Invalid session length: "+err.Error())
+			return
+		}
+		if value := r.FormValue("seed"); value != "" {
+			if opts.Seed, err = strconv.ParseInt(value, 10, 64); err != nil {
+				showError(w, "Invalid seed: "+err.Error())
+				return
+			}
+		}
+
+		if _, err := db.GenerateRoutines(opts); err != nil {
+			showError(w, "Failed to generate routines: "+err.Error())
+			return
+		}
+
+		redirect(w, r, "/routines")
+	}
+}
@@ -15,27 +15,48 @@ const (
 	templates = "templates"
 	ps        = string(os.PathSeparator)
 
-	basePath        = "templates" + ps + base + ".gohtml"
-	errorPath       = "templates" + ps + "error.gohtml"
-	homePath        = "templates" + ps + "home.gohtml"
-	exercisesPath   = "templates" + ps + "exercises.gohtml"
-	exercisePath    = "templates" + ps + "exercise.gohtml"
-	routinesPath    = "templates" + ps + "routines.gohtml"
-	routinePath     = "templates" + ps + "routine.gohtml"
-	workoutsPath    = "templates" + ps + "workouts.gohtml"
-	profilePath     = "templates" + ps + "profile.gohtml"
-	profileEditPath = "templates" + ps + "profile_edit.gohtml"
+	basePath         = "templates" + ps + base + ".gohtml"
+	errorPath        = "templates" + ps + "error.gohtml"
+	homePath         = "templates" + ps + "home.gohtml"
+	exercisesPath    = "templates" + ps + "exercises.gohtml"
+	exercisePath     = "templates" + ps + "exercise.gohtml"
+	routinesPath     = "templates" + ps + "routines.gohtml"
+	routinePath      = "templates" + ps + "routine.gohtml"
+	workoutsPath     = "templates" + ps + "workouts.gohtml"
+	profilePath      = "templates" + ps + "profile.gohtml"
+	profileEditPath  = "templates" + ps + "profile_edit.gohtml"
+	measurementsPath = "templates" + ps + "measurements.gohtml"
+	measurementPath  = "templates" + ps + "measurement.gohtml"
+	equipmentPath    = "templates" + ps + "equipment.gohtml"
+	workoutPath      = "templates" + ps + "workout.gohtml"
+	calendarPath     = "templates" + ps + "calendar.gohtml"
+	programsPath     = "templates" + ps + "programs.gohtml"
+	programPath      = "templates" + ps + "program.gohtml"
+
+	routineHistoryPath  = "templates" + ps + "routine_history.gohtml"
+	workoutImportPath   = "templates" + ps + "workout_import.gohtml"
+	routineGeneratePath = "templates" + ps + "routine_generate.gohtml"
 )
 
 var (
 	tmpl    map[string]*template.Template
 	funcMap = template.FuncMap{
-		"capitalize":      g.Capitalize,
-		"coalesce":        coalesce,
-		"formatBirthDate": formatBirthDate,
-		"formatDay":       formatDay,
-		"isChecked":       isChecked,
-		"sum":             func(a, b int) int { return a + b },
+		"capitalize":       g.Capitalize,
+		"coalesce":         coalesce,
+		"formatBirthDate":  formatBirthDate,
+		"formatDay":        formatDay,
+		"formatDate":       formatDate,
+		"formatDuration":   formatDuration,
+		"formatValue":      formatValue,
+		"isChecked":        isChecked,
+		"isToday":          isToday,
+		"lengthValue":      lengthValue,
+		"loadValue":        loadValue,
+		"optional":         optional,
+		"plannedLoadValue": plannedLoadValue,
+		"plateLoad":        plateLoad,
+		"weightValue":      weightValue,
+		"sum":              func(a, b int) int { return a + b },
 	}
 )
 
@@ -44,9 +65,27 @@ type PageData struct {
 	Days           []database.Day
 	Exercises      []database.Exercise
 	Routines       []database.Routine
+	Programs       []database.Program
+	ActiveProgram  *database.Program
 	RecordRoutines []database.RecordRoutine
 	CurrentWorkout *database.RecordRoutine
 	User           *database.User
+	Inventory      *database.EquipmentInventory
+	Volume         *float64
+	BodyMetrics    []database.BodyMetric
+	Units          []string
+	Chart          *Chart
+	Calendar       *CalendarMonth
+	Today          *database.ScheduledWorkout
+	FeedURL        string
+	Versions       []RoutineVersionView
+	Workouts       []database.WorkoutSummary
+	WorkoutImport  *WorkoutImportView
+	Alternatives   map[string][]database.ExerciseAlternative
+	EquipmentTypes []string
+	Estimates      map[uint]*database.RoutineEstimate
+	SQLite         bool
+	Admin          bool
 	Message        string
 	ID             uint
 }
@@ -57,6 +96,7 @@ func getPageData(db *database.Database, page string) (pageData *PageData, err er
 		CurrentWorkout: db.GetCurrentWorkout(),
 	}
 
+	pageData.User, err = db.GetUserByID(1)
 	return
 }
 
@@ -94,31 +134,89 @@ func InitServeMux(s *http.ServeMux, db *database.Database) {
 	tmpl[workoutsPath] = parseTemplate(workoutsPath)
 	tmpl[profilePath] = parseTemplate(profilePath)
 	tmpl[profileEditPath] = parseTemplate(profileEditPath)
-
-	s.HandleFunc("GET /", getHome(db))                                // home page
-	s.HandleFunc("GET /exercises/{id}", getExercises(db))             // select exercise for routine item id
-	s.HandleFunc("GET /exercises/{id}/{exerciseId}", getExercise(db)) // confirm exercise for routine item id
-	s.HandleFunc("GET /routines", getRoutines(db))                    // list all routines
-	s.HandleFunc("GET /routines/{id}", getRoutine(db))                // edit routine
-	s.HandleFunc("GET /profile", getProfile(db))                      // user profile
-	s.HandleFunc("GET /profile/edit", getProfileEdit(db))             // edit user profile
-
-	s.HandleFunc("POST /exercises/{id}/{exerciseId}", postAddExercise(db))          // add exercise item to routine item
-	s.HandleFunc("POST /routines/new", postAddRoutines(db))                         // add new routine
-	s.HandleFunc("POST /routines/{id}", postRoutines(db))                           // edit routine (name, description)
-	s.HandleFunc("POST /routines/{id}/delete", postRoutinesDelete(db))              // delete routine
-	s.HandleFunc("POST /routines/{id}/new", postAddRoutineItems(db))                // add new routine item to routine
-	s.HandleFunc("POST /routines/{id}/start", postAddRecordRoutine(db))             // add new record routine
-	s.HandleFunc("POST /record-routines/{id}/delete", postRecordRoutinesDelete(db)) // delete record routine
-	s.HandleFunc("POST /exercise-items/{id}/up", postExerciseItemsUp(db))           // move exercise item up
-	s.HandleFunc("POST /exercise-items/{id}/down", postExerciseItemsDown(db))       // move exercise item down
-	s.HandleFunc("POST /routine-items/{id}/up", postRoutineItemsUp(db))             // move routine item up
-	s.HandleFunc("POST /routine-items/{id}/down", postRoutineItemsDown(db))         // move routine item down
-	s.HandleFunc("POST /exercise-items/{id}/delete", postExerciseItemsDelete(db))   // delete exercise item
-	s.HandleFunc("POST /exercise-items/{id}", postExerciseItems(db))                // edit exercise item (restTime, sets)
-	s.HandleFunc("POST /exercise-items/{id}/new", postAddSet(db))                   // add new set to exercise item
-	s.HandleFunc("POST /sets/{id}/delete", postSetsDelete(db))                      // delete set
-	s.HandleFunc("POST /profile/edit", postProfileEdit(db))                         // edit user profile
+	tmpl[measurementsPath] = parseTemplate(measurementsPath)
+	tmpl[measurementPath] = parseTemplate(measurementPath)
+	tmpl[equipmentPath] = parseTemplate(equipmentPath)
+	tmpl[workoutPath] = parseTemplate(workoutPath)
+	tmpl[calendarPath] = parseTemplate(calendarPath)
+	tmpl[programsPath] = parseTemplate(programsPath)
+	tmpl[programPath] = parseTemplate(programPath)
+	tmpl[routineHistoryPath] = parseTemplate(routineHistoryPath)
+	tmpl[workoutImportPath] = parseTemplate(workoutImportPath)
+	tmpl[routineGeneratePath] = parseTemplate(routineGeneratePath)
+
+	s.HandleFunc("GET /", getHome(db))                                  // home page
+	s.HandleFunc("GET /exercises/{id}", getExercises(db))               // select exercise for routine item id
+	s.HandleFunc("GET /exercises/{id}/{exerciseId}", getExercise(db))   // confirm exercise for routine item id
+	s.HandleFunc("GET /routines", getRoutines(db))                      // list all routines
+	s.HandleFunc("GET /routines/generate", getRoutineGenerate(db))      // routine generator
+	s.HandleFunc("GET /routines/{id}", getRoutine(db))                  // edit routine
+	s.HandleFunc("GET /routines/{id}/history", getRoutineHistory(db))   // routine versions
+	s.HandleFunc("GET /routines/{id}/export", getRoutineExport(db))     // download routine as JSON or YAML
+	s.HandleFunc("GET /profile", getProfile(db))                        // user profile
+	s.HandleFunc("GET /profile/edit", getProfileEdit(db))               // edit user profile
+	s.HandleFunc("GET /measurements", getMeasurements(db))              // list body metrics
+	s.HandleFunc("GET /measurements/{id}", getMeasurement(db))          // body metric history
+	s.HandleFunc("GET /profile/equipment", getEquipment(db))            // equipment inventory
+	s.HandleFunc("GET /record-routines/{id}", getRecordRoutine(db))     // workout session
+	s.HandleFunc("GET /workouts", getWorkouts(db))                      // workout log
+	s.HandleFunc("GET /workouts/export", getWorkoutsExport(db))         // download workout log as CSV
+	s.HandleFunc("GET /calendar", getCalendar(db))                      // scheduled workouts
+	s.HandleFunc("GET /programs", getPrograms(db))                      // list all programs
+	s.HandleFunc("GET /programs/{id}", getProgram(db))                  // edit program
+	s.HandleFunc("GET /calendar/{token}/feed.ics", getCalendarFeed(db)) // iCalendar feed
+	s.HandleFunc("GET /backup/archive", getBackupArchive(db))           // download full data archive
+	s.HandleFunc("GET /backup/database", getBackupDatabase(db))         // download database snapshot
+
+	s.HandleFunc("POST /exercises/{id}/{exerciseId}", postAddExercise(db))                    // add exercise item to routine item
+	s.HandleFunc("POST /routines/new", postAddRoutines(db))                                   // add new routine
+	s.HandleFunc("POST /routines/generate", postRoutineGenerate(db))                          // generate routines from goals and constraints
+	s.HandleFunc("POST /routines/import", postImportRoutine(db))                              // import routine from JSON or YAML
+	s.HandleFunc("POST /routines/{id}", postRoutines(db))                                     // edit routine (name, description)
+	s.HandleFunc("POST /routines/{id}/clone", postRoutineClone(db))                           // clone routine or use template
+	s.HandleFunc("POST /routines/{id}/delete", postRoutinesDelete(db))                        // delete routine
+	s.HandleFunc("POST /routines/{id}/new", postAddRoutineItems(db))                          // add new routine item to routine
+	s.HandleFunc("POST /routines/{id}/start", postAddRecordRoutine(db))                       // add new record routine
+	s.HandleFunc("POST /record-routines/{id}/delete", postRecordRoutinesDelete(db))           // delete record routine
+	s.HandleFunc("POST /record-routines/{id}", postRecordRoutine(db))                         // save workout session sets
+	s.HandleFunc("POST /record-routines/{id}/finish", postRecordRoutineFinish(db))            // finish workout session
+	s.HandleFunc("POST /record-routines/new", postAddFreestyleRecordRoutine(db))              // start workout without a routine
+	s.HandleFunc("POST /record-routines/{id}/exercises", postAddRecordExercise(db))           // add exercise to workout session
+	s.HandleFunc("POST /record-routines/{id}/sets", postAddRecordSet(db))                     // add set to workout session exercise
+	s.HandleFunc("POST /record-routines/{id}/swap", postRecordExerciseSwap(db))               // swap workout session exercise
+	s.HandleFunc("POST /record-routines/{id}/skip", postRecordExerciseSkip(db))               // skip workout session exercise
+	s.HandleFunc("POST /record-routines/{id}/up", postRecordRoutineItemUp(db))                // move workout session item up
+	s.HandleFunc("POST /record-routines/{id}/down", postRecordRoutineItemDown(db))            // move workout session item down
+	s.HandleFunc("POST /record-routines/{id}/routine", postRecordRoutineSaveRoutine(db))      // save workout as new routine
+	s.HandleFunc("POST /workouts/import", postWorkoutsImport(db))                             // upload workout history from CSV
+	s.HandleFunc("POST /workouts/import/confirm", postWorkoutsImportConfirm(db))              // import workout history with the chosen exercises
+	s.HandleFunc("POST /programs/new", postAddProgram(db))                                    // add new program
+	s.HandleFunc("POST /programs/{id}", postProgram(db))                                      // edit program (name, description, weeks)
+	s.HandleFunc("POST /programs/{id}/delete", postProgramDelete(db))                         // delete program
+	s.HandleFunc("POST /programs/{id}/weeks", postProgramWeeks(db))                           // edit program week modifiers
+	s.HandleFunc("POST /programs/{id}/routines", postAddProgramRoutine(db))                   // add routine to program
+	s.HandleFunc("POST /programs/{id}/routines/{index}/delete", postProgramRoutineDelete(db)) // remove routine from program
+	s.HandleFunc("POST /programs/{id}/start", postProgramStart(db))                           // start following program
+	s.HandleFunc("POST /programs/{id}/stop", postProgramStop(db))                             // stop following program
+	s.HandleFunc("POST /exercise-items/{id}/up", postExerciseItemsUp(db))                     // move exercise item up
+	s.HandleFunc("POST /exercise-items/{id}/down", postExerciseItemsDown(db))                 // move exercise item down
+	s.HandleFunc("POST /routine-items/{id}/up", postRoutineItemsUp(db))                       // move routine item up
+	s.HandleFunc("POST /routine-items/{id}/down", postRoutineItemsDown(db))                   // move routine item down
+	s.HandleFunc("POST /exercise-items/{id}/delete", postExerciseItemsDelete(db))             // delete exercise item
+	s.HandleFunc("POST /exercise-items/{id}", postExerciseItems(db))                          // edit exercise item (restTime, sets)
+	s.HandleFunc("POST /exercise-items/{id}/new", postAddSet(db))                             // add new set to exercise item
+	s.HandleFunc("POST /sets/{id}/delete", postSetsDelete(db))                                // delete set
+	s.HandleFunc("POST /profile/edit", postProfileEdit(db))                                   // edit user profile
+	s.HandleFunc("POST /profile/feed-token", postFeedTokenReset(db))                          // reset calendar feed URL
+	s.HandleFunc("POST /profile/credentials", postProfileCredentials(db))                     // set username and password
+	s.HandleFunc("POST /profile/equipment", postEquipment(db))                                // edit equipment inventory
+	s.HandleFunc("POST /backup/restore", postRestoreBackup(db))                               // restore data archive
+	s.HandleFunc("POST /measurements/new", postAddBodyMetric(db))                             // add new body metric
+	s.HandleFunc("POST /measurements/import", postImportBodyMeasurements(db))                 // import body measurements from CSV
+	s.HandleFunc("POST /measurements/{id}", postBodyMetric(db))                               // edit body metric (name, unit)
+	s.HandleFunc("POST /measurements/{id}/delete", postBodyMetricDelete(db))                  // delete body metric
+	s.HandleFunc("POST /measurements/{id}/new", postAddBodyMeasurement(db))                   // add new measurement to body metric
+	s.HandleFunc("POST /body-measurements/{id}/delete", postBodyMeasurementDelete(db))        // delete measurement
 
 	s.HandleFunc("GET /static/", func(w http.ResponseWriter, r *http.Request) {
 		http.StripPrefix("/static/", http.FileServer(http.Dir("static"))).ServeHTTP(w, r)
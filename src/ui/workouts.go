@@ -0,0 +1,165 @@
+package ui
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/birabittoh/go-lift/src/database"
+)
+
+func getWorkouts(db *database.Database) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		pageData, err := getPageData(db, "workouts")
+		if err != nil {
+			showError(w, "Failed to retrieve page data: "+err.Error())
+			return
+		}
+
+		workouts, err := db.GetWorkoutSummaries(time.Time{})
+		if err != nil {
+			showError(w, "Failed to retrieve workouts: "+err.Error())
+			return
+		}
+
+		// Newest first
+		for i := len(workouts) - 1; i >= 0; i-- {
+			pageData.Workouts = append(pageData.Workouts, workouts[i])
+		}
+
+		executeTemplateSafe(w, workoutsPath, pageData)
+	}
+}
+
+func getWorkoutsExport(db *database.Database) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		from := time.Time{}
+		to := time.Now()
+
+		var err error
+		if value := r.FormValue("from"); value != "" {
+			from, err = time.ParseInLocation("2006-01-02", value, time.Local)
+			if err != nil {
+				showError(w, "Invalid from date: "+err.Error())
+				return
+			}
+		}
+		if value := r.FormValue("to"); value != "" {
+			to, err = time.ParseInLocation("2006-01-02", value, time.Local)
+			if err != nil {
+				showError(w, "Invalid to date: "+err.Error())
+				return
+			}
+		}
+
+		format := r.FormValue("format")
+		if format != database.CSVFormatStrong && format != database.CSVFormatHevy {
+			showError(w, "Unsupported export format: "+format)
+			return
+		}
+
+		records, err := db.GetWorkoutLog(from, to.AddDate(0, 0, 1))
+		if err != nil {
+			showError(w, "Failed to retrieve workouts: "+err.Error())
+			return
+		}
+
+		user, err := db.GetUserByID(1)
+		if err != nil {
+			showError(w, "Failed to retrieve user: "+err.Error())
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", "go-lift-workouts-"+format+".csv"))
+		database.WriteWorkoutsCSV(w, records, format, user.Units())
+	}
+}
+
+// WorkoutImportView carries the uploaded file through the exercise mapping step
+type WorkoutImportView struct {
+	*database.WorkoutImport
+	Data       string
+	WeightUnit string
+}
+
+func postWorkoutsImport(db *database.Database) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			showError(w, "Failed to read uploaded file: "+err.Error())
+			return
+		}
+		defer file.Close()
+
+		data, err := io.ReadAll(file)
+		if err != nil {
+			showError(w, "Failed to read uploaded file: "+err.Error())
+			return
+		}
+
+		weightUnit := r.FormValue("weightUnit")
+		imp, err := db.PrepareWorkoutImport(data, weightUnit)
+		if err != nil {
+			showError(w, "Failed to import workouts: "+err.Error())
+			return
+		}
+
+		pageData, err := getPageData(db, "workouts")
+		if err != nil {
+			showError(w, "Failed to retrieve page data: "+err.Error())
+			return
+		}
+
+		pageData.Exercises, err = db.GetExercises()
+		if err != nil {
+			showError(w, "Failed to retrieve exercises: "+err.Error())
+			return
+		}
+
+		pageData.WorkoutImport = &WorkoutImportView{WorkoutImport: imp, Data: string(data), WeightUnit: weightUnit}
+		executeTemplateSafe(w, workoutImportPath, pageData)
+	}
+}
+
+func postWorkoutsImportConfirm(db *database.Database) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			showError(w, "Failed to parse form: "+err.Error())
+			return
+		}
+
+		imp, err := db.PrepareWorkoutImport([]byte(r.FormValue("data")), r.FormValue("weightUnit"))
+		if err != nil {
+			showError(w, "Failed to import workouts: "+err.Error())
+			return
+		}
+
+		// An exercise typed in by hand wins over the selected suggestion
+		mapping := map[string]string{}
+		for i := range imp.Exercises {
+			prefix := fmt.Sprintf("exercises[%d]", i)
+			name := r.FormValue(prefix + "[name]")
+			id := r.FormValue(prefix + "[id]")
+			if other := strings.TrimSpace(r.FormValue(prefix + "[other]")); other != "" {
+				id = other
+			}
+			mapping[name] = id
+		}
+
+		result, err := db.ImportWorkouts(imp, mapping)
+		if err != nil {
+			showError(w, "Failed to import workouts: "+err.Error())
+			return
+		}
+
+		if len(result.Errors) > 0 {
+			showError(w, fmt.Sprintf("Imported %d workouts with %d errors: %v", result.Imported, len(result.Errors), result.Errors))
+			return
+		}
+
+		redirect(w, r, "/workouts")
+	}
+}
@@ -0,0 +1,120 @@
+package src
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/birabittoh/go-lift/src/config"
+	"github.com/birabittoh/go-lift/src/database"
+	"golang.org/x/term"
+	"gorm.io/gorm"
+)
+
+// readPassword asks for a password without echoing it when the standard
+// input is a terminal, and otherwise reads it from the first line
+func readPassword() (string, error) {
+	if fd := int(os.Stdin.Fd()); term.IsTerminal(fd) {
+		fmt.Fprint(os.Stderr, "Password: ")
+		password, err := term.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return "", fmt.Errorf("failed to read password: %w", err)
+		}
+		return string(password), nil
+	}
+
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return "", fmt.Errorf("failed to read password: %w", err)
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// runCreateUser adds a user who can sign in with a password
+func runCreateUser(cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("create-user", flag.ExitOnError)
+	name := fs.String("name", "", "display name (default: the username)")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: go-lift create-user [-name name] <username>")
+		fmt.Fprintln(fs.Output(), "The password is read from the standard input.")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	db, err := database.InitializeDB(cfg)
+	if err != nil {
+		return err
+	}
+
+	password, err := readPassword()
+	if err != nil {
+		return err
+	}
+
+	user, err := db.CreateUser(*name, fs.Arg(0), password)
+	if err != nil {
+		return err
+	}
+
+	slog.Info("User created", "username", *user.Username, "id", user.ID)
+	return nil
+}
+
+// runResetPassword sets the password of a user, and with -id their username
+func runResetPassword(cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("reset-password", flag.ExitOnError)
+	id := fs.Uint("id", 0, "set the username and password of the user with this ID, instead of finding them by username")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: go-lift reset-password [-id id] <username>")
+		fmt.Fprintln(fs.Output(), "The password is read from the standard input.")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	username := fs.Arg(0)
+
+	db, err := database.InitializeDB(cfg)
+	if err != nil {
+		return err
+	}
+
+	var user *database.User
+	if *id > 0 {
+		user, err = db.GetUserByID(*id)
+	} else {
+		user, err = db.GetUserByUsername(username)
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("user not found, use -id to pick a user without a username")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to retrieve user: %w", err)
+	}
+
+	password, err := readPassword()
+	if err != nil {
+		return err
+	}
+	if password == "" {
+		return fmt.Errorf("password is required")
+	}
+
+	if err := db.SetUserCredentials(user, username, password); err != nil {
+		return err
+	}
+
+	slog.Info("Password updated", "username", username)
+	return nil
+}
@@ -0,0 +1,158 @@
+package src
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/birabittoh/go-lift/src/config"
+	"github.com/birabittoh/go-lift/src/database"
+)
+
+const dateLayout = "2006-01-02"
+
+// exerciseMapping collects repeated -map name=id flags
+type exerciseMapping map[string]string
+
+func (m exerciseMapping) String() string {
+	return fmt.Sprint(map[string]string(m))
+}
+
+func (m exerciseMapping) Set(value string) error {
+	name, id, ok := strings.Cut(value, "=")
+	if !ok || strings.TrimSpace(name) == "" {
+		return fmt.Errorf("expected name=id, got %q", value)
+	}
+	m[strings.TrimSpace(name)] = strings.TrimSpace(id)
+	return nil
+}
+
+// runExport writes the finished workouts as CSV
+func runExport(cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	format := fs.String("format", database.CSVFormatStrong, "column layout, strong or hevy")
+	from := fs.String("from", "", "first day to export, as YYYY-MM-DD (default: the first workout)")
+	to := fs.String("to", "", "last day to export, as YYYY-MM-DD (default: today)")
+	output := fs.String("o", "", "output file (default: standard output)")
+	fs.Parse(args)
+
+	if *format != database.CSVFormatStrong && *format != database.CSVFormatHevy {
+		return fmt.Errorf("unsupported format: %s", *format)
+	}
+
+	start, end := time.Time{}, time.Now()
+	var err error
+	if *from != "" {
+		if start, err = time.ParseInLocation(dateLayout, *from, time.Local); err != nil {
+			return fmt.Errorf("invalid from date: %s", *from)
+		}
+	}
+	if *to != "" {
+		if end, err = time.ParseInLocation(dateLayout, *to, time.Local); err != nil {
+			return fmt.Errorf("invalid to date: %s", *to)
+		}
+	}
+
+	db, err := database.InitializeDB(cfg)
+	if err != nil {
+		return err
+	}
+
+	records, err := db.GetWorkoutLog(start, end.AddDate(0, 0, 1))
+	if err != nil {
+		return err
+	}
+
+	user, err := db.GetUserByID(1)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve user: %w", err)
+	}
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			return fmt.Errorf("failed to create file: %w", err)
+		}
+		defer f.Close()
+		w = f
+	}
+
+	if err := database.WriteWorkoutsCSV(w, records, *format, user.Units()); err != nil {
+		return fmt.Errorf("failed to write workouts: %w", err)
+	}
+
+	if *output != "" {
+		slog.Info("Workouts exported", "workouts", len(records), "path", *output)
+	}
+	return nil
+}
+
+// runImport imports the workouts of a Strong, Hevy or FitNotes CSV export
+func runImport(cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	weightUnit := fs.String("weight-unit", "", "weight unit, kg or lb, used when the file does not state it")
+	dryRun := fs.Bool("dry-run", false, "only show how exercises would be matched")
+	mapping := exerciseMapping{}
+	fs.Var(mapping, "map", "use an exercise for a name of the file, as name=id (empty id to skip it), can be repeated")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: go-lift import [-weight-unit kg|lb] [-map name=id]... [-dry-run] <file.csv>")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	data, err := os.ReadFile(fs.Arg(0))
+	if err != nil {
+		return fmt.Errorf("failed to read file: %w", err)
+	}
+
+	db, err := database.InitializeDB(cfg)
+	if err != nil {
+		return err
+	}
+
+	imp, err := db.PrepareWorkoutImport(data, *weightUnit)
+	if err != nil {
+		return err
+	}
+
+	if *dryRun {
+		fmt.Printf("%d workouts in %s format\n", len(imp.Workouts), imp.Format)
+		for _, match := range imp.Exercises {
+			id, ok := mapping[match.Name]
+			if !ok {
+				id = match.ExerciseID
+			}
+			if id == "" {
+				id = "(skipped)"
+			}
+			fmt.Printf("%-40s %4d sets  %s\n", match.Name, match.Sets, id)
+		}
+		for _, e := range imp.Errors {
+			fmt.Println("error:", e)
+		}
+		return nil
+	}
+
+	result, err := db.ImportWorkouts(imp, mapping)
+	if err != nil {
+		return err
+	}
+
+	for _, e := range result.Errors {
+		slog.Warn("Skipped row", "error", e)
+	}
+	if len(result.SkippedExercises) > 0 {
+		slog.Warn("Skipped exercises", "names", strings.Join(result.SkippedExercises, ", "))
+	}
+	slog.Info("Workouts imported", "workouts", result.Imported, "sets", result.Sets, "duplicates", result.Duplicates)
+	return nil
+}